@@ -0,0 +1,265 @@
+// File: backend/controllers/basket_controller.go
+
+package controllers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/shyamanurag/stock-trading-app/backend/internal/models"
+	"github.com/shyamanurag/stock-trading-app/backend/internal/services"
+)
+
+// BasketController handles basket-related API requests
+type BasketController struct {
+	basketService *services.BasketService
+}
+
+// NewBasketController creates a new BasketController
+func NewBasketController(basketService *services.BasketService) *BasketController {
+	return &BasketController{
+		basketService: basketService,
+	}
+}
+
+// PlaceBasket godoc
+// @Summary Place a basket of orders
+// @Description Place several orders together. Either every order is placed or the whole basket is rejected.
+// @Tags baskets
+// @Accept json
+// @Produce json
+// @Param request body models.PlaceBasketRequest true "Orders"
+// @Success 201 {object} models.BasketOrder
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Security BearerAuth
+// @Router /baskets/place [post]
+func (bc *BasketController) PlaceBasket(c *gin.Context) {
+	userID := c.GetString("userID")
+
+	var request models.PlaceBasketRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid basket request: " + err.Error(),
+		})
+		return
+	}
+
+	basketOrder, err := bc.basketService.PlaceBasket(c.Request.Context(), userID, &request)
+	respondBasketOrder(c, basketOrder, err)
+}
+
+// SaveBasket godoc
+// @Summary Save a basket
+// @Description Save a named basket of orders, replacing any basket with the same name
+// @Tags baskets
+// @Accept json
+// @Produce json
+// @Param request body models.SaveBasketRequest true "Basket"
+// @Success 200 {object} models.Basket
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Security BearerAuth
+// @Router /baskets [post]
+func (bc *BasketController) SaveBasket(c *gin.Context) {
+	userID := c.GetString("userID")
+
+	var request models.SaveBasketRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid basket request: " + err.Error(),
+		})
+		return
+	}
+
+	basket, err := bc.basketService.SaveBasket(c.Request.Context(), userID, &request)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Failed to save basket: " + err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, basket)
+}
+
+// ListBaskets godoc
+// @Summary List saved baskets
+// @Description Get the user's saved baskets
+// @Tags baskets
+// @Produce json
+// @Success 200 {array} models.Basket
+// @Failure 401 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Security BearerAuth
+// @Router /baskets [get]
+func (bc *BasketController) ListBaskets(c *gin.Context) {
+	userID := c.GetString("userID")
+
+	baskets, err := bc.basketService.ListBaskets(c.Request.Context(), userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to get baskets: " + err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, baskets)
+}
+
+// GetBasket godoc
+// @Summary Get a saved basket
+// @Description Get one of the user's saved baskets by name
+// @Tags baskets
+// @Produce json
+// @Param name path string true "Basket name"
+// @Success 200 {object} models.Basket
+// @Failure 401 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Security BearerAuth
+// @Router /baskets/{name} [get]
+func (bc *BasketController) GetBasket(c *gin.Context) {
+	userID := c.GetString("userID")
+
+	basket, err := bc.basketService.GetBasket(c.Request.Context(), userID, c.Param("name"))
+	if err != nil {
+		c.JSON(basketErrorStatus(err), gin.H{
+			"error": "Failed to get basket: " + err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, basket)
+}
+
+// DeleteBasket godoc
+// @Summary Delete a saved basket
+// @Description Delete one of the user's saved baskets by name
+// @Tags baskets
+// @Produce json
+// @Param name path string true "Basket name"
+// @Success 200 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Security BearerAuth
+// @Router /baskets/{name} [delete]
+func (bc *BasketController) DeleteBasket(c *gin.Context) {
+	userID := c.GetString("userID")
+
+	if err := bc.basketService.DeleteBasket(c.Request.Context(), userID, c.Param("name")); err != nil {
+		c.JSON(basketErrorStatus(err), gin.H{
+			"error": "Failed to delete basket: " + err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Basket deleted",
+	})
+}
+
+// ExecuteBasket godoc
+// @Summary Place a saved basket
+// @Description Place every order in one of the user's saved baskets. Either every order is placed or the whole basket is rejected.
+// @Tags baskets
+// @Produce json
+// @Param name path string true "Basket name"
+// @Success 201 {object} models.BasketOrder
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Security BearerAuth
+// @Router /baskets/{name}/execute [post]
+func (bc *BasketController) ExecuteBasket(c *gin.Context) {
+	userID := c.GetString("userID")
+
+	basketOrder, err := bc.basketService.ExecuteBasket(c.Request.Context(), userID, c.Param("name"))
+	respondBasketOrder(c, basketOrder, err)
+}
+
+// ListBasketOrders godoc
+// @Summary List basket orders
+// @Description Get the user's recent basket placements with their orders and status
+// @Tags baskets
+// @Produce json
+// @Param limit query int false "Limit results (default 20, max 100)"
+// @Success 200 {array} models.BasketOrder
+// @Failure 401 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Security BearerAuth
+// @Router /basket-orders [get]
+func (bc *BasketController) ListBasketOrders(c *gin.Context) {
+	userID := c.GetString("userID")
+
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "20"))
+	if err != nil || limit <= 0 {
+		limit = 20
+	} else if limit > 100 {
+		limit = 100
+	}
+
+	basketOrders, err := bc.basketService.ListBasketOrders(c.Request.Context(), userID, limit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to get basket orders: " + err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, basketOrders)
+}
+
+// GetBasketOrder godoc
+// @Summary Get a basket order
+// @Description Get one of the user's basket placements with its orders and status
+// @Tags baskets
+// @Produce json
+// @Param id path string true "Basket order ID"
+// @Success 200 {object} models.BasketOrder
+// @Failure 401 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Security BearerAuth
+// @Router /basket-orders/{id} [get]
+func (bc *BasketController) GetBasketOrder(c *gin.Context) {
+	userID := c.GetString("userID")
+
+	basketOrder, err := bc.basketService.GetBasketOrder(c.Request.Context(), c.Param("id"), userID)
+	if err != nil {
+		c.JSON(basketErrorStatus(err), gin.H{
+			"error": "Failed to get basket order: " + err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, basketOrder)
+}
+
+// respondBasketOrder writes the result of placing a basket. A rejected
+// basket is returned with the reason it was rejected.
+func respondBasketOrder(c *gin.Context, basketOrder *models.BasketOrder, err error) {
+	if err != nil {
+		if basketOrder != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error":  "Basket rejected: " + err.Error(),
+				"basket": basketOrder,
+			})
+			return
+		}
+		c.JSON(basketErrorStatus(err), gin.H{
+			"error": "Failed to place basket: " + err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusCreated, basketOrder)
+}
+
+// basketErrorStatus maps a basket service error to an HTTP status
+func basketErrorStatus(err error) int {
+	if errors.Is(err, services.ErrBasketNotFound) {
+		return http.StatusNotFound
+	}
+	return http.StatusBadRequest
+}
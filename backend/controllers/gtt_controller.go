@@ -0,0 +1,205 @@
+// File: backend/controllers/gtt_controller.go
+
+package controllers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/shyamanurag/stock-trading-app/backend/internal/models"
+	"github.com/shyamanurag/stock-trading-app/backend/internal/services"
+)
+
+// GTTController handles GTT-related API requests
+type GTTController struct {
+	gttService *services.GTTService
+}
+
+// NewGTTController creates a new GTTController
+func NewGTTController(gttService *services.GTTService) *GTTController {
+	return &GTTController{
+		gttService: gttService,
+	}
+}
+
+// CreateGTT godoc
+// @Summary Create a GTT
+// @Description Create a good-till-triggered order with one trigger or a target and stop-loss pair
+// @Tags gtt
+// @Accept json
+// @Produce json
+// @Param request body models.CreateGTTRequest true "GTT details"
+// @Success 201 {object} models.GTT
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Security BearerAuth
+// @Router /gtt [post]
+func (gc *GTTController) CreateGTT(c *gin.Context) {
+	userID := c.GetString("userID")
+
+	var request models.CreateGTTRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid GTT request: " + err.Error(),
+		})
+		return
+	}
+
+	gtt, err := gc.gttService.CreateGTT(c.Request.Context(), userID, &request)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Failed to create GTT: " + err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gtt)
+}
+
+// ListGTTs godoc
+// @Summary List GTTs
+// @Description Get the user's GTTs, optionally filtered by status
+// @Tags gtt
+// @Produce json
+// @Param status query string false "Status (ACTIVE, TRIGGERED, CANCELLED, EXPIRED, REJECTED)"
+// @Success 200 {array} models.GTT
+// @Failure 401 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Security BearerAuth
+// @Router /gtt [get]
+func (gc *GTTController) ListGTTs(c *gin.Context) {
+	userID := c.GetString("userID")
+	status := models.GTTStatus(c.Query("status"))
+
+	gtts, err := gc.gttService.ListGTTs(c.Request.Context(), userID, status)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to get GTTs: " + err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gtts)
+}
+
+// GetGTT godoc
+// @Summary Get a GTT
+// @Description Get one of the user's GTTs
+// @Tags gtt
+// @Produce json
+// @Param id path string true "GTT ID"
+// @Success 200 {object} models.GTT
+// @Failure 401 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Security BearerAuth
+// @Router /gtt/{id} [get]
+func (gc *GTTController) GetGTT(c *gin.Context) {
+	userID := c.GetString("userID")
+
+	gtt, err := gc.gttService.GetGTT(c.Request.Context(), c.Param("id"), userID)
+	if err != nil {
+		c.JSON(gttErrorStatus(err), gin.H{
+			"error": "Failed to get GTT: " + err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gtt)
+}
+
+// ModifyGTT godoc
+// @Summary Modify a GTT
+// @Description Change the legs or expiry of an active GTT
+// @Tags gtt
+// @Accept json
+// @Produce json
+// @Param id path string true "GTT ID"
+// @Param request body models.ModifyGTTRequest true "Changes"
+// @Success 200 {object} models.GTT
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Security BearerAuth
+// @Router /gtt/{id} [put]
+func (gc *GTTController) ModifyGTT(c *gin.Context) {
+	userID := c.GetString("userID")
+
+	var request models.ModifyGTTRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid GTT request: " + err.Error(),
+		})
+		return
+	}
+
+	gtt, err := gc.gttService.ModifyGTT(c.Request.Context(), c.Param("id"), userID, &request)
+	if err != nil {
+		c.JSON(gttErrorStatus(err), gin.H{
+			"error": "Failed to modify GTT: " + err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gtt)
+}
+
+// CancelGTT godoc
+// @Summary Cancel a GTT
+// @Description Cancel an active GTT
+// @Tags gtt
+// @Produce json
+// @Param id path string true "GTT ID"
+// @Success 200 {object} map[string]string
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Security BearerAuth
+// @Router /gtt/{id} [delete]
+func (gc *GTTController) CancelGTT(c *gin.Context) {
+	userID := c.GetString("userID")
+
+	if err := gc.gttService.CancelGTT(c.Request.Context(), c.Param("id"), userID); err != nil {
+		c.JSON(gttErrorStatus(err), gin.H{
+			"error": "Failed to cancel GTT: " + err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "GTT cancelled",
+	})
+}
+
+// GetGTTHistory godoc
+// @Summary Get GTT status history
+// @Description Get every status change of one of the user's GTTs
+// @Tags gtt
+// @Produce json
+// @Param id path string true "GTT ID"
+// @Success 200 {array} models.GTTStatusChange
+// @Failure 401 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Security BearerAuth
+// @Router /gtt/{id}/history [get]
+func (gc *GTTController) GetGTTHistory(c *gin.Context) {
+	userID := c.GetString("userID")
+
+	history, err := gc.gttService.GetGTTHistory(c.Request.Context(), c.Param("id"), userID)
+	if err != nil {
+		c.JSON(gttErrorStatus(err), gin.H{
+			"error": "Failed to get GTT history: " + err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, history)
+}
+
+// gttErrorStatus maps a GTT service error to an HTTP status
+func gttErrorStatus(err error) int {
+	if errors.Is(err, services.ErrGTTNotFound) {
+		return http.StatusNotFound
+	}
+	return http.StatusBadRequest
+}
@@ -0,0 +1,128 @@
+// File: backend/controllers/halt_controller.go
+
+package controllers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/shyamanurag/stock-trading-app/backend/internal/models"
+	"github.com/shyamanurag/stock-trading-app/backend/internal/services"
+)
+
+// HaltController handles trading halt and kill switch API requests
+type HaltController struct {
+	killSwitch *services.KillSwitch
+}
+
+// NewHaltController creates a new HaltController
+func NewHaltController(killSwitch *services.KillSwitch) *HaltController {
+	return &HaltController{
+		killSwitch: killSwitch,
+	}
+}
+
+// HaltTrading godoc
+// @Summary Halt trading
+// @Description Block new orders for a user, a symbol, a segment or the whole platform, optionally cancelling every open order the halt covers. Users can only halt their own trading, optionally until a set time as self-exclusion; other scopes need an admin.
+// @Tags halts
+// @Accept json
+// @Produce json
+// @Param request body models.HaltRequest true "Halt"
+// @Success 201 {object} models.TradingHalt
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Security BearerAuth
+// @Router /trading/halts [post]
+func (hc *HaltController) HaltTrading(c *gin.Context) {
+	userID := c.GetString("userID")
+
+	var request models.HaltRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid halt request: " + err.Error(),
+		})
+		return
+	}
+
+	halt, err := hc.killSwitch.Halt(c.Request.Context(), userID, &request)
+	if err != nil {
+		if halt != nil {
+			c.JSON(http.StatusCreated, gin.H{
+				"error": "Trading halted but open orders were not all cancelled: " + err.Error(),
+				"halt":  halt,
+			})
+			return
+		}
+		c.JSON(haltErrorStatus(err), gin.H{
+			"error": "Failed to halt trading: " + err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusCreated, halt)
+}
+
+// ListHalts godoc
+// @Summary List trading halts
+// @Description Get the trading halts in force. Admins see every halt; users see their own and those covering instruments, segments or the platform.
+// @Tags halts
+// @Produce json
+// @Success 200 {array} models.TradingHalt
+// @Failure 401 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Security BearerAuth
+// @Router /trading/halts [get]
+func (hc *HaltController) ListHalts(c *gin.Context) {
+	userID := c.GetString("userID")
+
+	halts, err := hc.killSwitch.Active(c.Request.Context(), userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to get halts: " + err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, halts)
+}
+
+// LiftHalt godoc
+// @Summary Lift a trading halt
+// @Description Lift a trading halt. Users can only lift halts they placed on themselves without an end time.
+// @Tags halts
+// @Produce json
+// @Param id path string true "Halt ID"
+// @Success 200 {object} models.TradingHalt
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Security BearerAuth
+// @Router /trading/halts/{id} [delete]
+func (hc *HaltController) LiftHalt(c *gin.Context) {
+	userID := c.GetString("userID")
+
+	halt, err := hc.killSwitch.Lift(c.Request.Context(), userID, c.Param("id"))
+	if err != nil {
+		c.JSON(haltErrorStatus(err), gin.H{
+			"error": "Failed to lift halt: " + err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, halt)
+}
+
+// haltErrorStatus maps a kill switch error to an HTTP status
+func haltErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, services.ErrHaltNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, services.ErrHaltNotPermitted):
+		return http.StatusForbidden
+	}
+	return http.StatusBadRequest
+}
@@ -0,0 +1,107 @@
+// File: backend/controllers/order_controller.go
+
+package controllers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/shyamanurag/stock-trading-app/backend/internal/models"
+	"github.com/shyamanurag/stock-trading-app/backend/internal/services"
+)
+
+// OrderController handles order-related API requests
+type OrderController struct {
+	tradingService *services.TradingService
+}
+
+// NewOrderController creates a new OrderController
+func NewOrderController(tradingService *services.TradingService) *OrderController {
+	return &OrderController{
+		tradingService: tradingService,
+	}
+}
+
+// PlaceOrder godoc
+// @Summary Place a new order
+// @Description Place a trading order. Resubmitting a clientOrderId already used within the last 24 hours returns the original order with duplicate set instead of placing another.
+// @Tags orders
+// @Accept json
+// @Produce json
+// @Param request body models.PlaceOrderRequest true "Order"
+// @Success 201 {object} models.OrderResponse
+// @Success 200 {object} models.OrderResponse "Duplicate of an earlier order"
+// @Failure 400 {object} models.OrderResponse "Order rejected"
+// @Failure 401 {object} map[string]string
+// @Failure 409 {object} map[string]string
+// @Security BearerAuth
+// @Router /trading/order [post]
+func (oc *OrderController) PlaceOrder(c *gin.Context) {
+	userID := c.GetString("userID")
+
+	var request models.PlaceOrderRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid order request: " + err.Error(),
+		})
+		return
+	}
+
+	response, err := oc.tradingService.SubmitOrder(c.Request.Context(), userID, &request)
+	if err != nil {
+		c.JSON(orderErrorStatus(err), gin.H{
+			"error": "Failed to place order: " + err.Error(),
+		})
+		return
+	}
+
+	switch {
+	case !response.Success:
+		c.JSON(http.StatusBadRequest, response)
+	case response.Duplicate:
+		c.JSON(http.StatusOK, response)
+	default:
+		c.JSON(http.StatusCreated, response)
+	}
+}
+
+// PreviewCharges godoc
+// @Summary Preview trade charges
+// @Description Get the itemised brokerage and statutory charges a trade would attract
+// @Tags orders
+// @Accept json
+// @Produce json
+// @Param request body models.ChargesRequest true "Trade details"
+// @Success 200 {object} models.ChargesBreakup
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Security BearerAuth
+// @Router /trading/charges [post]
+func (oc *OrderController) PreviewCharges(c *gin.Context) {
+	var request models.ChargesRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid charges request: " + err.Error(),
+		})
+		return
+	}
+
+	charges, err := oc.tradingService.PreviewCharges(c.Request.Context(), &request)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Failed to calculate charges: " + err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, charges)
+}
+
+// orderErrorStatus maps an order placement error to an HTTP status
+func orderErrorStatus(err error) int {
+	if errors.Is(err, services.ErrIdempotencyConflict) || errors.Is(err, services.ErrRequestInProgress) {
+		return http.StatusConflict
+	}
+	return http.StatusBadRequest
+}
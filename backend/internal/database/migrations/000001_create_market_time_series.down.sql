@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS candles;
+DROP TABLE IF EXISTS market_ticks;
@@ -0,0 +1,46 @@
+-- Ticks and OHLC bars are append-only and always read by instrument and time
+-- range, so both tables are range-partitioned on timestamp and carry BRIN
+-- indexes, which stay small on time-ordered data. Partitions are created
+-- ahead of time and dropped for retention by the time series store.
+
+CREATE TABLE IF NOT EXISTS market_ticks (
+    exchange      VARCHAR(10)      NOT NULL,
+    symbol        TEXT             NOT NULL,
+    timestamp     TIMESTAMPTZ      NOT NULL,
+    last_price    DOUBLE PRECISION NOT NULL,
+    volume        BIGINT           NOT NULL DEFAULT 0,
+    bid           DOUBLE PRECISION NOT NULL DEFAULT 0,
+    bid_qty       INTEGER          NOT NULL DEFAULT 0,
+    ask           DOUBLE PRECISION NOT NULL DEFAULT 0,
+    ask_qty       INTEGER          NOT NULL DEFAULT 0,
+    open_interest BIGINT           NOT NULL DEFAULT 0
+) PARTITION BY RANGE (timestamp);
+
+CREATE INDEX IF NOT EXISTS idx_market_ticks_timestamp_brin ON market_ticks USING BRIN (timestamp);
+CREATE INDEX IF NOT EXISTS idx_market_ticks_instrument ON market_ticks (exchange, symbol, timestamp);
+
+-- Bars are split by interval first so each interval can have its own
+-- retention, then by month
+CREATE TABLE IF NOT EXISTS candles (
+    exchange   VARCHAR(10)      NOT NULL,
+    symbol     TEXT             NOT NULL,
+    "interval" VARCHAR(5)       NOT NULL,
+    timestamp  TIMESTAMPTZ      NOT NULL,
+    open       DOUBLE PRECISION NOT NULL,
+    high       DOUBLE PRECISION NOT NULL,
+    low        DOUBLE PRECISION NOT NULL,
+    close      DOUBLE PRECISION NOT NULL,
+    volume     BIGINT           NOT NULL DEFAULT 0,
+    created_at TIMESTAMPTZ,
+    updated_at TIMESTAMPTZ,
+    PRIMARY KEY (exchange, symbol, "interval", timestamp)
+) PARTITION BY LIST ("interval");
+
+CREATE TABLE IF NOT EXISTS candles_1m PARTITION OF candles FOR VALUES IN ('1m') PARTITION BY RANGE (timestamp);
+CREATE TABLE IF NOT EXISTS candles_5m PARTITION OF candles FOR VALUES IN ('5m') PARTITION BY RANGE (timestamp);
+CREATE TABLE IF NOT EXISTS candles_15m PARTITION OF candles FOR VALUES IN ('15m') PARTITION BY RANGE (timestamp);
+CREATE TABLE IF NOT EXISTS candles_30m PARTITION OF candles FOR VALUES IN ('30m') PARTITION BY RANGE (timestamp);
+CREATE TABLE IF NOT EXISTS candles_1h PARTITION OF candles FOR VALUES IN ('1h') PARTITION BY RANGE (timestamp);
+CREATE TABLE IF NOT EXISTS candles_1d PARTITION OF candles FOR VALUES IN ('1d') PARTITION BY RANGE (timestamp);
+
+CREATE INDEX IF NOT EXISTS idx_candles_timestamp_brin ON candles USING BRIN (timestamp);
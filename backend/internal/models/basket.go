@@ -0,0 +1,64 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type BasketOrderStatus string
+
+const (
+	BasketOrderStatusRejected  BasketOrderStatus = "REJECTED"  // Nothing was placed
+	BasketOrderStatusOpen      BasketOrderStatus = "OPEN"      // Orders are working, none filled yet
+	BasketOrderStatusPartial   BasketOrderStatus = "PARTIAL"   // Some orders filled, others still working
+	BasketOrderStatusCompleted BasketOrderStatus = "COMPLETED" // Every order filled
+	BasketOrderStatusClosed    BasketOrderStatus = "CLOSED"    // Every order done but not all filled
+)
+
+// Basket is a saved, named list of orders that can be placed together
+type Basket struct {
+	ID        string         `gorm:"primaryKey;type:uuid;default:gen_random_uuid()" json:"id"`
+	UserID    string         `gorm:"type:uuid;not null;uniqueIndex:idx_baskets_user_name" json:"userId"`
+	Name      string         `gorm:"not null;uniqueIndex:idx_baskets_user_name" json:"name"`
+	Items     []BasketItem   `gorm:"foreignKey:BasketID" json:"items"`
+	CreatedAt time.Time      `json:"createdAt"`
+	UpdatedAt time.Time      `json:"updatedAt"`
+	DeletedAt gorm.DeletedAt `gorm:"index" json:"-"`
+}
+
+// BasketItem is one order in a saved basket
+type BasketItem struct {
+	ID                string `gorm:"primaryKey;type:uuid;default:gen_random_uuid()" json:"id"`
+	BasketID          string `gorm:"type:uuid;not null;index" json:"basketId"`
+	Position          int    `gorm:"not null" json:"position"`
+	PlaceOrderRequest `gorm:"embedded"`
+	CreatedAt         time.Time `json:"createdAt"`
+}
+
+// BasketOrder is one placement of a basket. Its orders are reserved and
+// stored together or not at all; Status then follows the orders.
+type BasketOrder struct {
+	ID            string            `gorm:"primaryKey;type:uuid;default:gen_random_uuid()" json:"id"`
+	UserID        string            `gorm:"type:uuid;not null;index" json:"userId"`
+	BasketID      *string           `gorm:"type:uuid" json:"basketId,omitempty"` // Saved basket, if placed by name
+	Name          string            `json:"name,omitempty"`
+	Status        BasketOrderStatus `gorm:"type:varchar(20);not null" json:"status"`
+	RequiredFunds float64           `json:"requiredFunds"`
+	Error         string            `json:"error,omitempty"`
+	Orders        []Order           `gorm:"foreignKey:BasketOrderID" json:"orders,omitempty"`
+	CreatedAt     time.Time         `json:"createdAt"`
+	UpdatedAt     time.Time         `json:"updatedAt"`
+}
+
+// PlaceBasketRequest represents the request to place a basket of orders
+type PlaceBasketRequest struct {
+	Orders []PlaceOrderRequest `json:"orders" binding:"required,min=1,max=20,dive"`
+}
+
+// SaveBasketRequest represents the request to save a named basket. Saving
+// under an existing name replaces that basket's orders.
+type SaveBasketRequest struct {
+	Name   string              `json:"name" binding:"required"`
+	Orders []PlaceOrderRequest `json:"orders" binding:"required,min=1,max=20,dive"`
+}
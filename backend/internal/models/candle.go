@@ -0,0 +1,32 @@
+package models
+
+import "time"
+
+// Candle is a closed OHLC bar built from live ticks. Bars are keyed by
+// instrument, interval and the time they opened. The candles table is
+// partitioned by interval and then by month; see the time series migration.
+type Candle struct {
+	Exchange  string    `gorm:"type:varchar(10);primaryKey" json:"exchange"`
+	Symbol    string    `gorm:"primaryKey" json:"symbol"`
+	Interval  string    `gorm:"type:varchar(5);primaryKey" json:"interval"` // 1m, 5m, 15m, 30m, 1h or 1d
+	Timestamp time.Time `gorm:"primaryKey" json:"timestamp"`                // When the bar opened
+	Open      float64   `gorm:"not null" json:"open"`
+	High      float64   `gorm:"not null" json:"high"`
+	Low       float64   `gorm:"not null" json:"low"`
+	Close     float64   `gorm:"not null" json:"close"`
+	Volume    int64     `gorm:"not null;default:0" json:"volume"`
+	CreatedAt time.Time `json:"-"`
+	UpdatedAt time.Time `json:"-"`
+}
+
+// OHLC returns the bar as a candlestick
+func (c *Candle) OHLC() OHLC {
+	return OHLC{
+		Timestamp: c.Timestamp,
+		Open:      c.Open,
+		High:      c.High,
+		Low:       c.Low,
+		Close:     c.Close,
+		Volume:    c.Volume,
+	}
+}
@@ -0,0 +1,95 @@
+package models
+
+import (
+	"time"
+)
+
+// ChargeRate is one version of the statutory and brokerage rates for a
+// segment. Percentages are of turnover; a blank Product or Exchange matches
+// any value.
+type ChargeRate struct {
+	Product             string     `json:"product,omitempty"` // CNC, MIS, NRML
+	InstrumentType      string     `json:"instrumentType"`    // EQ, FUT, OPT
+	Exchange            string     `json:"exchange,omitempty"`
+	BrokeragePercent    float64    `json:"brokeragePercent"`
+	BrokerageFlat       float64    `json:"brokerageFlat"` // Per executed order; caps the percentage when both are set
+	STTBuyPercent       float64    `json:"sttBuyPercent"`
+	STTSellPercent      float64    `json:"sttSellPercent"`
+	ExchangeTxnPercent  float64    `json:"exchangeTxnPercent"`
+	SEBIFeePerCrore     float64    `json:"sebiFeePerCrore"`
+	StampDutyBuyPercent float64    `json:"stampDutyBuyPercent"`
+	GSTPercent          float64    `json:"gstPercent"` // On brokerage, exchange and SEBI charges
+	EffectiveFrom       time.Time  `json:"effectiveFrom"`
+	EffectiveTo         *time.Time `json:"effectiveTo,omitempty"`
+}
+
+// ChargesBreakup is the itemised charges for a trade
+type ChargesBreakup struct {
+	Turnover          float64 `json:"turnover"`
+	Brokerage         float64 `json:"brokerage"`
+	STT               float64 `json:"stt"`
+	ExchangeTxnCharge float64 `json:"exchangeTxnCharge"`
+	SEBIFee           float64 `json:"sebiFee"`
+	StampDuty         float64 `json:"stampDuty"`
+	GST               float64 `json:"gst"`
+	Total             float64 `json:"total"`
+}
+
+// Add accumulates another breakup into this one
+func (b *ChargesBreakup) Add(other *ChargesBreakup) {
+	b.Turnover += other.Turnover
+	b.Brokerage += other.Brokerage
+	b.STT += other.STT
+	b.ExchangeTxnCharge += other.ExchangeTxnCharge
+	b.SEBIFee += other.SEBIFee
+	b.StampDuty += other.StampDuty
+	b.GST += other.GST
+	b.Total += other.Total
+}
+
+// ToJSON converts the breakup for storage in a JSONB column
+func (b *ChargesBreakup) ToJSON() JSON {
+	return JSON{
+		"turnover":          b.Turnover,
+		"brokerage":         b.Brokerage,
+		"stt":               b.STT,
+		"exchangeTxnCharge": b.ExchangeTxnCharge,
+		"sebiFee":           b.SEBIFee,
+		"stampDuty":         b.StampDuty,
+		"gst":               b.GST,
+		"total":             b.Total,
+	}
+}
+
+// ChargesBreakupFromJSON reads a breakup stored by ToJSON. Missing or
+// malformed items are treated as zero.
+func ChargesBreakupFromJSON(data JSON) *ChargesBreakup {
+	value := func(key string) float64 {
+		if v, ok := data[key].(float64); ok {
+			return v
+		}
+		return 0
+	}
+	return &ChargesBreakup{
+		Turnover:          value("turnover"),
+		Brokerage:         value("brokerage"),
+		STT:               value("stt"),
+		ExchangeTxnCharge: value("exchangeTxnCharge"),
+		SEBIFee:           value("sebiFee"),
+		StampDuty:         value("stampDuty"),
+		GST:               value("gst"),
+		Total:             value("total"),
+	}
+}
+
+// ChargesRequest represents the request to preview the charges for a trade.
+// Without a price the current market price is used.
+type ChargesRequest struct {
+	Symbol         string    `json:"symbol" binding:"required"`
+	Exchange       string    `json:"exchange" binding:"required"`
+	Quantity       int       `json:"quantity" binding:"required,min=1"`
+	Price          *float64  `json:"price"`
+	Side           OrderSide `json:"side" binding:"required"`
+	Product        string    `json:"product" binding:"required"`
+	InstrumentType string    `json:"instrumentType" binding:"required"`
+}
@@ -211,5 +211,18 @@ func AutoMigrate(db *gorm.DB) error {
 		&WatchlistItem{},
 		&ApiKey{},
 		&MarketData{},
+		&Trade{},
+		&OrderAmendment{},
+		&OrderReservation{},
+		&Position{},
+		&GTT{},
+		&GTTLeg{},
+		&GTTStatusChange{},
+		&Basket{},
+		&BasketItem{},
+		&BasketOrder{},
+		&IdempotencyRecord{},
+		&TradingHalt{},
+		&MarketHoliday{},
 	)
 }
@@ -0,0 +1,96 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type GTTType string
+type GTTStatus string
+
+const (
+	GTTTypeSingle GTTType = "SINGLE" // One trigger, one order
+	GTTTypeOCO    GTTType = "OCO"    // Target and stop-loss; the first to trigger wins
+
+	GTTStatusActive    GTTStatus = "ACTIVE"
+	GTTStatusTriggered GTTStatus = "TRIGGERED"
+	GTTStatusCancelled GTTStatus = "CANCELLED"
+	GTTStatusExpired   GTTStatus = "EXPIRED"
+	GTTStatusRejected  GTTStatus = "REJECTED" // Triggered but the order could not be placed
+)
+
+// GTT is a good-till-triggered conditional order. It watches the last traded
+// price and places a real order when one of its legs triggers.
+type GTT struct {
+	ID             string         `gorm:"primaryKey;type:uuid;default:gen_random_uuid()" json:"id"`
+	UserID         string         `gorm:"type:uuid;not null;index" json:"userId"`
+	Type           GTTType        `gorm:"type:varchar(10);not null" json:"type"`
+	Status         GTTStatus      `gorm:"type:varchar(20);default:'ACTIVE';index" json:"status"`
+	Symbol         string         `gorm:"not null" json:"symbol"`
+	Exchange       string         `gorm:"not null;default:'NSE'" json:"exchange"`
+	Side           OrderSide      `gorm:"type:varchar(10);not null" json:"side"`
+	Product        string         `gorm:"not null;default:'CNC'" json:"product"`
+	InstrumentType string         `gorm:"not null;default:'EQ'" json:"instrumentType"`
+	LastPrice      float64        `json:"lastPrice"` // Last price when created; sets each leg's trigger direction
+	Legs           []GTTLeg       `gorm:"foreignKey:GTTID" json:"legs"`
+	TriggeredLeg   *int           `json:"triggeredLeg,omitempty"`
+	OrderID        *string        `gorm:"type:uuid" json:"orderId,omitempty"` // Order placed on trigger
+	Remarks        string         `json:"remarks,omitempty"`
+	ExpiresAt      time.Time      `gorm:"not null;index" json:"expiresAt"`
+	TriggeredAt    *time.Time     `json:"triggeredAt,omitempty"`
+	CreatedAt      time.Time      `json:"createdAt"`
+	UpdatedAt      time.Time      `json:"updatedAt"`
+	DeletedAt      gorm.DeletedAt `gorm:"index" json:"-"`
+}
+
+// GTTLeg is one trigger of a GTT and the order it places
+type GTTLeg struct {
+	ID           string    `gorm:"primaryKey;type:uuid;default:gen_random_uuid()" json:"id"`
+	GTTID        string    `gorm:"type:uuid;not null;index" json:"gttId"`
+	Leg          int       `gorm:"not null" json:"leg"`
+	TriggerPrice float64   `gorm:"not null" json:"triggerPrice"`
+	Quantity     int       `gorm:"not null" json:"quantity"`
+	OrderType    OrderType `gorm:"type:varchar(20);not null" json:"orderType"` // MARKET or LIMIT
+	Price        *float64  `json:"price,omitempty"`
+	CreatedAt    time.Time `json:"createdAt"`
+	UpdatedAt    time.Time `json:"updatedAt"`
+}
+
+// GTTStatusChange records a GTT moving between statuses
+type GTTStatusChange struct {
+	ID         string    `gorm:"primaryKey;type:uuid;default:gen_random_uuid()" json:"id"`
+	GTTID      string    `gorm:"type:uuid;not null;index" json:"gttId"`
+	FromStatus GTTStatus `gorm:"type:varchar(20)" json:"fromStatus,omitempty"`
+	ToStatus   GTTStatus `gorm:"type:varchar(20);not null" json:"toStatus"`
+	Reason     string    `json:"reason,omitempty"`
+	OrderID    *string   `gorm:"type:uuid" json:"orderId,omitempty"`
+	CreatedAt  time.Time `json:"createdAt"`
+}
+
+// GTTLegRequest is one leg of a GTT request
+type GTTLegRequest struct {
+	TriggerPrice float64   `json:"triggerPrice" binding:"required,gt=0"`
+	Quantity     int       `json:"quantity" binding:"required,min=1"`
+	OrderType    OrderType `json:"orderType" binding:"required"`
+	Price        *float64  `json:"price"`
+}
+
+// CreateGTTRequest represents the request to create a GTT
+type CreateGTTRequest struct {
+	Type           GTTType         `json:"type" binding:"required"`
+	Symbol         string          `json:"symbol" binding:"required"`
+	Exchange       string          `json:"exchange" binding:"required"`
+	Side           OrderSide       `json:"side" binding:"required"`
+	Product        string          `json:"product" binding:"required"`
+	InstrumentType string          `json:"instrumentType"`
+	Legs           []GTTLegRequest `json:"legs" binding:"required,min=1,max=2,dive"`
+	ExpiresAt      *time.Time      `json:"expiresAt"` // Defaults to one year
+}
+
+// ModifyGTTRequest represents the request to change an active GTT's legs
+// or expiry. Fields left nil are unchanged.
+type ModifyGTTRequest struct {
+	Legs      []GTTLegRequest `json:"legs" binding:"omitempty,min=1,max=2,dive"`
+	ExpiresAt *time.Time      `json:"expiresAt"`
+}
@@ -0,0 +1,80 @@
+package models
+
+import "time"
+
+type HaltScope string
+
+const (
+	HaltScopeUser     HaltScope = "USER"     // One user's orders
+	HaltScopeSymbol   HaltScope = "SYMBOL"   // One instrument, on one or every exchange
+	HaltScopeSegment  HaltScope = "SEGMENT"  // Equity or derivatives, on one or every exchange
+	HaltScopePlatform HaltScope = "PLATFORM" // Every order on the platform
+
+	HaltSourceUser  = "user"  // Self-exclusion
+	HaltSourceAdmin = "admin" // Operations, e.g. when a feed goes bad
+	HaltSourceRisk  = "risk"  // Automated risk rule
+
+	SegmentEquity      = "EQ"
+	SegmentDerivatives = "FO"
+)
+
+// TradingHalt blocks new orders and modifications for a user, an
+// instrument, a segment or the whole platform until it is lifted or its
+// Until time passes
+type TradingHalt struct {
+	ID              string     `gorm:"primaryKey;type:uuid;default:gen_random_uuid()" json:"id"`
+	Scope           HaltScope  `gorm:"type:varchar(20);not null;index" json:"scope"`
+	UserID          *string    `gorm:"type:uuid;index" json:"userId,omitempty"` // USER halts
+	Exchange        string     `json:"exchange,omitempty"`                      // SYMBOL and SEGMENT halts; blank for every exchange
+	Symbol          string     `json:"symbol,omitempty"`                        // SYMBOL halts
+	Segment         string     `json:"segment,omitempty"`                       // SEGMENT halts: EQ or FO
+	Reason          string     `gorm:"not null" json:"reason"`
+	Source          string     `gorm:"type:varchar(10);not null" json:"source"` // user, admin or risk
+	HaltedBy        string     `gorm:"not null" json:"haltedBy"`                // User ID, or system for risk rules
+	Until           *time.Time `json:"until,omitempty"`                         // Lifts by itself at this time
+	CancelledOrders int        `gorm:"default:0" json:"cancelledOrders"`        // Open orders cancelled when the halt was placed
+	LiftedBy        string     `json:"liftedBy,omitempty"`
+	LiftedAt        *time.Time `gorm:"index" json:"liftedAt,omitempty"`
+	CreatedAt       time.Time  `json:"createdAt"`
+	UpdatedAt       time.Time  `json:"updatedAt"`
+}
+
+// ActiveAt reports whether the halt is in force at t
+func (h *TradingHalt) ActiveAt(t time.Time) bool {
+	return h.LiftedAt == nil && (h.Until == nil || t.Before(*h.Until))
+}
+
+// Covers reports whether the halt applies to an order
+func (h *TradingHalt) Covers(order *Order) bool {
+	switch h.Scope {
+	case HaltScopeUser:
+		return h.UserID != nil && *h.UserID == order.UserID
+	case HaltScopeSymbol:
+		return h.Symbol == order.Symbol && (h.Exchange == "" || h.Exchange == order.Exchange)
+	case HaltScopeSegment:
+		return h.Segment == SegmentOf(order.InstrumentType) && (h.Exchange == "" || h.Exchange == order.Exchange)
+	case HaltScopePlatform:
+		return true
+	}
+	return false
+}
+
+// SegmentOf returns the segment an instrument type trades in
+func SegmentOf(instrumentType string) string {
+	if instrumentType == "" || instrumentType == "EQ" {
+		return SegmentEquity
+	}
+	return SegmentDerivatives
+}
+
+// HaltRequest represents a request to halt trading
+type HaltRequest struct {
+	Scope            HaltScope  `json:"scope" binding:"required"`
+	UserID           string     `json:"userId"` // USER halts placed by an admin; users always halt themselves
+	Exchange         string     `json:"exchange"`
+	Symbol           string     `json:"symbol"`
+	Segment          string     `json:"segment"`
+	Reason           string     `json:"reason" binding:"required"`
+	Until            *time.Time `json:"until"`            // Self-exclusion end; cannot be lifted early by the user
+	CancelOpenOrders bool       `json:"cancelOpenOrders"` // Cancel every open order the halt covers
+}
@@ -0,0 +1,27 @@
+package models
+
+import "time"
+
+// IdempotencyScope is the kind of request an idempotency key protects
+type IdempotencyScope string
+
+const (
+	IdempotencyScopeOrder      IdempotencyScope = "ORDER"
+	IdempotencyScopeDeposit    IdempotencyScope = "DEPOSIT"
+	IdempotencyScopeWithdrawal IdempotencyScope = "WITHDRAWAL"
+)
+
+// IdempotencyRecord remembers a client-supplied key so a retried request
+// returns what the first one created instead of acting twice. Keys are
+// unique per user and scope until the record expires.
+type IdempotencyRecord struct {
+	ID          string           `gorm:"primaryKey;type:uuid;default:gen_random_uuid()" json:"id"`
+	UserID      string           `gorm:"type:uuid;not null;uniqueIndex:idx_idempotency_key" json:"userId"`
+	Scope       IdempotencyScope `gorm:"type:varchar(20);not null;uniqueIndex:idx_idempotency_key" json:"scope"`
+	Key         string           `gorm:"type:varchar(64);not null;uniqueIndex:idx_idempotency_key" json:"key"`
+	RequestHash string           `gorm:"type:varchar(64);not null" json:"-"` // SHA-256 of the first request
+	ResourceID  string           `json:"resourceId,omitempty"`               // Order or payment the request created; empty while in flight
+	ExpiresAt   time.Time        `gorm:"index" json:"expiresAt"`
+	CreatedAt   time.Time        `json:"createdAt"`
+	UpdatedAt   time.Time        `json:"updatedAt"`
+}
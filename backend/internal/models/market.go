@@ -154,6 +154,7 @@ type MarketHoliday struct {
 	Date        time.Time `json:"date"`
 	Description string    `json:"description"`
 	Exchange    string    `json:"exchange"`
-	Status      string    `json:"status"` // CLOSED, EARLY_CLOSE
-	CloseTime   *string   `json:"closeTime,omitempty"`
+	Status      string    `json:"status"`              // CLOSED, EARLY_CLOSE, MUHURAT
+	OpenTime    *string   `json:"openTime,omitempty"`  // HH:MM local time, MUHURAT only
+	CloseTime   *string   `json:"closeTime,omitempty"` // HH:MM local time
 }
@@ -0,0 +1,21 @@
+package models
+
+import "time"
+
+// MarketHoliday statuses
+const (
+	MarketHolidayClosed     = "CLOSED"      // No trading all day
+	MarketHolidayEarlyClose = "EARLY_CLOSE" // Regular session closing at CloseTime
+	MarketHolidayMuhurat    = "MUHURAT"     // Special session from OpenTime to CloseTime only
+)
+
+// MarketStatusChange is an exchange moving from one market status to the
+// next, published when the session changes state
+type MarketStatusChange struct {
+	Exchange   string       `json:"exchange"`
+	Status     MarketStatus `json:"status"`
+	Previous   MarketStatus `json:"previous,omitempty"`
+	At         time.Time    `json:"at"`
+	NextStatus MarketStatus `json:"nextStatus"`
+	NextAt     time.Time    `json:"nextAt"`
+}
@@ -19,6 +19,7 @@ const (
 	OrderTypeLimit     OrderType = "LIMIT"
 	OrderTypeStopLoss  OrderType = "STOP_LOSS"
 	OrderTypeStopLimit OrderType = "STOP_LIMIT"
+	OrderTypeTrailingStop OrderType = "TRAILING_STOP" // Stop-loss whose trigger follows the price
 
 	OrderStatusPending   OrderStatus = "PENDING"
 	OrderStatusOpen      OrderStatus = "OPEN"
@@ -27,11 +28,22 @@ const (
 	OrderStatusCancelled OrderStatus = "CANCELLED"
 	OrderStatusRejected  OrderStatus = "REJECTED"
 	OrderStatusExpired   OrderStatus = "EXPIRED"
+	OrderStatusInactive  OrderStatus = "INACTIVE" // Bracket/cover leg waiting for its parent to fill
+	OrderStatusQueued    OrderStatus = "QUEUED"   // After-market order waiting for the next session
 
 	OrderValidityDay       OrderValidity = "DAY"
 	OrderValidityIOC       OrderValidity = "IOC" // Immediate or Cancel
 	OrderValidityGTC       OrderValidity = "GTC" // Good Till Cancelled  
 	OrderValidityGTD       OrderValidity = "GTD" // Good Till Date
+
+	OrderVarietyRegular = "regular"
+	OrderVarietyBracket = "bo"
+	OrderVarietyCover   = "co"
+	OrderVarietyAMO     = "amo"
+
+	ProductCNC  = "CNC"  // Cash and carry (delivery)
+	ProductMIS  = "MIS"  // Margin intraday square-off
+	ProductNRML = "NRML" // Normal (carry-forward F&O)
 )
 
 // Order represents a trading order
@@ -58,13 +70,22 @@ type Order struct {
 	OptionType       *string       `json:"optionType,omitempty"` // CE, PE
 	OrderID          string        `json:"orderId,omitempty"` // Exchange order ID
 	Tag              string        `json:"tag,omitempty"` // User defined tag
-	ParentOrderID    *string       `json:"parentOrderId,omitempty"` // For bracket/cover orders
-	DisclosedQty     *int          `json:"disclosedQty,omitempty"`
+	ClientOrderID    string        `gorm:"index" json:"clientOrderId,omitempty"` // Client-supplied ID that makes placing the order idempotent
+	ParentOrderID    *string       `json:"parentOrderId,omitempty"` // For bracket/cover legs and order slices
+	SliceCount       int           `gorm:"default:0" json:"sliceCount,omitempty"` // Child slices of an order split at the freeze quantity
+	BasketOrderID    *string       `gorm:"type:uuid;index" json:"basketOrderId,omitempty"` // Basket the order was placed in
+	DisclosedQty     *int          `json:"disclosedQty,omitempty"` // Iceberg slice size shown in the order book
 	Variety          string        `gorm:"default:'regular'" json:"variety"` // regular, bo (bracket), co (cover), amo (after-market)
+	TargetPrice      *float64      `json:"targetPrice,omitempty"` // Bracket order target leg price
+	StopLossPrice    *float64      `json:"stopLossPrice,omitempty"` // Bracket/cover order stop-loss leg trigger
+	TrailAmount      *float64      `json:"trailAmount,omitempty"` // Trailing stop distance from the best price
+	TrailPercent     *float64      `json:"trailPercent,omitempty"` // Trailing stop distance as a percentage of the best price
+	TrailAnchor      *float64      `json:"trailAnchor,omitempty"` // Best price seen since the trailing stop was placed
 	Error            string        `json:"error,omitempty"`
 	Remarks          string        `json:"remarks,omitempty"`
 	StrategyID       *string       `gorm:"type:uuid" json:"strategyId,omitempty"`
 	Trades           []Trade       `gorm:"foreignKey:OrderID" json:"trades,omitempty"`
+	Amendments       []OrderAmendment `gorm:"foreignKey:OrderID" json:"amendments,omitempty"`
 	Charges          JSON          `gorm:"type:jsonb;default:'{}'::jsonb" json:"charges"`
 	PlacedBy         string        `json:"placedBy,omitempty"` // User or system
 	CancelledBy      string        `json:"cancelledBy,omitempty"`
@@ -91,10 +112,79 @@ type Trade struct {
 	OrderTimestamp   time.Time      `json:"orderTimestamp"`
 	TradeTimestamp   time.Time      `json:"tradeTimestamp"`
 	Charges          JSON           `gorm:"type:jsonb;default:'{}'::jsonb" json:"charges"`
+	IcebergSlice     int            `gorm:"default:0" json:"icebergSlice,omitempty"` // Disclosed slice of an iceberg order this trade filled
+	Replenished      bool           `gorm:"default:false" json:"replenished,omitempty"` // This trade used up its slice and the next one was disclosed
 	CreatedAt        time.Time      `json:"createdAt"`
 	UpdatedAt        time.Time      `json:"updatedAt"`
 }
 
+// OrderAmendment records a change made to an open order
+type OrderAmendment struct {
+	ID              string    `gorm:"primaryKey;type:uuid;default:gen_random_uuid()" json:"id"`
+	OrderID         string    `gorm:"type:uuid;not null;index" json:"orderId"`
+	UserID          string    `gorm:"type:uuid;not null" json:"userId"`
+	OldQuantity     int       `json:"oldQuantity"`
+	NewQuantity     int       `json:"newQuantity"`
+	OldPrice        *float64  `json:"oldPrice,omitempty"`
+	NewPrice        *float64  `json:"newPrice,omitempty"`
+	OldTriggerPrice *float64  `json:"oldTriggerPrice,omitempty"`
+	NewTriggerPrice *float64  `json:"newTriggerPrice,omitempty"`
+	FilledQuantity  int       `json:"filledQuantity"` // Filled quantity at the time of the amendment
+	AmendedBy       string    `json:"amendedBy,omitempty"` // User or system
+	CreatedAt       time.Time `json:"createdAt"`
+}
+
+// ReservationStatus is the state of an order reservation
+type ReservationStatus string
+
+const (
+	ReservationStatusActive   ReservationStatus = "ACTIVE"   // Still holding funds or securities
+	ReservationStatusConsumed ReservationStatus = "CONSUMED" // Used up by fills
+	ReservationStatusReleased ReservationStatus = "RELEASED" // Returned on cancel or expiry
+)
+
+// OrderReservation records the funds or securities held for an order's
+// unfilled quantity. Amount and Quantity are what is still held; fills draw
+// them down and cancellation or expiry releases the rest.
+type OrderReservation struct {
+	ID               string            `gorm:"primaryKey;type:uuid;default:gen_random_uuid()" json:"id"`
+	OrderID          string            `gorm:"type:uuid;not null;uniqueIndex" json:"orderId"`
+	UserID           string            `gorm:"type:uuid;not null;index" json:"userId"`
+	Symbol           string            `gorm:"not null" json:"symbol"`
+	Side             OrderSide         `gorm:"type:varchar(10);not null" json:"side"`
+	Amount           float64           `gorm:"not null;default:0" json:"amount"`
+	Currency         string            `gorm:"not null;default:'INR'" json:"currency"`
+	Quantity         int               `gorm:"not null;default:0" json:"quantity"`
+	ReservedAmount   float64           `gorm:"not null;default:0" json:"reservedAmount"`   // Total funds ever held
+	ReservedQuantity int               `gorm:"not null;default:0" json:"reservedQuantity"` // Total securities ever held
+	Status           ReservationStatus `gorm:"type:varchar(20);default:'ACTIVE';index" json:"status"`
+	CreatedAt        time.Time         `json:"createdAt"`
+	UpdatedAt        time.Time         `json:"updatedAt"`
+}
+
+// HoldBalanceMismatch is a wallet whose HoldBalance differs from the funds
+// held by its active reservations and the margin blocked on its short
+// positions
+type HoldBalanceMismatch struct {
+	UserID        string  `json:"userId"`
+	HoldBalance   float64 `json:"holdBalance"`
+	Reserved      float64 `json:"reserved"`
+	MarginBlocked float64 `json:"marginBlocked"`
+}
+
+// ReservationAudit is the result of checking the reservation ledger against
+// wallets and orders
+type ReservationAudit struct {
+	CheckedAt             time.Time             `json:"checkedAt"`
+	HoldBalanceMismatches []HoldBalanceMismatch `json:"holdBalanceMismatches"`
+	OrphanedReservations  []*OrderReservation   `json:"orphanedReservations"` // Still active for closed orders
+}
+
+// Healthy reports whether the audit found no violations
+func (a *ReservationAudit) Healthy() bool {
+	return len(a.HoldBalanceMismatches) == 0 && len(a.OrphanedReservations) == 0
+}
+
 // OrderBook represents the order book for a symbol
 type OrderBook struct {
 	Symbol   string       `json:"symbol"`
@@ -149,13 +239,27 @@ type PlaceOrderRequest struct {
 	OptionType     *string       `json:"optionType"`
 	Variety        string        `json:"variety"`
 	ParentOrderID  *string       `json:"parentOrderId"`
+	TargetPrice    *float64      `json:"targetPrice"`
+	StopLossPrice  *float64      `json:"stopLossPrice"`
+	TrailAmount    *float64      `json:"trailAmount"`
+	TrailPercent   *float64      `json:"trailPercent"`
+	ClientOrderID  string        `json:"clientOrderId" binding:"omitempty,max=64"` // Resubmitting the same ID returns the original order
+}
+
+// ModifyOrderRequest represents the request to modify an open order.
+// Fields left nil are unchanged.
+type ModifyOrderRequest struct {
+	Quantity     *int     `json:"quantity" binding:"omitempty,min=1"`
+	Price        *float64 `json:"price"`
+	TriggerPrice *float64 `json:"triggerPrice"`
 }
 
 // OrderResponse represents the response for an order
 type OrderResponse struct {
-	Order    Order  `json:"order"`
-	Message  string `json:"message,omitempty"`
-	Success  bool   `json:"success"`
+	Order     Order  `json:"order"`
+	Message   string `json:"message,omitempty"`
+	Success   bool   `json:"success"`
+	Duplicate bool   `json:"duplicate,omitempty"` // The client order ID was already used and the original order is returned
 }
 
 // StrategyRequest represents the request to create or update a trading strategy
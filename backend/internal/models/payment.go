@@ -59,6 +59,7 @@ type Payment struct {
 	NetAmount         float64        `json:"netAmount"`
 	RefundedAmount    float64        `json:"refundedAmount,omitempty"`
 	BankAccountID     *string        `gorm:"type:uuid" json:"bankAccountId,omitempty"`
+	IdempotencyKey    string         `gorm:"index" json:"idempotencyKey,omitempty"`
 	ApprovedBy        string         `json:"approvedBy,omitempty"`
 	RejectedBy        string         `json:"rejectedBy,omitempty"`
 	RejectReason      string         `json:"rejectReason,omitempty"`
@@ -106,20 +107,22 @@ type WalletTransaction struct {
 
 // DepositRequest represents a request to deposit funds
 type DepositRequest struct {
-	Amount      float64       `json:"amount" binding:"required,min=100"`
-	Currency    string        `json:"currency" binding:"required"`
-	Method      PaymentMethod `json:"method" binding:"required"`
-	Description string        `json:"description"`
-	ReturnURL   string        `json:"returnUrl"`
-	Metadata    JSON          `json:"metadata"`
+	Amount         float64       `json:"amount" binding:"required,min=100"`
+	Currency       string        `json:"currency" binding:"required"`
+	Method         PaymentMethod `json:"method" binding:"required"`
+	Description    string        `json:"description"`
+	ReturnURL      string        `json:"returnUrl"`
+	Metadata       JSON          `json:"metadata"`
+	IdempotencyKey string        `json:"idempotencyKey" binding:"omitempty,max=64"` // Resubmitting the same key returns the original payment
 }
 
 // WithdrawalRequest represents a request to withdraw funds
 type WithdrawalRequest struct {
-	Amount        float64  `json:"amount" binding:"required,min=100"`
-	BankAccountID string   `json:"bankAccountId" binding:"required"`
-	Description   string   `json:"description"`
-	Metadata      JSON     `json:"metadata"`
+	Amount         float64 `json:"amount" binding:"required,min=100"`
+	BankAccountID  string  `json:"bankAccountId" binding:"required"`
+	Description    string  `json:"description"`
+	Metadata       JSON    `json:"metadata"`
+	IdempotencyKey string  `json:"idempotencyKey" binding:"omitempty,max=64"` // Resubmitting the same key returns the original payment
 }
 
 // PaymentResponse represents the response for a payment
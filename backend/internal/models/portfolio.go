@@ -49,6 +49,32 @@ type Holding struct {
 	DeletedAt         gorm.DeletedAt `gorm:"index" json:"-"`
 }
 
+// Position represents a user's intraday (MIS) position in an instrument for
+// one trading day. Intraday positions are kept apart from delivery holdings
+// and are flattened before the close.
+type Position struct {
+	ID               string    `gorm:"primaryKey;type:uuid;default:gen_random_uuid()" json:"id"`
+	UserID           string    `gorm:"type:uuid;not null;index:idx_position_key" json:"userId"`
+	Symbol           string    `gorm:"not null;index:idx_position_key" json:"symbol"`
+	Exchange         string    `gorm:"not null;index:idx_position_key" json:"exchange"`
+	Product          string    `gorm:"not null;default:'MIS';index:idx_position_key" json:"product"`
+	InstrumentType   string    `gorm:"not null;default:'EQ'" json:"instrumentType"`
+	TradingDate      time.Time `gorm:"type:date;not null;index:idx_position_key" json:"tradingDate"`
+	Quantity         int       `gorm:"not null;default:0" json:"quantity"` // Net quantity, negative when short
+	ReservedQuantity int       `gorm:"not null;default:0" json:"reservedQuantity"` // Held by open exit orders
+	BuyQuantity      int       `json:"buyQuantity"`
+	SellQuantity     int       `json:"sellQuantity"`
+	BuyValue         float64   `json:"buyValue"`
+	SellValue        float64   `json:"sellValue"`
+	AvgPrice         float64   `json:"avgPrice"` // Average price of the open quantity
+	RealisedPL       float64   `json:"realisedPL"`
+	LastPrice        float64   `json:"lastPrice"`    // Price the position was last marked at
+	UnrealisedPL     float64   `json:"unrealisedPL"` // Mark-to-market P&L of the open quantity
+	MarginBlocked    float64   `gorm:"not null;default:0" json:"marginBlocked"` // Held on the wallet against a short
+	CreatedAt        time.Time `json:"createdAt"`
+	UpdatedAt        time.Time `json:"updatedAt"`
+}
+
 // Transaction represents a transaction (buy/sell) in a portfolio
 type Transaction struct {
 	ID                string         `gorm:"primaryKey;type:uuid;default:gen_random_uuid()" json:"id"`
@@ -0,0 +1,37 @@
+package models
+
+import "time"
+
+// ReplayEventType identifies what a replay event carries
+type ReplayEventType string
+
+const (
+	ReplayEventSymbols ReplayEventType = "symbols" // Instrument master
+	ReplayEventIndices ReplayEventType = "indices" // Market indices
+	ReplayEventQuote   ReplayEventType = "quote"
+	ReplayEventDepth   ReplayEventType = "depth"
+)
+
+// ReplayEvent is one line of a market data replay file.
+//
+// Replay files are JSON Lines: one event per line in the order it was
+// received, gzip-compressed when the file name ends in .gz. A recording
+// normally starts with symbols and indices events holding the reference
+// data, followed by the session's quote and depth events:
+//
+//	{"type":"symbols","time":"2024-03-14T09:00:00.000+05:30","symbols":[{"symbol":"TCS","exchange":"NSE",...}]}
+//	{"type":"quote","time":"2024-03-14T09:15:00.250+05:30","quote":{"symbol":"TCS","exchange":"NSE","lastPrice":3450.4,...}}
+//	{"type":"depth","time":"2024-03-14T09:15:00.250+05:30","depth":{"symbol":"TCS","exchange":"NSE","bids":[...],"asks":[...]}}
+//
+// time is when the event was received, in RFC 3339 with fractional seconds,
+// and is what replay paces events and sets its clock by. Only the field
+// named by type is set. Readers skip unknown types so recordings from newer
+// recorders stay playable.
+type ReplayEvent struct {
+	Type    ReplayEventType `json:"type"`
+	Time    time.Time       `json:"time"`
+	Symbols []Symbol        `json:"symbols,omitempty"`
+	Indices []MarketIndex   `json:"indices,omitempty"`
+	Quote   *MarketQuote    `json:"quote,omitempty"`
+	Depth   *MarketDepth    `json:"depth,omitempty"`
+}
@@ -0,0 +1,32 @@
+package models
+
+// Risk profiles a user can be assigned
+const (
+	RiskProfileConservative = "conservative"
+	RiskProfileModerate     = "moderate"
+	RiskProfileAggressive   = "aggressive"
+)
+
+// RiskLimits are the pre-trade limits applied to a user's orders. A zero
+// limit is not enforced.
+type RiskLimits struct {
+	MaxOrderValue            float64 `json:"maxOrderValue"`
+	MaxOrderQuantity         int     `json:"maxOrderQuantity"`
+	MaxPriceDeviationPercent float64 `json:"maxPriceDeviationPercent"` // Limit price distance from LTP
+	MaxDailyLoss             float64 `json:"maxDailyLoss"`
+	MaxOpenOrders            int     `json:"maxOpenOrders"`
+}
+
+// RiskRejection is the structured reason a pre-trade risk rule rejected an
+// order
+type RiskRejection struct {
+	Rule   string  `json:"rule"`
+	Reason string  `json:"reason"`
+	Limit  float64 `json:"limit,omitempty"`
+	Actual float64 `json:"actual,omitempty"`
+}
+
+// Error implements the error interface
+func (r *RiskRejection) Error() string {
+	return r.Rule + ": " + r.Reason
+}
@@ -0,0 +1,24 @@
+package models
+
+import "time"
+
+// Tick is a stored quote: the last trade and the top of the book for an
+// instrument at one moment. Full market depth is only kept in memory. The
+// market_ticks table is partitioned by day; see the time series migration.
+type Tick struct {
+	Exchange     string    `gorm:"type:varchar(10);not null" json:"exchange"`
+	Symbol       string    `gorm:"not null" json:"symbol"`
+	Timestamp    time.Time `gorm:"not null" json:"timestamp"`
+	LastPrice    float64   `gorm:"not null" json:"lastPrice"`
+	Volume       int64     `gorm:"not null;default:0" json:"volume"` // Cumulative day volume
+	Bid          float64   `gorm:"not null;default:0" json:"bid"`
+	BidQty       int       `gorm:"not null;default:0" json:"bidQty"`
+	Ask          float64   `gorm:"not null;default:0" json:"ask"`
+	AskQty       int       `gorm:"not null;default:0" json:"askQty"`
+	OpenInterest int64     `gorm:"not null;default:0" json:"openInterest,omitempty"`
+}
+
+// TableName returns the partitioned table ticks are stored in
+func (Tick) TableName() string {
+	return "market_ticks"
+}
@@ -0,0 +1,159 @@
+// stock-trading-app/backend/internal/repository/basket_repository.go
+
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/shyamanurag/stock-trading-app/backend/internal/models"
+	"gorm.io/gorm"
+)
+
+// BasketRepository handles database operations for saved baskets and basket
+// orders
+type BasketRepository struct {
+	db *gorm.DB
+}
+
+// NewBasketRepository creates a new BasketRepository
+func NewBasketRepository(db *gorm.DB) *BasketRepository {
+	return &BasketRepository{db: db}
+}
+
+// Create adds a new saved basket and its items to the database
+func (r *BasketRepository) Create(ctx context.Context, basket *models.Basket) error {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	result := r.db.WithContext(ctx).Create(basket)
+	if result.Error != nil {
+		return result.Error
+	}
+	return nil
+}
+
+// GetByName retrieves a user's saved basket and its items by name
+func (r *BasketRepository) GetByName(ctx context.Context, userID string, name string) (*models.Basket, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	var basket models.Basket
+	result := r.db.WithContext(ctx).
+		Preload("Items", func(db *gorm.DB) *gorm.DB { return db.Order("position ASC") }).
+		Where("user_id = ? AND name = ?", userID, name).
+		First(&basket)
+	if result.Error != nil {
+		if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, result.Error
+	}
+	return &basket, nil
+}
+
+// GetByUserID retrieves a user's saved baskets and their items
+func (r *BasketRepository) GetByUserID(ctx context.Context, userID string) ([]*models.Basket, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	var baskets []*models.Basket
+	result := r.db.WithContext(ctx).
+		Preload("Items", func(db *gorm.DB) *gorm.DB { return db.Order("position ASC") }).
+		Where("user_id = ?", userID).
+		Order("name ASC").
+		Find(&baskets)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	return baskets, nil
+}
+
+// ReplaceItems replaces a saved basket's items
+func (r *BasketRepository) ReplaceItems(ctx context.Context, basketID string, items []models.BasketItem) error {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	if err := r.db.WithContext(ctx).Where("basket_id = ?", basketID).Delete(&models.BasketItem{}).Error; err != nil {
+		return err
+	}
+	result := r.db.WithContext(ctx).Create(&items)
+	if result.Error != nil {
+		return result.Error
+	}
+	return nil
+}
+
+// Delete deletes a saved basket
+func (r *BasketRepository) Delete(ctx context.Context, basket *models.Basket) error {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	result := r.db.WithContext(ctx).Delete(basket)
+	if result.Error != nil {
+		return result.Error
+	}
+	return nil
+}
+
+// CreateOrder records a basket placement
+func (r *BasketRepository) CreateOrder(ctx context.Context, basketOrder *models.BasketOrder) error {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	result := r.db.WithContext(ctx).Omit("Orders").Create(basketOrder)
+	if result.Error != nil {
+		return result.Error
+	}
+	return nil
+}
+
+// UpdateOrder updates a basket placement
+func (r *BasketRepository) UpdateOrder(ctx context.Context, basketOrder *models.BasketOrder) error {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	result := r.db.WithContext(ctx).Omit("Orders").Save(basketOrder)
+	if result.Error != nil {
+		return result.Error
+	}
+	return nil
+}
+
+// GetOrderByID retrieves a basket placement and its orders by ID
+func (r *BasketRepository) GetOrderByID(ctx context.Context, id string) (*models.BasketOrder, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	var basketOrder models.BasketOrder
+	result := r.db.WithContext(ctx).
+		Preload("Orders", func(db *gorm.DB) *gorm.DB { return db.Order("created_at ASC") }).
+		First(&basketOrder, "id = ?", id)
+	if result.Error != nil {
+		if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, result.Error
+	}
+	return &basketOrder, nil
+}
+
+// GetOrdersByUserID retrieves a user's basket placements and their orders,
+// newest first
+func (r *BasketRepository) GetOrdersByUserID(ctx context.Context, userID string, limit int) ([]*models.BasketOrder, error) {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	var basketOrders []*models.BasketOrder
+	result := r.db.WithContext(ctx).
+		Preload("Orders", func(db *gorm.DB) *gorm.DB { return db.Order("created_at ASC") }).
+		Where("user_id = ?", userID).
+		Order("created_at DESC").
+		Limit(limit).
+		Find(&basketOrders)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	return basketOrders, nil
+}
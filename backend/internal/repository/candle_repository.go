@@ -0,0 +1,62 @@
+// stock-trading-app/backend/internal/repository/candle_repository.go
+
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/shyamanurag/stock-trading-app/backend/internal/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// CandleRepository handles database operations for OHLC bars
+type CandleRepository struct {
+	db *gorm.DB
+}
+
+// NewCandleRepository creates a new CandleRepository
+func NewCandleRepository(db *gorm.DB) *CandleRepository {
+	return &CandleRepository{db: db}
+}
+
+// SaveBatch stores closed bars in one statement, replacing any bar already
+// stored for the same instrument, interval and open time
+func (r *CandleRepository) SaveBatch(ctx context.Context, candles []*models.Candle) error {
+	if len(candles) == 0 {
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	result := r.db.WithContext(ctx).
+		Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "exchange"}, {Name: "symbol"}, {Name: "interval"}, {Name: "timestamp"}},
+			DoUpdates: clause.AssignmentColumns([]string{"open", "high", "low", "close", "volume", "updated_at"}),
+		}).
+		Create(&candles)
+	if result.Error != nil {
+		return result.Error
+	}
+	return nil
+}
+
+// GetRange retrieves an instrument's bars for an interval that opened in
+// [startTime, endTime), oldest first
+func (r *CandleRepository) GetRange(ctx context.Context, exchange string, symbol string, interval string, startTime time.Time, endTime time.Time) ([]*models.Candle, error) {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	var candles []*models.Candle
+	result := r.db.WithContext(ctx).
+		Where(`exchange = ? AND symbol = ? AND "interval" = ? AND timestamp >= ? AND timestamp < ?`,
+			exchange, symbol, interval, startTime, endTime).
+		Order("timestamp ASC").
+		Find(&candles)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	return candles, nil
+}
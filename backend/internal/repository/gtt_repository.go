@@ -0,0 +1,176 @@
+// stock-trading-app/backend/internal/repository/gtt_repository.go
+
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/shyamanurag/stock-trading-app/backend/internal/models"
+	"gorm.io/gorm"
+)
+
+// GTTRepository handles database operations for GTT conditional orders
+type GTTRepository struct {
+	db *gorm.DB
+}
+
+// NewGTTRepository creates a new GTTRepository
+func NewGTTRepository(db *gorm.DB) *GTTRepository {
+	return &GTTRepository{db: db}
+}
+
+// Create adds a new GTT and its legs to the database
+func (r *GTTRepository) Create(ctx context.Context, gtt *models.GTT) error {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	result := r.db.WithContext(ctx).Create(gtt)
+	if result.Error != nil {
+		return result.Error
+	}
+	return nil
+}
+
+// GetByID retrieves a GTT and its legs by ID
+func (r *GTTRepository) GetByID(ctx context.Context, id string) (*models.GTT, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	var gtt models.GTT
+	result := r.db.WithContext(ctx).
+		Preload("Legs", func(db *gorm.DB) *gorm.DB { return db.Order("leg ASC") }).
+		First(&gtt, "id = ?", id)
+	if result.Error != nil {
+		if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, result.Error
+	}
+	return &gtt, nil
+}
+
+// GetByUserID retrieves a user's GTTs, newest first. If status is set only
+// GTTs in that status are returned.
+func (r *GTTRepository) GetByUserID(ctx context.Context, userID string, status models.GTTStatus) ([]*models.GTT, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	query := r.db.WithContext(ctx).
+		Preload("Legs", func(db *gorm.DB) *gorm.DB { return db.Order("leg ASC") }).
+		Where("user_id = ?", userID)
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+
+	var gtts []*models.GTT
+	result := query.Order("created_at DESC").Find(&gtts)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	return gtts, nil
+}
+
+// GetActive retrieves every active GTT
+func (r *GTTRepository) GetActive(ctx context.Context) ([]*models.GTT, error) {
+	ctx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+
+	var gtts []*models.GTT
+	result := r.db.WithContext(ctx).
+		Preload("Legs", func(db *gorm.DB) *gorm.DB { return db.Order("leg ASC") }).
+		Where("status = ?", models.GTTStatusActive).
+		Find(&gtts)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	return gtts, nil
+}
+
+// GetExpired retrieves the active GTTs whose expiry is before now
+func (r *GTTRepository) GetExpired(ctx context.Context, now time.Time) ([]*models.GTT, error) {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	var gtts []*models.GTT
+	result := r.db.WithContext(ctx).
+		Where("status = ? AND expires_at < ?", models.GTTStatusActive, now).
+		Find(&gtts)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	return gtts, nil
+}
+
+// Update updates a GTT
+func (r *GTTRepository) Update(ctx context.Context, gtt *models.GTT) error {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	result := r.db.WithContext(ctx).Omit("Legs").Save(gtt)
+	if result.Error != nil {
+		return result.Error
+	}
+	return nil
+}
+
+// ReplaceLegs replaces a GTT's legs
+func (r *GTTRepository) ReplaceLegs(ctx context.Context, gttID string, legs []models.GTTLeg) error {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	if err := r.db.WithContext(ctx).Where("gtt_id = ?", gttID).Delete(&models.GTTLeg{}).Error; err != nil {
+		return err
+	}
+	result := r.db.WithContext(ctx).Create(&legs)
+	if result.Error != nil {
+		return result.Error
+	}
+	return nil
+}
+
+// TransitionStatus moves a GTT from one status to another. It returns false
+// if the GTT was no longer in the from status, so only one caller can claim
+// a transition.
+func (r *GTTRepository) TransitionStatus(ctx context.Context, id string, from models.GTTStatus, to models.GTTStatus) (bool, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	result := r.db.WithContext(ctx).
+		Model(&models.GTT{}).
+		Where("id = ? AND status = ?", id, from).
+		Update("status", to)
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected == 1, nil
+}
+
+// CreateStatusChange records a GTT status change
+func (r *GTTRepository) CreateStatusChange(ctx context.Context, change *models.GTTStatusChange) error {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	result := r.db.WithContext(ctx).Create(change)
+	if result.Error != nil {
+		return result.Error
+	}
+	return nil
+}
+
+// GetStatusHistory retrieves a GTT's status changes, oldest first
+func (r *GTTRepository) GetStatusHistory(ctx context.Context, gttID string) ([]*models.GTTStatusChange, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	var changes []*models.GTTStatusChange
+	result := r.db.WithContext(ctx).
+		Where("gtt_id = ?", gttID).
+		Order("created_at ASC").
+		Find(&changes)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	return changes, nil
+}
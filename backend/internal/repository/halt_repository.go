@@ -0,0 +1,78 @@
+// stock-trading-app/backend/internal/repository/halt_repository.go
+
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/shyamanurag/stock-trading-app/backend/internal/models"
+	"gorm.io/gorm"
+)
+
+// HaltRepository handles database operations for trading halts
+type HaltRepository struct {
+	db *gorm.DB
+}
+
+// NewHaltRepository creates a new HaltRepository
+func NewHaltRepository(db *gorm.DB) *HaltRepository {
+	return &HaltRepository{db: db}
+}
+
+// Create adds a new trading halt to the database
+func (r *HaltRepository) Create(ctx context.Context, halt *models.TradingHalt) error {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	result := r.db.WithContext(ctx).Create(halt)
+	if result.Error != nil {
+		return result.Error
+	}
+	return nil
+}
+
+// GetByID retrieves a trading halt by ID
+func (r *HaltRepository) GetByID(ctx context.Context, id string) (*models.TradingHalt, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	var halt models.TradingHalt
+	result := r.db.WithContext(ctx).First(&halt, "id = ?", id)
+	if result.Error != nil {
+		if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, result.Error
+	}
+	return &halt, nil
+}
+
+// Update updates a trading halt
+func (r *HaltRepository) Update(ctx context.Context, halt *models.TradingHalt) error {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	result := r.db.WithContext(ctx).Save(halt)
+	if result.Error != nil {
+		return result.Error
+	}
+	return nil
+}
+
+// GetActive retrieves every halt in force at now, oldest first
+func (r *HaltRepository) GetActive(ctx context.Context, now time.Time) ([]*models.TradingHalt, error) {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	var halts []*models.TradingHalt
+	result := r.db.WithContext(ctx).
+		Where("lifted_at IS NULL AND (until IS NULL OR until > ?)", now).
+		Order("created_at ASC").
+		Find(&halts)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	return halts, nil
+}
@@ -0,0 +1,38 @@
+// stock-trading-app/backend/internal/repository/holiday_repository.go
+
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/shyamanurag/stock-trading-app/backend/internal/models"
+	"gorm.io/gorm"
+)
+
+// HolidayRepository handles database operations for exchange holiday lists
+type HolidayRepository struct {
+	db *gorm.DB
+}
+
+// NewHolidayRepository creates a new HolidayRepository
+func NewHolidayRepository(db *gorm.DB) *HolidayRepository {
+	return &HolidayRepository{db: db}
+}
+
+// GetFrom retrieves every holiday, early close and muhurat session on or
+// after startDate, earliest first
+func (r *HolidayRepository) GetFrom(ctx context.Context, startDate time.Time) ([]models.MarketHoliday, error) {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	var holidays []models.MarketHoliday
+	result := r.db.WithContext(ctx).
+		Where("date >= ?", startDate).
+		Order("date ASC").
+		Find(&holidays)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	return holidays, nil
+}
@@ -0,0 +1,95 @@
+// stock-trading-app/backend/internal/repository/idempotency_repository.go
+
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/shyamanurag/stock-trading-app/backend/internal/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// IdempotencyRepository handles database operations for idempotency keys
+type IdempotencyRepository struct {
+	db *gorm.DB
+}
+
+// NewIdempotencyRepository creates a new IdempotencyRepository
+func NewIdempotencyRepository(db *gorm.DB) *IdempotencyRepository {
+	return &IdempotencyRepository{db: db}
+}
+
+// Claim stores a new idempotency record. It returns false without error if
+// the user already has a record for the same scope and key.
+func (r *IdempotencyRepository) Claim(ctx context.Context, record *models.IdempotencyRecord) (bool, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	result := r.db.WithContext(ctx).
+		Clauses(clause.OnConflict{DoNothing: true}).
+		Create(record)
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected == 1, nil
+}
+
+// Get retrieves a user's idempotency record for a scope and key
+func (r *IdempotencyRepository) Get(ctx context.Context, userID string, scope models.IdempotencyScope, key string) (*models.IdempotencyRecord, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	var record models.IdempotencyRecord
+	result := r.db.WithContext(ctx).
+		Where("user_id = ? AND scope = ? AND key = ?", userID, scope, key).
+		First(&record)
+	if result.Error != nil {
+		if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, result.Error
+	}
+	return &record, nil
+}
+
+// Complete records the resource a request created
+func (r *IdempotencyRepository) Complete(ctx context.Context, id string, resourceID string) error {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	result := r.db.WithContext(ctx).
+		Model(&models.IdempotencyRecord{}).
+		Where("id = ?", id).
+		Update("resource_id", resourceID)
+	if result.Error != nil {
+		return result.Error
+	}
+	return nil
+}
+
+// Delete deletes an idempotency record
+func (r *IdempotencyRepository) Delete(ctx context.Context, id string) error {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	result := r.db.WithContext(ctx).Delete(&models.IdempotencyRecord{}, "id = ?", id)
+	if result.Error != nil {
+		return result.Error
+	}
+	return nil
+}
+
+// DeleteExpired deletes every record that expired before now
+func (r *IdempotencyRepository) DeleteExpired(ctx context.Context, now time.Time) (int64, error) {
+	ctx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+
+	result := r.db.WithContext(ctx).Delete(&models.IdempotencyRecord{}, "expires_at < ?", now)
+	if result.Error != nil {
+		return 0, result.Error
+	}
+	return result.RowsAffected, nil
+}
@@ -0,0 +1,251 @@
+// stock-trading-app/backend/internal/repository/order_repository.go
+
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/shyamanurag/stock-trading-app/backend/internal/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// OrderRepository handles database operations for orders
+type OrderRepository struct {
+	db *gorm.DB
+}
+
+// NewOrderRepository creates a new OrderRepository
+func NewOrderRepository(db *gorm.DB) *OrderRepository {
+	return &OrderRepository{db: db}
+}
+
+// Create adds a new order to the database
+func (r *OrderRepository) Create(ctx context.Context, order *models.Order) error {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	result := r.db.WithContext(ctx).Create(order)
+	if result.Error != nil {
+		return result.Error
+	}
+	return nil
+}
+
+// GetByID retrieves an order by ID
+func (r *OrderRepository) GetByID(ctx context.Context, id string) (*models.Order, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	var order models.Order
+	result := r.db.WithContext(ctx).First(&order, "id = ?", id)
+	if result.Error != nil {
+		if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, result.Error
+	}
+	return &order, nil
+}
+
+// GetByIDForUpdate retrieves an order by ID and locks its row until the
+// transaction ends
+func (r *OrderRepository) GetByIDForUpdate(ctx context.Context, id string) (*models.Order, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	var order models.Order
+	result := r.db.WithContext(ctx).
+		Clauses(clause.Locking{Strength: "UPDATE"}).
+		First(&order, "id = ?", id)
+	if result.Error != nil {
+		if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, result.Error
+	}
+	return &order, nil
+}
+
+// Update updates an order
+func (r *OrderRepository) Update(ctx context.Context, order *models.Order) error {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	result := r.db.WithContext(ctx).Save(order)
+	if result.Error != nil {
+		return result.Error
+	}
+	return nil
+}
+
+// GetByStatusAndType retrieves all orders of the given types in any of the given statuses.
+// Parents of sliced orders, which never trade themselves, are excluded.
+func (r *OrderRepository) GetByStatusAndType(ctx context.Context, statuses []models.OrderStatus, types []models.OrderType) ([]*models.Order, error) {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	var orders []*models.Order
+	result := r.db.WithContext(ctx).
+		Where("status IN ? AND type IN ? AND slice_count = 0", statuses, types).
+		Order("created_at ASC").
+		Find(&orders)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	return orders, nil
+}
+
+// GetByParentID retrieves the child orders linked to a parent order
+func (r *OrderRepository) GetByParentID(ctx context.Context, parentID string) ([]*models.Order, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	var orders []*models.Order
+	result := r.db.WithContext(ctx).
+		Where("parent_order_id = ?", parentID).
+		Order("created_at ASC").
+		Find(&orders)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	return orders, nil
+}
+
+// GetByStatusAndValidity retrieves all orders with any of the given validities in any of the given statuses.
+// Parents of sliced orders, which never trade themselves, are excluded.
+func (r *OrderRepository) GetByStatusAndValidity(ctx context.Context, statuses []models.OrderStatus, validities []models.OrderValidity) ([]*models.Order, error) {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	var orders []*models.Order
+	result := r.db.WithContext(ctx).
+		Where("status IN ? AND validity IN ? AND slice_count = 0", statuses, validities).
+		Order("created_at ASC").
+		Find(&orders)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	return orders, nil
+}
+
+// CreateAmendment records an amendment to an order
+func (r *OrderRepository) CreateAmendment(ctx context.Context, amendment *models.OrderAmendment) error {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	result := r.db.WithContext(ctx).Create(amendment)
+	if result.Error != nil {
+		return result.Error
+	}
+	return nil
+}
+
+// GetAmendments retrieves the amendment history of an order, oldest first
+func (r *OrderRepository) GetAmendments(ctx context.Context, orderID string) ([]*models.OrderAmendment, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	var amendments []*models.OrderAmendment
+	result := r.db.WithContext(ctx).
+		Where("order_id = ?", orderID).
+		Order("created_at ASC").
+		Find(&amendments)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	return amendments, nil
+}
+
+// GetOpenByProduct retrieves every open order for a product on an exchange
+func (r *OrderRepository) GetOpenByProduct(ctx context.Context, exchange string, product string) ([]*models.Order, error) {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	var orders []*models.Order
+	result := r.db.WithContext(ctx).
+		Where("exchange = ? AND product = ? AND status IN ?", exchange, product, []models.OrderStatus{
+			models.OrderStatusPending,
+			models.OrderStatusOpen,
+			models.OrderStatusPartial,
+		}).
+		Order("created_at ASC").
+		Find(&orders)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	return orders, nil
+}
+
+// GetQueued retrieves the after-market orders waiting for an exchange's next
+// session in submission order. Slice parents are excluded; their slices are
+// queued in their place.
+func (r *OrderRepository) GetQueued(ctx context.Context, exchange string) ([]*models.Order, error) {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	var orders []*models.Order
+	result := r.db.WithContext(ctx).
+		Where("exchange = ? AND status = ? AND slice_count = 0", exchange, models.OrderStatusQueued).
+		Order("created_at ASC").
+		Find(&orders)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	return orders, nil
+}
+
+// GetOpen retrieves every order that is still working or queued, oldest
+// first. Blank filters match every user, exchange or symbol.
+func (r *OrderRepository) GetOpen(ctx context.Context, userID string, exchange string, symbol string) ([]*models.Order, error) {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	query := r.db.WithContext(ctx).
+		Where("status IN ?", []models.OrderStatus{
+			models.OrderStatusPending,
+			models.OrderStatusOpen,
+			models.OrderStatusPartial,
+			models.OrderStatusQueued,
+		})
+	if userID != "" {
+		query = query.Where("user_id = ?", userID)
+	}
+	if exchange != "" {
+		query = query.Where("exchange = ?", exchange)
+	}
+	if symbol != "" {
+		query = query.Where("symbol = ?", symbol)
+	}
+
+	var orders []*models.Order
+	result := query.Order("created_at ASC").Find(&orders)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	return orders, nil
+}
+
+// CountOpenByUserID counts a user's orders that are still working. The
+// parent of a sliced order is left out since its slices are counted.
+func (r *OrderRepository) CountOpenByUserID(ctx context.Context, userID string) (int64, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	var count int64
+	result := r.db.WithContext(ctx).
+		Model(&models.Order{}).
+		Where("user_id = ? AND status IN ?", userID, []models.OrderStatus{
+			models.OrderStatusPending,
+			models.OrderStatusOpen,
+			models.OrderStatusPartial,
+		}).
+		Where("slice_count = 0").
+		Count(&count)
+	if result.Error != nil {
+		return 0, result.Error
+	}
+	return count, nil
+}
@@ -0,0 +1,79 @@
+// stock-trading-app/backend/internal/repository/partition_repository.go
+
+package repository
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// PartitionRepository manages the time range partitions of partitioned
+// tables. Table and partition names are built by the caller and never come
+// from user input.
+type PartitionRepository struct {
+	db *gorm.DB
+}
+
+// NewPartitionRepository creates a new PartitionRepository
+func NewPartitionRepository(db *gorm.DB) *PartitionRepository {
+	return &PartitionRepository{db: db}
+}
+
+// CreateRangePartition creates the partition of parent named name holding
+// rows from startTime up to endTime, unless it already exists
+func (r *PartitionRepository) CreateRangePartition(ctx context.Context, parent string, name string, startTime time.Time, endTime time.Time) error {
+	ctx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+
+	statement := fmt.Sprintf("CREATE TABLE IF NOT EXISTS %s PARTITION OF %s FOR VALUES FROM ('%s') TO ('%s')",
+		quoteIdentifier(name), quoteIdentifier(parent),
+		startTime.UTC().Format(time.RFC3339), endTime.UTC().Format(time.RFC3339))
+
+	result := r.db.WithContext(ctx).Exec(statement)
+	if result.Error != nil {
+		return result.Error
+	}
+	return nil
+}
+
+// ListPartitions retrieves the names of a table's partitions
+func (r *PartitionRepository) ListPartitions(ctx context.Context, parent string) ([]string, error) {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	var names []string
+	result := r.db.WithContext(ctx).Raw(`
+		SELECT child.relname
+		FROM pg_inherits
+		JOIN pg_class parent ON parent.oid = pg_inherits.inhparent
+		JOIN pg_class child ON child.oid = pg_inherits.inhrelid
+		WHERE parent.relname = ?
+		ORDER BY child.relname`,
+		parent,
+	).Scan(&names)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	return names, nil
+}
+
+// DropPartition drops a partition and every row in it
+func (r *PartitionRepository) DropPartition(ctx context.Context, name string) error {
+	ctx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+
+	result := r.db.WithContext(ctx).Exec("DROP TABLE IF EXISTS " + quoteIdentifier(name))
+	if result.Error != nil {
+		return result.Error
+	}
+	return nil
+}
+
+// quoteIdentifier quotes a table name for use in DDL
+func quoteIdentifier(name string) string {
+	return `"` + strings.ReplaceAll(name, `"`, `""`) + `"`
+}
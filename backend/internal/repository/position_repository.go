@@ -0,0 +1,116 @@
+// stock-trading-app/backend/internal/repository/position_repository.go
+
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/shyamanurag/stock-trading-app/backend/internal/models"
+	"gorm.io/gorm"
+)
+
+// PositionRepository handles database operations for intraday positions
+type PositionRepository struct {
+	db *gorm.DB
+}
+
+// NewPositionRepository creates a new PositionRepository
+func NewPositionRepository(db *gorm.DB) *PositionRepository {
+	return &PositionRepository{db: db}
+}
+
+// Create adds a new position to the database
+func (r *PositionRepository) Create(ctx context.Context, position *models.Position) error {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	result := r.db.WithContext(ctx).Create(position)
+	if result.Error != nil {
+		return result.Error
+	}
+	return nil
+}
+
+// Update updates a position
+func (r *PositionRepository) Update(ctx context.Context, position *models.Position) error {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	result := r.db.WithContext(ctx).Save(position)
+	if result.Error != nil {
+		return result.Error
+	}
+	return nil
+}
+
+// Get retrieves a user's position in a symbol for a product and trading day
+func (r *PositionRepository) Get(ctx context.Context, userID string, exchange string, symbol string, product string, tradingDate time.Time) (*models.Position, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	var position models.Position
+	result := r.db.WithContext(ctx).
+		Where("user_id = ? AND exchange = ? AND symbol = ? AND product = ? AND trading_date = ?",
+			userID, exchange, symbol, product, tradingDate.Format("2006-01-02")).
+		First(&position)
+	if result.Error != nil {
+		if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, result.Error
+	}
+	return &position, nil
+}
+
+// GetByUserID retrieves a user's positions for a trading day
+func (r *PositionRepository) GetByUserID(ctx context.Context, userID string, tradingDate time.Time) ([]*models.Position, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	var positions []*models.Position
+	result := r.db.WithContext(ctx).
+		Where("user_id = ? AND trading_date = ?", userID, tradingDate.Format("2006-01-02")).
+		Order("symbol ASC").
+		Find(&positions)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	return positions, nil
+}
+
+// GetOpen retrieves every position with a non-zero quantity for a product
+// on an exchange and trading day
+func (r *PositionRepository) GetOpen(ctx context.Context, exchange string, product string, tradingDate time.Time) ([]*models.Position, error) {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	var positions []*models.Position
+	result := r.db.WithContext(ctx).
+		Where("exchange = ? AND product = ? AND trading_date = ? AND quantity <> 0",
+			exchange, product, tradingDate.Format("2006-01-02")).
+		Order("user_id ASC, symbol ASC").
+		Find(&positions)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	return positions, nil
+}
+
+// GetShort retrieves every position sold short for a product on a trading
+// day
+func (r *PositionRepository) GetShort(ctx context.Context, product string, tradingDate time.Time) ([]*models.Position, error) {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	var positions []*models.Position
+	result := r.db.WithContext(ctx).
+		Where("product = ? AND trading_date = ? AND quantity < 0", product, tradingDate.Format("2006-01-02")).
+		Order("user_id ASC, symbol ASC").
+		Find(&positions)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	return positions, nil
+}
@@ -0,0 +1,119 @@
+// stock-trading-app/backend/internal/repository/reservation_repository.go
+
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/shyamanurag/stock-trading-app/backend/internal/models"
+	"gorm.io/gorm"
+)
+
+// ReservationRepository handles database operations for order reservations
+type ReservationRepository struct {
+	db *gorm.DB
+}
+
+// NewReservationRepository creates a new ReservationRepository
+func NewReservationRepository(db *gorm.DB) *ReservationRepository {
+	return &ReservationRepository{db: db}
+}
+
+// Create adds a new reservation to the database
+func (r *ReservationRepository) Create(ctx context.Context, reservation *models.OrderReservation) error {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	result := r.db.WithContext(ctx).Create(reservation)
+	if result.Error != nil {
+		return result.Error
+	}
+	return nil
+}
+
+// GetByOrderID retrieves the reservation for an order
+func (r *ReservationRepository) GetByOrderID(ctx context.Context, orderID string) (*models.OrderReservation, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	var reservation models.OrderReservation
+	result := r.db.WithContext(ctx).First(&reservation, "order_id = ?", orderID)
+	if result.Error != nil {
+		if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, result.Error
+	}
+	return &reservation, nil
+}
+
+// Update updates a reservation
+func (r *ReservationRepository) Update(ctx context.Context, reservation *models.OrderReservation) error {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	result := r.db.WithContext(ctx).Save(reservation)
+	if result.Error != nil {
+		return result.Error
+	}
+	return nil
+}
+
+// FindHoldBalanceMismatches returns every wallet whose HoldBalance differs
+// by more than tolerance from the sum of its active reservations and the
+// margin blocked on its positions. Margin for a filled short moves from the
+// order's reservation onto the position, so both hold funds.
+func (r *ReservationRepository) FindHoldBalanceMismatches(ctx context.Context, tolerance float64) ([]models.HoldBalanceMismatch, error) {
+	ctx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+
+	var mismatches []models.HoldBalanceMismatch
+	result := r.db.WithContext(ctx).Raw(`
+		SELECT w.user_id, w.hold_balance,
+			COALESCE(r.reserved, 0) AS reserved,
+			COALESCE(p.margin_blocked, 0) AS margin_blocked
+		FROM wallets w
+		LEFT JOIN (
+			SELECT user_id, SUM(amount) AS reserved
+			FROM order_reservations
+			WHERE status = ?
+			GROUP BY user_id
+		) r ON r.user_id = w.user_id
+		LEFT JOIN (
+			SELECT user_id, SUM(margin_blocked) AS margin_blocked
+			FROM positions
+			WHERE margin_blocked <> 0
+			GROUP BY user_id
+		) p ON p.user_id = w.user_id
+		WHERE ABS(w.hold_balance - COALESCE(r.reserved, 0) - COALESCE(p.margin_blocked, 0)) > ?`,
+		models.ReservationStatusActive, tolerance,
+	).Scan(&mismatches)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	return mismatches, nil
+}
+
+// FindOrphaned returns active reservations whose order has already been
+// completed, cancelled, rejected or expired
+func (r *ReservationRepository) FindOrphaned(ctx context.Context) ([]*models.OrderReservation, error) {
+	ctx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+
+	var reservations []*models.OrderReservation
+	result := r.db.WithContext(ctx).
+		Joins("JOIN orders o ON o.id = order_reservations.order_id").
+		Where("order_reservations.status = ? AND o.status IN ?", models.ReservationStatusActive, []models.OrderStatus{
+			models.OrderStatusCompleted,
+			models.OrderStatusCancelled,
+			models.OrderStatusRejected,
+			models.OrderStatusExpired,
+		}).
+		Find(&reservations)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	return reservations, nil
+}
@@ -0,0 +1,59 @@
+// stock-trading-app/backend/internal/repository/tick_repository.go
+
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/shyamanurag/stock-trading-app/backend/internal/models"
+	"gorm.io/gorm"
+)
+
+// TickRepository handles database operations for stored ticks
+type TickRepository struct {
+	db *gorm.DB
+}
+
+// NewTickRepository creates a new TickRepository
+func NewTickRepository(db *gorm.DB) *TickRepository {
+	return &TickRepository{db: db}
+}
+
+// SaveBatch stores ticks in one statement
+func (r *TickRepository) SaveBatch(ctx context.Context, ticks []*models.Tick) error {
+	if len(ticks) == 0 {
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	result := r.db.WithContext(ctx).Create(&ticks)
+	if result.Error != nil {
+		return result.Error
+	}
+	return nil
+}
+
+// GetRange retrieves up to limit of an instrument's ticks in
+// [startTime, endTime), oldest first. A limit of zero returns every tick.
+func (r *TickRepository) GetRange(ctx context.Context, exchange string, symbol string, startTime time.Time, endTime time.Time, limit int) ([]*models.Tick, error) {
+	ctx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+
+	query := r.db.WithContext(ctx).
+		Where("exchange = ? AND symbol = ? AND timestamp >= ? AND timestamp < ?",
+			exchange, symbol, startTime, endTime).
+		Order("timestamp ASC")
+	if limit > 0 {
+		query = query.Limit(limit)
+	}
+
+	var ticks []*models.Tick
+	result := query.Find(&ticks)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	return ticks, nil
+}
@@ -0,0 +1,49 @@
+// stock-trading-app/backend/internal/repository/trade_repository.go
+
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/shyamanurag/stock-trading-app/backend/internal/models"
+	"gorm.io/gorm"
+)
+
+// TradeRepository handles database operations for trade executions
+type TradeRepository struct {
+	db *gorm.DB
+}
+
+// NewTradeRepository creates a new TradeRepository
+func NewTradeRepository(db *gorm.DB) *TradeRepository {
+	return &TradeRepository{db: db}
+}
+
+// Create adds a new trade to the database
+func (r *TradeRepository) Create(ctx context.Context, trade *models.Trade) error {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	result := r.db.WithContext(ctx).Create(trade)
+	if result.Error != nil {
+		return result.Error
+	}
+	return nil
+}
+
+// GetByOrderID retrieves all trades for an order in execution order
+func (r *TradeRepository) GetByOrderID(ctx context.Context, orderID string) ([]*models.Trade, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	var trades []*models.Trade
+	result := r.db.WithContext(ctx).
+		Where("order_id = ?", orderID).
+		Order("trade_timestamp ASC").
+		Find(&trades)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	return trades, nil
+}
@@ -9,6 +9,9 @@ import (
 	"gorm.io/gorm"
 )
 
+// afterCommitKey is the context key holding a transaction's commit hooks
+type afterCommitKey struct{}
+
 // TransactionManager handles database transactions
 type TransactionManager struct {
 	db *gorm.DB
@@ -19,8 +22,12 @@ func NewTransactionManager(db *gorm.DB) *TransactionManager {
 	return &TransactionManager{db: db}
 }
 
-// WithTransaction executes a function within a database transaction
+// WithTransaction executes a function within a database transaction. Hooks
+// registered with AfterCommit run once the transaction has committed.
 func (tm *TransactionManager) WithTransaction(ctx context.Context, fn func(tx *gorm.DB) error) error {
+	hooks := &[]func(){}
+	ctx = context.WithValue(ctx, afterCommitKey{}, hooks)
+
 	tx := tm.db.WithContext(ctx).Begin()
 	if tx.Error != nil {
 		return fmt.Errorf("failed to begin transaction: %w", tx.Error)
@@ -42,5 +49,22 @@ func (tm *TransactionManager) WithTransaction(ctx context.Context, fn func(tx *g
 		return fmt.Errorf("failed to commit transaction: %w", err)
 	}
 
+	for _, hook := range *hooks {
+		hook()
+	}
+
 	return nil
 }
+
+// AfterCommit registers fn to run after the transaction tx belongs to has
+// committed. It is dropped if the transaction rolls back. Outside a managed
+// transaction fn runs immediately.
+func AfterCommit(tx *gorm.DB, fn func()) {
+	if tx.Statement != nil && tx.Statement.Context != nil {
+		if hooks, ok := tx.Statement.Context.Value(afterCommitKey{}).(*[]func()); ok {
+			*hooks = append(*hooks, fn)
+			return
+		}
+	}
+	fn()
+}
@@ -0,0 +1,285 @@
+// stock-trading-app/backend/internal/services/amo_queue.go
+
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/shyamanurag/stock-trading-app/backend/internal/models"
+	"github.com/shyamanurag/stock-trading-app/backend/internal/repository"
+	"gorm.io/gorm"
+)
+
+var errOrderNotQueued = errors.New("order is not queued")
+
+// errAwaitingOpen is returned when a queued market order is released before
+// its exchange opens
+var errAwaitingOpen = errors.New("market orders are released at the open")
+
+// AMOQueue releases after-market orders into the market when the session
+// monitor reports each exchange's next session starting. Orders are stored as
+// QUEUED with their funds or securities reserved and are released in
+// submission order. Market orders are held until the open so they do not
+// execute during the pre-open against the previous session's quote.
+type AMOQueue struct {
+	calendar  *MarketCalendar
+	trading   *TradingService
+	orderRepo *repository.OrderRepository
+	sessions  *MarketSessionMonitor
+	hub       *WebSocketHub
+	exchanges []string
+	due       map[string]bool // Exchanges whose session has started since their last release
+	wake      chan struct{}
+	mutex     sync.Mutex
+}
+
+// NewAMOQueue creates a new AMOQueue
+func NewAMOQueue(
+	calendar *MarketCalendar,
+	trading *TradingService,
+	orderRepo *repository.OrderRepository,
+	sessions *MarketSessionMonitor,
+	hub *WebSocketHub,
+) *AMOQueue {
+	return &AMOQueue{
+		calendar:  calendar,
+		trading:   trading,
+		orderRepo: orderRepo,
+		sessions:  sessions,
+		hub:       hub,
+		exchanges: []string{"NSE", "BSE"},
+		due:       make(map[string]bool),
+		wake:      make(chan struct{}, 1),
+	}
+}
+
+// Start releases queued orders as sessions start until ctx is done. If the
+// process starts while a session is under way the release is due
+// immediately.
+func (q *AMOQueue) Start(ctx context.Context) {
+	q.sessions.OnStatusChange(q.onStatusChange)
+	for _, exchange := range q.exchanges {
+		q.onStatusChange(q.sessions.Status(exchange))
+	}
+	go q.run(ctx)
+}
+
+// onStatusChange marks an exchange's queue due for release when its session
+// starts and again when it opens. It runs on the session monitor's goroutine, so the release itself
+// is left to run.
+func (q *AMOQueue) onStatusChange(change *models.MarketStatusChange) {
+	switch change.Status {
+	case models.MarketStatusPreOpen, models.MarketStatusOpen:
+	default:
+		return
+	}
+
+	q.mutex.Lock()
+	q.due[change.Exchange] = true
+	q.mutex.Unlock()
+
+	select {
+	case q.wake <- struct{}{}:
+	default:
+	}
+}
+
+// run releases each exchange's queue once it is due
+func (q *AMOQueue) run(ctx context.Context) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-q.wake:
+		}
+
+		q.mutex.Lock()
+		due := q.due
+		q.due = make(map[string]bool)
+		q.mutex.Unlock()
+
+		for _, exchange := range q.exchanges {
+			if !due[exchange] {
+				continue
+			}
+			if err := q.Release(ctx, exchange); err != nil {
+				log.Printf("AMO release failed for %s: %v", exchange, err)
+			}
+		}
+	}
+}
+
+// Release moves the queued after-market orders on an exchange into the
+// market in the order they were submitted. Market orders stay queued until
+// the exchange is open.
+func (q *AMOQueue) Release(ctx context.Context, exchange string) error {
+	orders, err := q.orderRepo.GetQueued(ctx, exchange)
+	if err != nil {
+		return fmt.Errorf("failed to get queued orders: %w", err)
+	}
+
+	open := q.calendar.IsOpen(exchange, q.calendar.Now())
+	for _, order := range orders {
+		if order.Type == models.OrderTypeMarket && !open {
+			continue
+		}
+		q.release(ctx, order)
+	}
+
+	return nil
+}
+
+// release releases one queued order and tells the user how it went
+func (q *AMOQueue) release(ctx context.Context, order *models.Order) {
+	message := ServerMessage{
+		Type:      "amo_released",
+		Timestamp: q.calendar.Now().Unix(),
+	}
+
+	released, err := q.trading.ReleaseQueuedOrder(ctx, order.ID)
+	if errors.Is(err, errOrderNotQueued) || errors.Is(err, errAwaitingOpen) {
+		// Cancelled while the queue was being released, or a market order
+		// left for the open
+		return
+	}
+	if err != nil {
+		log.Printf("Failed to release after-market order %s: %v", order.ID, err)
+		message.Type = "amo_rejected"
+		message.Error = fmt.Sprintf("After-market order for %s was not placed: %v", order.Symbol, err)
+	}
+	if released != nil {
+		message.Data = released
+	} else {
+		message.Data = order
+	}
+
+	if q.hub != nil {
+		q.hub.SendToUser(order.UserID, message)
+	}
+}
+
+// queueAfterMarketOrder marks a validated after-market order as QUEUED. They
+// are only accepted while the exchange is not trading.
+func (s *TradingService) queueAfterMarketOrder(order *models.Order) error {
+	switch s.calendar.StatusAt(order.Exchange, s.calendar.Now()) {
+	case models.MarketStatusPreOpen, models.MarketStatusOpen:
+		return fmt.Errorf("after-market orders are only accepted outside trading hours")
+	}
+
+	order.Status = models.OrderStatusQueued
+	return nil
+}
+
+// ReleaseQueuedOrder moves a queued after-market order into the market. It
+// is checked again against trading halts and the session's prices and
+// limits; an order that fails is rejected with the reason and its
+// reservation released. Market orders are only released once the exchange
+// is open.
+func (s *TradingService) ReleaseQueuedOrder(ctx context.Context, orderID string) (*models.Order, error) {
+	order, err := s.orderRepo.GetByID(ctx, orderID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get order: %w", err)
+	}
+	if order == nil || order.Status != models.OrderStatusQueued {
+		return nil, errOrderNotQueued
+	}
+	if order.Type == models.OrderTypeMarket && !s.calendar.IsOpen(order.Exchange, s.calendar.Now()) {
+		return nil, errAwaitingOpen
+	}
+
+	if rejection := s.killSwitch.Check(order); rejection != nil {
+		return s.rejectQueuedOrder(ctx, orderID, rejection)
+	}
+	if err := s.risk.Check(ctx, order); err != nil {
+		var rejection *models.RiskRejection
+		if !errors.As(err, &rejection) {
+			return nil, err
+		}
+		return s.rejectQueuedOrder(ctx, orderID, rejection)
+	}
+
+	// A market order's latency is waited out before the order is locked
+	var executedAt time.Time
+	if order.Type == models.OrderTypeMarket {
+		if executedAt, err = s.awaitExecution(ctx); err != nil {
+			return nil, err
+		}
+	}
+
+	var released *models.Order
+	err = s.transactionMgr.WithTransaction(ctx, func(tx *gorm.DB) error {
+		orderRepo := repository.NewOrderRepository(tx)
+		order, err := orderRepo.GetByIDForUpdate(ctx, orderID)
+		if err != nil {
+			return fmt.Errorf("failed to get order: %w", err)
+		}
+		if order == nil || order.Status != models.OrderStatusQueued {
+			return errOrderNotQueued
+		}
+
+		order.Status = models.OrderStatusPending
+		if order.Type == models.OrderTypeLimit {
+			order.Status = models.OrderStatusOpen
+		}
+
+		if err := orderRepo.Update(ctx, order); err != nil {
+			return fmt.Errorf("failed to update order: %w", err)
+		}
+		s.emitOrderEvent(tx, OrderModified, order, nil)
+
+		if order.Type == models.OrderTypeMarket {
+			if err := s.executeMarketOrder(ctx, tx, order, executedAt); err != nil {
+				return fmt.Errorf("failed to execute market order: %w", err)
+			}
+		} else if err := s.onSliceChange(ctx, tx, order); err != nil {
+			return err
+		}
+
+		released = order
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return s.activateOrder(ctx, released)
+}
+
+// rejectQueuedOrder rejects a queued order that failed its release risk
+// check and releases its reservation
+func (s *TradingService) rejectQueuedOrder(ctx context.Context, orderID string, rejection *models.RiskRejection) (*models.Order, error) {
+	var rejected *models.Order
+	err := s.transactionMgr.WithTransaction(ctx, func(tx *gorm.DB) error {
+		orderRepo := repository.NewOrderRepository(tx)
+		order, err := orderRepo.GetByIDForUpdate(ctx, orderID)
+		if err != nil {
+			return fmt.Errorf("failed to get order: %w", err)
+		}
+		if order == nil || order.Status != models.OrderStatusQueued {
+			return errOrderNotQueued
+		}
+
+		order.Status = models.OrderStatusRejected
+		order.Error = rejection.Error()
+		if err := orderRepo.Update(ctx, order); err != nil {
+			return fmt.Errorf("failed to update order: %w", err)
+		}
+		s.emitOrderEvent(tx, OrderRejected, order, nil)
+		if err := s.releaseReservation(ctx, tx, order); err != nil {
+			return err
+		}
+
+		rejected = order
+		return s.onSliceChange(ctx, tx, order)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return rejected, rejection
+}
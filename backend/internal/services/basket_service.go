@@ -0,0 +1,356 @@
+// stock-trading-app/backend/internal/services/basket_service.go
+
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shyamanurag/stock-trading-app/backend/internal/models"
+	"github.com/shyamanurag/stock-trading-app/backend/internal/repository"
+	"gorm.io/gorm"
+)
+
+// ErrBasketNotFound is returned when a saved basket or basket order does not
+// exist or belongs to another user
+var ErrBasketNotFound = errors.New("basket not found")
+
+// BasketService places groups of orders all-or-nothing. Every order in a
+// basket is validated and risk checked first, the combined funds are checked
+// against the wallet, and then all reservations and orders are written in
+// one transaction so either the whole basket is placed or none of it is.
+type BasketService struct {
+	basketRepo     *repository.BasketRepository
+	transactionMgr *repository.TransactionManager
+	trading        *TradingService
+}
+
+// NewBasketService creates a new BasketService
+func NewBasketService(
+	basketRepo *repository.BasketRepository,
+	transactionMgr *repository.TransactionManager,
+	trading *TradingService,
+) *BasketService {
+	return &BasketService{
+		basketRepo:     basketRepo,
+		transactionMgr: transactionMgr,
+		trading:        trading,
+	}
+}
+
+// PlaceBasket places a basket of orders. If any order cannot be placed the
+// basket is stored as REJECTED and returned along with the reason.
+func (s *BasketService) PlaceBasket(ctx context.Context, userID string, req *models.PlaceBasketRequest) (*models.BasketOrder, error) {
+	return s.placeBasket(ctx, userID, req.Orders, nil)
+}
+
+// ExecuteBasket places a user's saved basket by name
+func (s *BasketService) ExecuteBasket(ctx context.Context, userID string, name string) (*models.BasketOrder, error) {
+	basket, err := s.GetBasket(ctx, userID, name)
+	if err != nil {
+		return nil, err
+	}
+
+	requests := make([]models.PlaceOrderRequest, 0, len(basket.Items))
+	for _, item := range basket.Items {
+		requests = append(requests, item.PlaceOrderRequest)
+	}
+	return s.placeBasket(ctx, userID, requests, basket)
+}
+
+// placeBasket validates, funds and places every order in a basket together
+func (s *BasketService) placeBasket(ctx context.Context, userID string, requests []models.PlaceOrderRequest, saved *models.Basket) (*models.BasketOrder, error) {
+	if len(requests) == 0 {
+		return nil, fmt.Errorf("basket has no orders")
+	}
+
+	basketOrder := &models.BasketOrder{
+		ID:     uuid.New().String(),
+		UserID: userID,
+		Status: models.BasketOrderStatusOpen,
+	}
+	if saved != nil {
+		basketOrder.BasketID = &saved.ID
+		basketOrder.Name = saved.Name
+	}
+
+	// Validate, halt check and risk check every order before anything is
+	// reserved. Halts are checked first so a halted user's retries do not
+	// keep tripping the daily loss rule.
+	orders := make([]*models.Order, 0, len(requests))
+	for i := range requests {
+		order := orderFromRequest(userID, &requests[i])
+		order.BasketOrderID = &basketOrder.ID
+
+		if err := s.trading.prepareOrder(ctx, order); err != nil {
+			return s.rejectBasket(ctx, basketOrder, fmt.Errorf("order %d: %w", i+1, err))
+		}
+		if rejection := s.trading.killSwitch.Check(order); rejection != nil {
+			return s.rejectBasket(ctx, basketOrder, fmt.Errorf("order %d: %w", i+1, rejection))
+		}
+		if err := s.trading.risk.Check(ctx, order); err != nil {
+			return s.rejectBasket(ctx, basketOrder, fmt.Errorf("order %d: %w", i+1, err))
+		}
+
+		if order.Side == models.OrderSideBuy {
+			required, err := s.trading.requiredFunds(ctx, order)
+			if err != nil {
+				return nil, err
+			}
+			basketOrder.RequiredFunds += required
+		}
+		orders = append(orders, order)
+	}
+
+	// Check the combined funds up front so the user sees the basket total
+	// rather than whichever order happened to run out
+	if basketOrder.RequiredFunds > 0 {
+		wallet, err := s.trading.walletRepo.GetByUserID(ctx, userID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get wallet: %w", err)
+		}
+		if wallet == nil {
+			return s.rejectBasket(ctx, basketOrder, fmt.Errorf("wallet not found for user"))
+		}
+		if wallet.Balance < basketOrder.RequiredFunds {
+			return s.rejectBasket(ctx, basketOrder, fmt.Errorf("insufficient funds for basket: required %.2f, available %.2f",
+				basketOrder.RequiredFunds, wallet.Balance))
+		}
+	}
+
+	// The basket's market orders reach the market together, once their
+	// latency has been waited out
+	var executedAt time.Time
+	for _, order := range orders {
+		if executesOnPlacement(order) {
+			var err error
+			if executedAt, err = s.trading.awaitExecution(ctx); err != nil {
+				return nil, err
+			}
+			break
+		}
+	}
+
+	// Reserve for and store every order in one transaction
+	err := s.transactionMgr.WithTransaction(ctx, func(tx *gorm.DB) error {
+		if err := repository.NewBasketRepository(tx).CreateOrder(ctx, basketOrder); err != nil {
+			return fmt.Errorf("failed to create basket order: %w", err)
+		}
+		for i, order := range orders {
+			if err := s.trading.storeOrder(ctx, tx, order, executedAt); err != nil {
+				return fmt.Errorf("order %d: %w", i+1, err)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return s.rejectBasket(ctx, basketOrder, err)
+	}
+
+	// Rest, track and schedule the orders now the basket is committed
+	for _, order := range orders {
+		if _, err := s.trading.activateOrder(ctx, order); err != nil {
+			log.Printf("Failed to activate order %s in basket %s: %v", order.ID, basketOrder.ID, err)
+		}
+	}
+
+	return s.GetBasketOrder(ctx, basketOrder.ID, userID)
+}
+
+// rejectBasket stores a basket that could not be placed as REJECTED
+func (s *BasketService) rejectBasket(ctx context.Context, basketOrder *models.BasketOrder, reason error) (*models.BasketOrder, error) {
+	basketOrder.Status = models.BasketOrderStatusRejected
+	basketOrder.Error = reason.Error()
+	if err := s.basketRepo.CreateOrder(ctx, basketOrder); err != nil {
+		return nil, fmt.Errorf("failed to store rejected basket: %w", err)
+	}
+	return basketOrder, reason
+}
+
+// GetBasketOrder returns one of a user's basket placements with its orders
+// and aggregate status
+func (s *BasketService) GetBasketOrder(ctx context.Context, id string, userID string) (*models.BasketOrder, error) {
+	basketOrder, err := s.basketRepo.GetOrderByID(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get basket order: %w", err)
+	}
+	if basketOrder == nil || basketOrder.UserID != userID {
+		return nil, ErrBasketNotFound
+	}
+
+	s.refreshBasketStatus(ctx, basketOrder)
+	return basketOrder, nil
+}
+
+// ListBasketOrders returns a user's most recent basket placements
+func (s *BasketService) ListBasketOrders(ctx context.Context, userID string, limit int) ([]*models.BasketOrder, error) {
+	basketOrders, err := s.basketRepo.GetOrdersByUserID(ctx, userID, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get basket orders: %w", err)
+	}
+
+	for _, basketOrder := range basketOrders {
+		s.refreshBasketStatus(ctx, basketOrder)
+	}
+	return basketOrders, nil
+}
+
+// refreshBasketStatus derives a placed basket's status from its orders and
+// saves it if it changed
+func (s *BasketService) refreshBasketStatus(ctx context.Context, basketOrder *models.BasketOrder) {
+	if basketOrder.Status == models.BasketOrderStatusRejected || len(basketOrder.Orders) == 0 {
+		return
+	}
+
+	var working, completed, filled int
+	for _, order := range basketOrder.Orders {
+		switch {
+		case order.Status == models.OrderStatusCompleted:
+			completed++
+		case !isTerminalStatus(order.Status):
+			working++
+		}
+		if order.FilledQuantity > 0 {
+			filled++
+		}
+	}
+
+	status := models.BasketOrderStatusClosed
+	switch {
+	case completed == len(basketOrder.Orders):
+		status = models.BasketOrderStatusCompleted
+	case working > 0 && filled > 0:
+		status = models.BasketOrderStatusPartial
+	case working > 0:
+		status = models.BasketOrderStatusOpen
+	}
+
+	if status != basketOrder.Status {
+		basketOrder.Status = status
+		if err := s.basketRepo.UpdateOrder(ctx, basketOrder); err != nil {
+			log.Printf("Failed to update basket order %s: %v", basketOrder.ID, err)
+		}
+	}
+}
+
+// SaveBasket saves a named basket for a user, replacing the orders of any
+// basket already saved under that name
+func (s *BasketService) SaveBasket(ctx context.Context, userID string, req *models.SaveBasketRequest) (*models.Basket, error) {
+	var saved *models.Basket
+	err := s.transactionMgr.WithTransaction(ctx, func(tx *gorm.DB) error {
+		basketRepo := repository.NewBasketRepository(tx)
+		basket, err := basketRepo.GetByName(ctx, userID, req.Name)
+		if err != nil {
+			return fmt.Errorf("failed to get basket: %w", err)
+		}
+
+		isNew := basket == nil
+		if isNew {
+			basket = &models.Basket{
+				ID:     uuid.New().String(),
+				UserID: userID,
+				Name:   req.Name,
+			}
+		}
+
+		items := make([]models.BasketItem, 0, len(req.Orders))
+		for i, order := range req.Orders {
+			items = append(items, models.BasketItem{
+				ID:                uuid.New().String(),
+				BasketID:          basket.ID,
+				Position:          i + 1,
+				PlaceOrderRequest: order,
+			})
+		}
+
+		if isNew {
+			basket.Items = items
+			if err := basketRepo.Create(ctx, basket); err != nil {
+				return fmt.Errorf("failed to create basket: %w", err)
+			}
+		} else {
+			if err := basketRepo.ReplaceItems(ctx, basket.ID, items); err != nil {
+				return fmt.Errorf("failed to update basket: %w", err)
+			}
+			basket.Items = items
+		}
+
+		saved = basket
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return saved, nil
+}
+
+// GetBasket returns one of a user's saved baskets by name
+func (s *BasketService) GetBasket(ctx context.Context, userID string, name string) (*models.Basket, error) {
+	basket, err := s.basketRepo.GetByName(ctx, userID, name)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get basket: %w", err)
+	}
+	if basket == nil {
+		return nil, ErrBasketNotFound
+	}
+	return basket, nil
+}
+
+// ListBaskets returns a user's saved baskets
+func (s *BasketService) ListBaskets(ctx context.Context, userID string) ([]*models.Basket, error) {
+	baskets, err := s.basketRepo.GetByUserID(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get baskets: %w", err)
+	}
+	return baskets, nil
+}
+
+// DeleteBasket deletes one of a user's saved baskets by name
+func (s *BasketService) DeleteBasket(ctx context.Context, userID string, name string) error {
+	basket, err := s.GetBasket(ctx, userID, name)
+	if err != nil {
+		return err
+	}
+
+	if err := s.basketRepo.Delete(ctx, basket); err != nil {
+		return fmt.Errorf("failed to delete basket: %w", err)
+	}
+	return nil
+}
+
+// orderFromRequest builds an order for a user from a place order request
+func orderFromRequest(userID string, req *models.PlaceOrderRequest) *models.Order {
+	variety := req.Variety
+	if variety == "" {
+		variety = models.OrderVarietyRegular
+	}
+
+	return &models.Order{
+		UserID:         userID,
+		Symbol:         req.Symbol,
+		Exchange:       req.Exchange,
+		Quantity:       req.Quantity,
+		Price:          req.Price,
+		TriggerPrice:   req.TriggerPrice,
+		Type:           req.Type,
+		Side:           req.Side,
+		Validity:       req.Validity,
+		ValidityDate:   req.ValidityDate,
+		Product:        req.Product,
+		InstrumentType: req.InstrumentType,
+		ExpiryDate:     req.ExpiryDate,
+		StrikePrice:    req.StrikePrice,
+		OptionType:     req.OptionType,
+		DisclosedQty:   req.DisclosedQty,
+		Tag:            req.Tag,
+		Variety:        variety,
+		TargetPrice:    req.TargetPrice,
+		StopLossPrice:  req.StopLossPrice,
+		TrailAmount:    req.TrailAmount,
+		TrailPercent:   req.TrailPercent,
+	}
+}
@@ -0,0 +1,395 @@
+// stock-trading-app/backend/internal/services/bracket_orders.go
+
+package services
+
+import (
+	"context"
+	"fmt"
+	"log"
+
+	"github.com/google/uuid"
+	"github.com/shyamanurag/stock-trading-app/backend/internal/models"
+	"github.com/shyamanurag/stock-trading-app/backend/internal/repository"
+	"gorm.io/gorm"
+)
+
+// Bracket orders (variety bo) place an entry order with two exit legs: a
+// target limit order and a stop-loss order. Cover orders (variety co) have
+// only the stop-loss leg. Legs are created INACTIVE alongside the entry,
+// are activated for the filled quantity on the entry's first fill and grow
+// with every later fill; the two legs of a bracket are one-cancels-other.
+// The stop-loss leg holds the reservation for the exit, which the target leg
+// shares. Legs protecting a partly filled entry keep working when the rest
+// of the entry is cancelled or expires.
+
+// isBracketVariety reports whether an order belongs to a bracket or cover order
+func isBracketVariety(order *models.Order) bool {
+	return order.Variety == models.OrderVarietyBracket || order.Variety == models.OrderVarietyCover
+}
+
+// validateBracketOrder validates the exit legs of a bracket or cover order
+func (s *TradingService) validateBracketOrder(ctx context.Context, order *models.Order) error {
+	if order.StopLossPrice == nil || *order.StopLossPrice <= 0 {
+		return fmt.Errorf("%s orders require a stop-loss price", order.Variety)
+	}
+
+	if order.Variety == models.OrderVarietyBracket {
+		if order.TargetPrice == nil || *order.TargetPrice <= 0 {
+			return fmt.Errorf("bracket orders require a target price")
+		}
+	} else if order.TargetPrice != nil {
+		return fmt.Errorf("cover orders do not take a target price")
+	}
+
+	if order.Type != models.OrderTypeMarket && order.Type != models.OrderTypeLimit {
+		return fmt.Errorf("%s orders must have a market or limit entry", order.Variety)
+	}
+
+	// The legs must sit on the correct side of the entry price
+	var entryPrice float64
+	if order.Price != nil {
+		entryPrice = *order.Price
+	} else {
+		marketPrice, err := s.marketData.GetCurrentPrice(ctx, order.Symbol)
+		if err != nil {
+			return fmt.Errorf("failed to get current price: %w", err)
+		}
+		entryPrice = marketPrice
+	}
+
+	stopLoss := *order.StopLossPrice
+	if order.Side == models.OrderSideBuy {
+		if stopLoss >= entryPrice {
+			return fmt.Errorf("stop-loss %.2f must be below the entry price %.2f", stopLoss, entryPrice)
+		}
+		if order.TargetPrice != nil && *order.TargetPrice <= entryPrice {
+			return fmt.Errorf("target %.2f must be above the entry price %.2f", *order.TargetPrice, entryPrice)
+		}
+	} else {
+		if stopLoss <= entryPrice {
+			return fmt.Errorf("stop-loss %.2f must be above the entry price %.2f", stopLoss, entryPrice)
+		}
+		if order.TargetPrice != nil && *order.TargetPrice >= entryPrice {
+			return fmt.Errorf("target %.2f must be below the entry price %.2f", *order.TargetPrice, entryPrice)
+		}
+	}
+
+	return nil
+}
+
+// createBracketLegs stores the inactive exit legs for a bracket or cover order
+func (s *TradingService) createBracketLegs(ctx context.Context, tx *gorm.DB, parent *models.Order) error {
+	exitSide := models.OrderSideSell
+	if parent.Side == models.OrderSideSell {
+		exitSide = models.OrderSideBuy
+	}
+
+	stopLoss := *parent.StopLossPrice
+	legs := []*models.Order{
+		s.newBracketLeg(parent, exitSide, models.OrderTypeStopLoss, nil, &stopLoss),
+	}
+	if parent.TargetPrice != nil {
+		target := *parent.TargetPrice
+		legs = append(legs, s.newBracketLeg(parent, exitSide, models.OrderTypeLimit, &target, nil))
+	}
+
+	orderRepo := repository.NewOrderRepository(tx)
+	for _, leg := range legs {
+		if err := orderRepo.Create(ctx, leg); err != nil {
+			return fmt.Errorf("failed to create %s leg: %w", parent.Variety, err)
+		}
+		s.emitOrderEvent(tx, OrderPlaced, leg, nil)
+	}
+
+	return nil
+}
+
+// newBracketLeg builds an exit leg for a bracket or cover order
+func (s *TradingService) newBracketLeg(parent *models.Order, side models.OrderSide, orderType models.OrderType, price *float64, triggerPrice *float64) *models.Order {
+	parentID := parent.ID
+	return &models.Order{
+		ID:             uuid.New().String(),
+		UserID:         parent.UserID,
+		Symbol:         parent.Symbol,
+		Exchange:       parent.Exchange,
+		Quantity:       parent.Quantity,
+		RemainingQty:   parent.Quantity,
+		Price:          price,
+		TriggerPrice:   triggerPrice,
+		Type:           orderType,
+		Side:           side,
+		Status:         models.OrderStatusInactive,
+		Validity:       parent.Validity,
+		Product:        parent.Product,
+		InstrumentType: parent.InstrumentType,
+		Tag:            parent.Tag,
+		ParentOrderID:  &parentID,
+		Variety:        parent.Variety,
+		PlacedBy:       "system",
+	}
+}
+
+// onBracketFill advances a bracket or cover order after one of its orders
+// has been filled
+func (s *TradingService) onBracketFill(ctx context.Context, tx *gorm.DB, order *models.Order, quantity int) error {
+	if !isBracketVariety(order) {
+		return nil
+	}
+
+	if order.ParentOrderID == nil {
+		return s.activateBracketLegs(ctx, tx, order, quantity)
+	}
+
+	return s.fillBracketLeg(ctx, tx, order, quantity)
+}
+
+// activateBracketLegs brings the exit legs in line with the entry's filled
+// quantity after quantity more of it was filled. Inactive legs are activated
+// for everything filled so far and active legs grow by quantity.
+func (s *TradingService) activateBracketLegs(ctx context.Context, tx *gorm.DB, parent *models.Order, quantity int) error {
+	orderRepo := repository.NewOrderRepository(tx)
+	legs, err := orderRepo.GetByParentID(ctx, parent.ID)
+	if err != nil {
+		return fmt.Errorf("failed to get %s legs: %w", parent.Variety, err)
+	}
+
+	for _, leg := range legs {
+		if isTerminalStatus(leg.Status) {
+			continue
+		}
+
+		if leg.Status != models.OrderStatusInactive {
+			if err := s.growBracketLeg(ctx, tx, leg, quantity); err != nil {
+				return err
+			}
+			continue
+		}
+
+		leg.Quantity = parent.FilledQuantity
+		leg.RemainingQty = parent.FilledQuantity
+		if leg.Type == models.OrderTypeStopLoss {
+			// The stop-loss leg holds the reservation for the exit
+			if err := s.reserveFundsOrSecurities(ctx, tx, leg); err != nil {
+				return fmt.Errorf("failed to reserve for stop-loss leg: %w", err)
+			}
+			leg.Status = models.OrderStatusPending
+		} else {
+			leg.Status = models.OrderStatusOpen
+		}
+
+		if err := orderRepo.Update(ctx, leg); err != nil {
+			return fmt.Errorf("failed to activate leg: %w", err)
+		}
+		s.emitOrderEvent(tx, OrderModified, leg, nil)
+
+		// Fills can commit while the matching engine holds the book lock,
+		// so the book is updated on its own goroutine
+		legID := leg.ID
+		repository.AfterCommit(tx, func() {
+			go s.restOrTrackLeg(legID)
+		})
+	}
+
+	return nil
+}
+
+// growBracketLeg adds quantity to an active leg after more of its entry was
+// filled, moving the stop-loss leg's reservation to match
+func (s *TradingService) growBracketLeg(ctx context.Context, tx *gorm.DB, leg *models.Order, quantity int) error {
+	grown := *leg
+	grown.Quantity += quantity
+	grown.RemainingQty += quantity
+
+	if s.holdsReservation(leg) {
+		if err := s.adjustReservation(ctx, tx, leg, &grown); err != nil {
+			return fmt.Errorf("failed to reserve for stop-loss leg: %w", err)
+		}
+	}
+
+	orderRepo := repository.NewOrderRepository(tx)
+	if err := orderRepo.Update(ctx, &grown); err != nil {
+		return fmt.Errorf("failed to resize leg: %w", err)
+	}
+	s.emitOrderEvent(tx, OrderModified, &grown, nil)
+
+	// A resting target leg shows its new quantity in the book
+	if grown.Type == models.OrderTypeLimit {
+		legID := grown.ID
+		repository.AfterCommit(tx, func() {
+			go s.amendBookedLeg(legID)
+		})
+	}
+	return nil
+}
+
+// restOrTrackLeg rests an activated target leg in the book or starts
+// watching an activated stop-loss leg's trigger. The leg is read afresh so
+// it carries any quantity added since it was activated.
+func (s *TradingService) restOrTrackLeg(legID string) {
+	ctx := context.Background()
+	leg, err := s.orderRepo.GetByID(ctx, legID)
+	if err != nil || leg == nil {
+		log.Printf("Failed to get leg %s to activate: %v", legID, err)
+		return
+	}
+	if isTerminalStatus(leg.Status) {
+		return
+	}
+
+	if leg.Type == models.OrderTypeLimit {
+		err = s.restLimitOrder(ctx, leg)
+	} else {
+		err = s.stopMonitor.Track(leg)
+	}
+	if err != nil {
+		log.Printf("Failed to activate %s leg %s: %v", leg.Variety, leg.ID, err)
+	}
+	s.expiry.Track(leg)
+}
+
+// amendBookedLeg brings a resting target leg's book entry up to date with
+// the stored leg. A leg that is not resting yet is left to restOrTrackLeg.
+func (s *TradingService) amendBookedLeg(legID string) {
+	leg, err := s.orderRepo.GetByID(context.Background(), legID)
+	if err != nil || leg == nil {
+		log.Printf("Failed to get leg %s to amend: %v", legID, err)
+		return
+	}
+	if leg.Status != models.OrderStatusOpen && leg.Status != models.OrderStatusPartial {
+		return
+	}
+	s.matchingEngine.Amend(leg)
+}
+
+// fillBracketLeg keeps the one-cancels-other legs in step: a partial fill
+// shrinks the sibling and a complete fill cancels it
+func (s *TradingService) fillBracketLeg(ctx context.Context, tx *gorm.DB, leg *models.Order, quantity int) error {
+	orderRepo := repository.NewOrderRepository(tx)
+	siblings, err := orderRepo.GetByParentID(ctx, *leg.ParentOrderID)
+	if err != nil {
+		return fmt.Errorf("failed to get sibling legs: %w", err)
+	}
+
+	for _, sibling := range siblings {
+		if sibling.ID == leg.ID || isTerminalStatus(sibling.Status) {
+			continue
+		}
+
+		if leg.Status != models.OrderStatusCompleted {
+			sibling.Quantity -= quantity
+			sibling.RemainingQty -= quantity
+			if err := orderRepo.Update(ctx, sibling); err != nil {
+				return fmt.Errorf("failed to resize sibling leg: %w", err)
+			}
+			s.emitOrderEvent(tx, OrderModified, sibling, nil)
+			continue
+		}
+
+		// The filled leg consumed the shared reservation, so the sibling is
+		// cancelled without releasing anything
+		now := s.calendar.Now()
+		sibling.Status = models.OrderStatusCancelled
+		sibling.CancelledAt = &now
+		sibling.CancelledBy = "system"
+		if err := orderRepo.Update(ctx, sibling); err != nil {
+			return fmt.Errorf("failed to cancel sibling leg: %w", err)
+		}
+		s.emitOrderEvent(tx, OrderCancelled, sibling, nil)
+		s.untrackAfterCommit(tx, sibling)
+	}
+
+	return nil
+}
+
+// closeBracketLegs cascades a cancellation or expiry to the rest of a
+// bracket or cover order: closing the entry closes its legs and closing a leg
+// closes its sibling. Reservations are released for every leg holding one.
+// An entry that was partly filled keeps its legs, which protect the filled
+// quantity. The closed legs are returned.
+func (s *TradingService) closeBracketLegs(ctx context.Context, tx *gorm.DB, order *models.Order, status models.OrderStatus) ([]*models.Order, error) {
+	if !isBracketVariety(order) {
+		return nil, nil
+	}
+	if order.ParentOrderID == nil && order.FilledQuantity > 0 {
+		return nil, nil
+	}
+
+	parentID := order.ID
+	if order.ParentOrderID != nil {
+		parentID = *order.ParentOrderID
+	}
+
+	orderRepo := repository.NewOrderRepository(tx)
+	legs, err := orderRepo.GetByParentID(ctx, parentID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get %s legs: %w", order.Variety, err)
+	}
+
+	var closed []*models.Order
+	for _, leg := range legs {
+		if leg.ID == order.ID || isTerminalStatus(leg.Status) {
+			continue
+		}
+
+		holdsReservation := s.holdsReservation(leg)
+
+		leg.Status = status
+		if status == models.OrderStatusCancelled {
+			now := s.calendar.Now()
+			leg.CancelledAt = &now
+			leg.CancelledBy = order.CancelledBy
+		} else {
+			leg.Remarks = order.Remarks
+		}
+		if err := orderRepo.Update(ctx, leg); err != nil {
+			return nil, fmt.Errorf("failed to close leg: %w", err)
+		}
+		s.emitOrderEvent(tx, statusEvent(status), leg, nil)
+
+		if holdsReservation {
+			if err := s.releaseReservation(ctx, tx, leg); err != nil {
+				return nil, err
+			}
+		}
+		s.untrackAfterCommit(tx, leg)
+		closed = append(closed, leg)
+	}
+
+	return closed, nil
+}
+
+// holdsReservation reports whether an order has funds or securities
+// reserved for its unfilled quantity
+func (s *TradingService) holdsReservation(order *models.Order) bool {
+	if order.Status == models.OrderStatusInactive {
+		return false
+	}
+	// Bracket target legs share the stop-loss leg's reservation
+	if order.ParentOrderID != nil && isBracketVariety(order) && order.Type == models.OrderTypeLimit {
+		return false
+	}
+	return true
+}
+
+// untrackAfterCommit removes an order from the book and trigger index once
+// the transaction has committed
+func (s *TradingService) untrackAfterCommit(tx *gorm.DB, order *models.Order) {
+	exchange, symbol, orderID := order.Exchange, order.Symbol, order.ID
+	repository.AfterCommit(tx, func() {
+		// A sibling leg can be closed by a fill that commits while the
+		// matching engine holds this symbol's book lock
+		go s.matchingEngine.Remove(exchange, symbol, orderID)
+		s.stopMonitor.Untrack(orderID)
+		s.expiry.Untrack(orderID)
+	})
+}
+
+// isTerminalStatus reports whether an order can no longer change
+func isTerminalStatus(status models.OrderStatus) bool {
+	switch status {
+	case models.OrderStatusCompleted, models.OrderStatusCancelled,
+		models.OrderStatusRejected, models.OrderStatusExpired:
+		return true
+	}
+	return false
+}
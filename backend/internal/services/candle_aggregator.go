@@ -0,0 +1,268 @@
+// stock-trading-app/backend/internal/services/candle_aggregator.go
+
+package services
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/shyamanurag/stock-trading-app/backend/internal/models"
+)
+
+// candleIntervals are the bar intervals built from live ticks
+var candleIntervals = []string{"1m", "5m", "15m", "30m", "1h", "1d"}
+
+// candleCloseCheck is how often bars whose interval has ended without a
+// later tick are closed
+const candleCloseCheck = time.Second
+
+// liveCandle is a bar still being built
+type liveCandle struct {
+	candle models.Candle
+	end    time.Time
+}
+
+// lastTick is the latest tick seen for an instrument
+type lastTick struct {
+	at      time.Time
+	session time.Time // Start of the session the tick belongs to
+	volume  int64     // Cumulative session volume
+}
+
+// CandleAggregator builds OHLC bars from live quotes. Bars are aligned to
+// the exchange session: they start at the open and the last bar of the day
+// is cut short at the close, so an hourly NSE bar runs 9:15-10:15 and the
+// final one 15:15-15:30. Ticks outside the session are ignored, as are ticks
+// older than the last one seen for their instrument, since quote callbacks
+// can be delivered out of order. A bar closes when a tick arrives for a later
+// bar or its interval ends; closed bars are stored and published on the
+// candles:<exchange>:<symbol>:<interval> topic.
+type CandleAggregator struct {
+	marketData MarketDataService
+	calendar   *MarketCalendar
+	store      *TimeSeriesStore
+	hub        *WebSocketHub
+	bars       map[string]*liveCandle
+	lastClosed map[string]time.Time // Open time of the last bar closed for each key
+	lastTicks  map[string]lastTick
+	mutex      sync.Mutex
+}
+
+// NewCandleAggregator creates a new CandleAggregator
+func NewCandleAggregator(
+	marketData MarketDataService,
+	calendar *MarketCalendar,
+	store *TimeSeriesStore,
+	hub *WebSocketHub,
+) *CandleAggregator {
+	return &CandleAggregator{
+		marketData: marketData,
+		calendar:   calendar,
+		store:      store,
+		hub:        hub,
+		bars:       make(map[string]*liveCandle),
+		lastClosed: make(map[string]time.Time),
+		lastTicks:  make(map[string]lastTick),
+	}
+}
+
+// Start builds bars from live quotes until ctx is done
+func (a *CandleAggregator) Start(ctx context.Context) {
+	a.marketData.OnQuoteUpdate(func(quote *models.MarketQuote) {
+		a.OnQuote(ctx, quote)
+	})
+	go a.run(ctx)
+}
+
+// run closes bars whose interval has ended once per check on the
+// calendar's clock
+func (a *CandleAggregator) run(ctx context.Context) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-a.calendar.After(candleCloseCheck):
+			a.CloseDue(ctx, now)
+		}
+	}
+}
+
+// candleTopic returns the hub topic bars for an instrument are published on
+func candleTopic(exchange string, symbol string, interval string) string {
+	return fmt.Sprintf("candles:%s:%s:%s", exchange, symbol, interval)
+}
+
+// candleBounds returns when the bar containing t opens and closes, or false
+// if t is outside the exchange session
+func (a *CandleAggregator) candleBounds(exchange string, interval string, t time.Time) (time.Time, time.Time, bool) {
+	if !a.calendar.IsOpen(exchange, t) {
+		return time.Time{}, time.Time{}, false
+	}
+
+	openAt := a.calendar.SessionOpen(exchange, t)
+	closeAt := a.calendar.SessionClose(exchange, t)
+	if interval == "1d" {
+		return openAt, closeAt, true
+	}
+
+	length := intervalDuration(interval)
+	start := openAt.Add(t.Sub(openAt) / length * length)
+	end := start.Add(length)
+	if end.After(closeAt) {
+		end = closeAt
+	}
+	return start, end, true
+}
+
+// OnQuote adds a tick to every interval's bar for the quote's instrument
+func (a *CandleAggregator) OnQuote(ctx context.Context, quote *models.MarketQuote) {
+	if quote.LastPrice <= 0 {
+		return
+	}
+
+	tickAt := quoteTime(quote, a.calendar)
+
+	var closed []*models.Candle
+
+	a.mutex.Lock()
+	instrument := instrumentKey(quote.Exchange, quote.Symbol)
+	previous, seen := a.lastTicks[instrument]
+	if seen && tickAt.Before(previous.at) {
+		a.mutex.Unlock()
+		return // Stale tick delivered after a newer one
+	}
+
+	tick := lastTick{
+		at:      tickAt,
+		session: a.calendar.SessionStart(quote.Exchange, tickAt),
+		volume:  quote.Volume,
+	}
+	var traded int64
+	switch {
+	case !seen:
+		// Without a baseline the first tick's share of the day volume is
+		// unknown
+	case !tick.session.Equal(previous.session):
+		// The exchange resets the day volume for each session
+		traded = quote.Volume
+	case quote.Volume > previous.volume:
+		traded = quote.Volume - previous.volume
+	default:
+		// A tick with the same time can repeat an earlier volume
+		tick.volume = previous.volume
+	}
+	a.lastTicks[instrument] = tick
+
+	for _, interval := range candleIntervals {
+		start, end, ok := a.candleBounds(quote.Exchange, interval, tickAt)
+		if !ok {
+			continue
+		}
+
+		key := instrument + ":" + interval
+		if closedAt, ok := a.lastClosed[key]; ok && !start.After(closedAt) {
+			continue // Late tick for a bar already closed
+		}
+		bar := a.bars[key]
+		if bar != nil && start.After(bar.candle.Timestamp) {
+			closed = append(closed, a.closeBar(key, bar))
+			bar = nil
+		}
+
+		if bar == nil {
+			bar = &liveCandle{
+				candle: models.Candle{
+					Exchange:  quote.Exchange,
+					Symbol:    quote.Symbol,
+					Interval:  interval,
+					Timestamp: start,
+					Open:      quote.LastPrice,
+					High:      quote.LastPrice,
+					Low:       quote.LastPrice,
+					Close:     quote.LastPrice,
+				},
+				end: end,
+			}
+			a.bars[key] = bar
+		}
+
+		if quote.LastPrice > bar.candle.High {
+			bar.candle.High = quote.LastPrice
+		}
+		if quote.LastPrice < bar.candle.Low {
+			bar.candle.Low = quote.LastPrice
+		}
+		bar.candle.Close = quote.LastPrice
+
+		// The day bar carries the exchange's own day volume
+		if interval == "1d" {
+			bar.candle.Volume = quote.Volume
+		} else {
+			bar.candle.Volume += traded
+		}
+	}
+	a.mutex.Unlock()
+
+	a.publish(ctx, closed)
+}
+
+// CloseDue closes every bar whose interval ended before now
+func (a *CandleAggregator) CloseDue(ctx context.Context, now time.Time) {
+	var closed []*models.Candle
+
+	a.mutex.Lock()
+	for key, bar := range a.bars {
+		if !now.Before(bar.end) {
+			closed = append(closed, a.closeBar(key, bar))
+		}
+	}
+	a.mutex.Unlock()
+
+	a.publish(ctx, closed)
+}
+
+// closeBar takes a bar out of the live set. Callers hold the mutex.
+func (a *CandleAggregator) closeBar(key string, bar *liveCandle) *models.Candle {
+	delete(a.bars, key)
+	a.lastClosed[key] = bar.candle.Timestamp
+	candle := bar.candle
+	return &candle
+}
+
+// publish stores closed bars and sends them to their topic subscribers
+func (a *CandleAggregator) publish(ctx context.Context, closed []*models.Candle) {
+	if len(closed) == 0 {
+		return
+	}
+
+	if err := a.store.SaveCandles(ctx, closed); err != nil {
+		log.Printf("Failed to store %d closed bars: %v", len(closed), err)
+	}
+
+	for _, candle := range closed {
+		if a.hub != nil {
+			a.hub.SendToTopic(candleTopic(candle.Exchange, candle.Symbol, candle.Interval), ServerMessage{
+				Type:      "candle",
+				Data:      candle,
+				Timestamp: a.calendar.Now().Unix(),
+			})
+		}
+	}
+}
+
+// Current returns the bar still being built for an instrument and
+// interval, or nil if there is none
+func (a *CandleAggregator) Current(symbol string, exchange string, interval string) *models.OHLC {
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+
+	bar, ok := a.bars[instrumentKey(exchange, symbol)+":"+interval]
+	if !ok {
+		return nil
+	}
+	ohlc := bar.candle.OHLC()
+	return &ohlc
+}
@@ -0,0 +1,136 @@
+// stock-trading-app/backend/internal/services/charges_calculator.go
+
+package services
+
+import (
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/shyamanurag/stock-trading-app/backend/internal/models"
+)
+
+// chargesRevisionDate is when the STT and exchange transaction charge
+// revisions of October 2024 took effect
+var chargesRevisionDate = time.Date(2024, time.October, 1, 0, 0, 0, 0, istLocation)
+
+// DefaultChargeRates returns the brokerage and statutory rates for NSE and
+// BSE equity and F&O, before and after the October 2024 revision
+func DefaultChargeRates() []models.ChargeRate {
+	epoch := time.Date(2020, time.January, 1, 0, 0, 0, 0, istLocation)
+	previousTo := chargesRevisionDate
+
+	return []models.ChargeRate{
+		// Rates until 30 September 2024
+		{Product: "CNC", InstrumentType: "EQ", Exchange: "NSE", STTBuyPercent: 0.1, STTSellPercent: 0.1, ExchangeTxnPercent: 0.00322, SEBIFeePerCrore: 10, StampDutyBuyPercent: 0.015, GSTPercent: 18, EffectiveFrom: epoch, EffectiveTo: &previousTo},
+		{Product: "CNC", InstrumentType: "EQ", Exchange: "BSE", STTBuyPercent: 0.1, STTSellPercent: 0.1, ExchangeTxnPercent: 0.00375, SEBIFeePerCrore: 10, StampDutyBuyPercent: 0.015, GSTPercent: 18, EffectiveFrom: epoch, EffectiveTo: &previousTo},
+		{Product: "MIS", InstrumentType: "EQ", Exchange: "NSE", BrokeragePercent: 0.03, BrokerageFlat: 20, STTSellPercent: 0.025, ExchangeTxnPercent: 0.00322, SEBIFeePerCrore: 10, StampDutyBuyPercent: 0.003, GSTPercent: 18, EffectiveFrom: epoch, EffectiveTo: &previousTo},
+		{Product: "MIS", InstrumentType: "EQ", Exchange: "BSE", BrokeragePercent: 0.03, BrokerageFlat: 20, STTSellPercent: 0.025, ExchangeTxnPercent: 0.00375, SEBIFeePerCrore: 10, StampDutyBuyPercent: 0.003, GSTPercent: 18, EffectiveFrom: epoch, EffectiveTo: &previousTo},
+		{InstrumentType: "FUT", BrokeragePercent: 0.03, BrokerageFlat: 20, STTSellPercent: 0.0125, ExchangeTxnPercent: 0.0019, SEBIFeePerCrore: 10, StampDutyBuyPercent: 0.002, GSTPercent: 18, EffectiveFrom: epoch, EffectiveTo: &previousTo},
+		{InstrumentType: "OPT", BrokerageFlat: 20, STTSellPercent: 0.0625, ExchangeTxnPercent: 0.05, SEBIFeePerCrore: 10, StampDutyBuyPercent: 0.003, GSTPercent: 18, EffectiveFrom: epoch, EffectiveTo: &previousTo},
+
+		// Rates from 1 October 2024
+		{Product: "CNC", InstrumentType: "EQ", Exchange: "NSE", STTBuyPercent: 0.1, STTSellPercent: 0.1, ExchangeTxnPercent: 0.00297, SEBIFeePerCrore: 10, StampDutyBuyPercent: 0.015, GSTPercent: 18, EffectiveFrom: chargesRevisionDate},
+		{Product: "CNC", InstrumentType: "EQ", Exchange: "BSE", STTBuyPercent: 0.1, STTSellPercent: 0.1, ExchangeTxnPercent: 0.00375, SEBIFeePerCrore: 10, StampDutyBuyPercent: 0.015, GSTPercent: 18, EffectiveFrom: chargesRevisionDate},
+		{Product: "MIS", InstrumentType: "EQ", Exchange: "NSE", BrokeragePercent: 0.03, BrokerageFlat: 20, STTSellPercent: 0.025, ExchangeTxnPercent: 0.00297, SEBIFeePerCrore: 10, StampDutyBuyPercent: 0.003, GSTPercent: 18, EffectiveFrom: chargesRevisionDate},
+		{Product: "MIS", InstrumentType: "EQ", Exchange: "BSE", BrokeragePercent: 0.03, BrokerageFlat: 20, STTSellPercent: 0.025, ExchangeTxnPercent: 0.00375, SEBIFeePerCrore: 10, StampDutyBuyPercent: 0.003, GSTPercent: 18, EffectiveFrom: chargesRevisionDate},
+		{InstrumentType: "FUT", BrokeragePercent: 0.03, BrokerageFlat: 20, STTSellPercent: 0.02, ExchangeTxnPercent: 0.00173, SEBIFeePerCrore: 10, StampDutyBuyPercent: 0.002, GSTPercent: 18, EffectiveFrom: chargesRevisionDate},
+		{InstrumentType: "OPT", BrokerageFlat: 20, STTSellPercent: 0.1, ExchangeTxnPercent: 0.03503, SEBIFeePerCrore: 10, StampDutyBuyPercent: 0.003, GSTPercent: 18, EffectiveFrom: chargesRevisionDate},
+	}
+}
+
+// ChargesCalculator computes itemised brokerage and statutory charges for
+// trades from a versioned rate table
+type ChargesCalculator struct {
+	rates []models.ChargeRate
+}
+
+// NewChargesCalculator creates a new ChargesCalculator
+func NewChargesCalculator(rates []models.ChargeRate) *ChargesCalculator {
+	return &ChargesCalculator{
+		rates: rates,
+	}
+}
+
+// rateFor returns the most specific rate for a segment in effect at t
+func (c *ChargesCalculator) rateFor(product string, instrumentType string, exchange string, t time.Time) (*models.ChargeRate, error) {
+	var best *models.ChargeRate
+	bestScore := -1
+
+	for i := range c.rates {
+		rate := &c.rates[i]
+		if rate.InstrumentType != instrumentType {
+			continue
+		}
+		if t.Before(rate.EffectiveFrom) || (rate.EffectiveTo != nil && !t.Before(*rate.EffectiveTo)) {
+			continue
+		}
+
+		score := 0
+		switch rate.Product {
+		case product:
+			score += 2
+		case "":
+		default:
+			continue
+		}
+		switch rate.Exchange {
+		case exchange:
+			score++
+		case "":
+		default:
+			continue
+		}
+
+		if score > bestScore {
+			best, bestScore = rate, score
+		}
+	}
+
+	if best == nil {
+		return nil, fmt.Errorf("no charge rates for %s %s on %s", product, instrumentType, exchange)
+	}
+	return best, nil
+}
+
+// Calculate returns the charges for trading quantity at price on the given
+// side, using the rates in effect at t
+func (c *ChargesCalculator) Calculate(product string, instrumentType string, exchange string, side models.OrderSide, quantity int, price float64, t time.Time) (*models.ChargesBreakup, error) {
+	rate, err := c.rateFor(product, instrumentType, exchange, t)
+	if err != nil {
+		return nil, err
+	}
+
+	turnover := price * float64(quantity)
+
+	brokerage := turnover * rate.BrokeragePercent / 100
+	if rate.BrokerageFlat > 0 && (rate.BrokeragePercent == 0 || brokerage > rate.BrokerageFlat) {
+		brokerage = rate.BrokerageFlat
+	}
+
+	sttPercent := rate.STTSellPercent
+	if side == models.OrderSideBuy {
+		sttPercent = rate.STTBuyPercent
+	}
+
+	breakup := &models.ChargesBreakup{
+		Turnover:          roundPaise(turnover),
+		Brokerage:         roundPaise(brokerage),
+		STT:               roundPaise(turnover * sttPercent / 100),
+		ExchangeTxnCharge: roundPaise(turnover * rate.ExchangeTxnPercent / 100),
+		SEBIFee:           roundPaise(turnover / 1e7 * rate.SEBIFeePerCrore),
+	}
+	if side == models.OrderSideBuy {
+		breakup.StampDuty = roundPaise(turnover * rate.StampDutyBuyPercent / 100)
+	}
+	breakup.GST = roundPaise((breakup.Brokerage + breakup.ExchangeTxnCharge + breakup.SEBIFee) * rate.GSTPercent / 100)
+	breakup.Total = roundPaise(breakup.Brokerage + breakup.STT + breakup.ExchangeTxnCharge +
+		breakup.SEBIFee + breakup.StampDuty + breakup.GST)
+
+	return breakup, nil
+}
+
+// roundPaise rounds an amount to two decimal places
+func roundPaise(amount float64) float64 {
+	return math.Round(amount*100) / 100
+}
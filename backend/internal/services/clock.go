@@ -0,0 +1,93 @@
+// stock-trading-app/backend/internal/services/clock.go
+
+package services
+
+import (
+	"sync"
+	"time"
+)
+
+// Clock is the time source for the trading engine. It is the system clock
+// in production; in replay it follows the recorded market so orders,
+// expiries and sessions run on the replayed day's time.
+type Clock interface {
+	Now() time.Time
+
+	// After returns a channel that receives the clock's time once d has
+	// passed on the clock
+	After(d time.Duration) <-chan time.Time
+}
+
+// systemClock is the wall clock
+type systemClock struct{}
+
+func (systemClock) Now() time.Time {
+	return time.Now()
+}
+
+func (systemClock) After(d time.Duration) <-chan time.Time {
+	return time.After(d)
+}
+
+// clockWaiter is a pending After on a VirtualClock
+type clockWaiter struct {
+	at time.Time
+	ch chan time.Time
+}
+
+// VirtualClock is a clock that only moves when it is set. It never goes
+// backwards; waiters fire as soon as the clock reaches their time.
+type VirtualClock struct {
+	now     time.Time
+	waiters []clockWaiter
+	mutex   sync.Mutex
+}
+
+// NewVirtualClock creates a VirtualClock stopped at start
+func NewVirtualClock(start time.Time) *VirtualClock {
+	return &VirtualClock{now: start}
+}
+
+// Now returns the clock's current time
+func (c *VirtualClock) Now() time.Time {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	return c.now
+}
+
+// After returns a channel that receives the clock's time once it has moved
+// d past now
+func (c *VirtualClock) After(d time.Duration) <-chan time.Time {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	ch := make(chan time.Time, 1)
+	if d <= 0 {
+		ch <- c.now
+		return ch
+	}
+	c.waiters = append(c.waiters, clockWaiter{at: c.now.Add(d), ch: ch})
+	return ch
+}
+
+// Set moves the clock forward to t and fires every waiter that is due.
+// Times before the clock's current time are ignored.
+func (c *VirtualClock) Set(t time.Time) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	if !t.After(c.now) {
+		return
+	}
+	c.now = t
+
+	pending := c.waiters[:0]
+	for _, waiter := range c.waiters {
+		if waiter.at.After(t) {
+			pending = append(pending, waiter)
+			continue
+		}
+		waiter.ch <- t
+	}
+	c.waiters = pending
+}
@@ -0,0 +1,327 @@
+// stock-trading-app/backend/internal/services/fill_simulator.go
+
+package services
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"math/rand"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/shyamanurag/stock-trading-app/backend/internal/models"
+	"github.com/shyamanurag/stock-trading-app/backend/internal/repository"
+	"gorm.io/gorm"
+)
+
+const (
+	// depthHistory is how much market depth is kept beyond the longest
+	// simulated latency, so an order can execute against the book as it
+	// stood at its execution time
+	depthHistory = time.Minute
+
+	// maxExecutionWait caps how long an order waits in real time for the
+	// clock to reach its execution time, so a stalled replay clock cannot
+	// hold up order placement
+	maxExecutionWait = 5 * time.Second
+)
+
+// FillSimulatorConfig controls how market orders are filled against the
+// visible order book
+type FillSimulatorConfig struct {
+	Latency         time.Duration // Delay between an order arriving and executing
+	LatencyJitter   time.Duration // Random extra latency of up to this much
+	SlippageBps     float64       // Random adverse slippage of up to this many basis points per level
+	ImpactBps       float64       // Further adverse slippage for each level walked past the first
+	FillBeyondDepth bool          // Fill what the book cannot absorb past its last level, or at the last price without any depth, instead of leaving it unfilled
+	Seed            int64         // Seed for the random source; zero seeds from the clock
+}
+
+// DefaultFillSimulatorConfig returns the fill simulation used for paper
+// trading
+func DefaultFillSimulatorConfig() FillSimulatorConfig {
+	return FillSimulatorConfig{
+		Latency:         50 * time.Millisecond,
+		LatencyJitter:   100 * time.Millisecond,
+		SlippageBps:     2,
+		ImpactBps:       1,
+		FillBeyondDepth: false,
+	}
+}
+
+// SimulatedFill is one execution produced by the fill simulator
+type SimulatedFill struct {
+	Quantity int
+	Price    float64
+	Time     time.Time
+}
+
+// depthSnapshot is one market depth update and the liquidity simulated
+// fills have already taken from it
+type depthSnapshot struct {
+	depth *models.MarketDepth
+	taken map[float64]int
+}
+
+// FillSimulator fills market orders by walking the opposite side of the
+// market depth level by level. An order executes at its arrival time plus
+// the simulated latency, against the depth snapshot in force at that time;
+// callers wait for the calendar's clock to reach it before opening the
+// order's transaction. Liquidity taken from a snapshot is
+// remembered once the order's transaction commits, so back-to-back orders
+// do not fill against the same quantity twice. All randomness comes from
+// one seeded source so a run can be reproduced exactly.
+type FillSimulator struct {
+	marketData MarketDataService
+	calendar   *MarketCalendar
+	config     FillSimulatorConfig
+	rng        *rand.Rand
+	history    map[string][]*depthSnapshot // Recent snapshots per instrument, oldest first
+	mutex      sync.Mutex
+}
+
+// NewFillSimulator creates a new FillSimulator
+func NewFillSimulator(marketData MarketDataService, calendar *MarketCalendar, config FillSimulatorConfig) *FillSimulator {
+	seed := config.Seed
+	if seed == 0 {
+		seed = time.Now().UnixNano()
+	}
+
+	return &FillSimulator{
+		marketData: marketData,
+		calendar:   calendar,
+		config:     config,
+		rng:        rand.New(rand.NewSource(seed)),
+		history:    make(map[string][]*depthSnapshot),
+	}
+}
+
+// Start records depth updates so orders can execute against the book as it
+// stood after their latency
+func (f *FillSimulator) Start() {
+	f.marketData.OnDepthUpdate(func(depth *models.MarketDepth) {
+		f.mutex.Lock()
+		defer f.mutex.Unlock()
+		f.record(depth)
+	})
+}
+
+// ExecutionTime returns when a market order arriving at arrivedAt executes
+func (f *FillSimulator) ExecutionTime(arrivedAt time.Time) time.Time {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	return arrivedAt.Add(f.latency())
+}
+
+// Wait blocks until the calendar's clock reaches an execution time, ctx is
+// done or maxExecutionWait has passed. It must not be called with a
+// transaction open.
+func (f *FillSimulator) Wait(ctx context.Context, executedAt time.Time) error {
+	wait := executedAt.Sub(f.calendar.Now())
+	if wait <= 0 {
+		return nil
+	}
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-f.calendar.After(wait):
+	case <-time.After(maxExecutionWait):
+	}
+	return nil
+}
+
+// Simulate returns the executions for quantity of a market order executing
+// at executedAt, against the depth in force then. When the book cannot
+// absorb the whole quantity and fills beyond depth are disabled, the fills
+// add up to less than quantity and the rest of the order is left unfilled.
+// Without any depth nothing fills, unless fills beyond depth are enabled
+// and the order fills at the last traded price. The liquidity taken is
+// recorded once tx commits.
+func (f *FillSimulator) Simulate(ctx context.Context, tx *gorm.DB, order *models.Order, quantity int, executedAt time.Time) ([]SimulatedFill, error) {
+	f.mutex.Lock()
+	fills, snapshot, taken, err := f.walk(ctx, order, quantity, executedAt)
+	f.mutex.Unlock()
+	if err != nil {
+		return nil, err
+	}
+
+	if len(taken) > 0 {
+		repository.AfterCommit(tx, func() {
+			f.mutex.Lock()
+			defer f.mutex.Unlock()
+			for price, quantity := range taken {
+				snapshot.taken[price] += quantity
+			}
+		})
+	}
+	return fills, nil
+}
+
+// walk works out the fills for quantity of an order executing at
+// executedAt. It returns the snapshot filled against and the liquidity
+// taken from each of its levels. The caller holds the mutex.
+func (f *FillSimulator) walk(ctx context.Context, order *models.Order, quantity int, executedAt time.Time) ([]SimulatedFill, *depthSnapshot, map[float64]int, error) {
+	tickSize := f.tickSize(order)
+	snapshot := f.snapshotAt(order, executedAt)
+	var levels []models.DepthLevel
+	if snapshot != nil {
+		levels = f.opposingLevels(order, snapshot.depth)
+	}
+	if len(levels) == 0 {
+		if !f.config.FillBeyondDepth {
+			return nil, nil, nil, nil
+		}
+
+		lastPrice, err := f.marketData.GetCurrentPrice(ctx, order.Symbol)
+		if err != nil {
+			return nil, nil, nil, fmt.Errorf("failed to get current price: %w", err)
+		}
+		return []SimulatedFill{{
+			Quantity: quantity,
+			Price:    f.slip(order.Side, lastPrice, 0, tickSize),
+			Time:     executedAt,
+		}}, nil, nil, nil
+	}
+
+	var fills []SimulatedFill
+	taken := make(map[float64]int)
+	remaining := quantity
+	var lastLevel float64
+	for i, level := range levels {
+		if remaining == 0 {
+			break
+		}
+		lastLevel = level.Price
+
+		available := level.Quantity - snapshot.taken[level.Price]
+		if available <= 0 {
+			continue
+		}
+
+		take := minInt(remaining, available)
+		taken[level.Price] += take
+		remaining -= take
+
+		fills = append(fills, SimulatedFill{
+			Quantity: take,
+			Price:    f.slip(order.Side, level.Price, i, tickSize),
+			Time:     executedAt,
+		})
+	}
+
+	// Whatever the visible book could not absorb fills one level past it
+	if remaining > 0 && f.config.FillBeyondDepth {
+		fills = append(fills, SimulatedFill{
+			Quantity: remaining,
+			Price:    f.slip(order.Side, lastLevel, len(levels), tickSize),
+			Time:     executedAt,
+		})
+	}
+
+	return fills, snapshot, taken, nil
+}
+
+// opposingLevels returns the depth levels an order takes liquidity from,
+// best price first
+func (f *FillSimulator) opposingLevels(order *models.Order, depth *models.MarketDepth) []models.DepthLevel {
+	if depth == nil {
+		return nil
+	}
+	if order.Side == models.OrderSideBuy {
+		return depth.Asks
+	}
+	return depth.Bids
+}
+
+// record adds a depth update to its instrument's history, which is kept in
+// update time order, and drops snapshots no order can execute against any
+// more. A snapshot already recorded keeps the liquidity taken from it.
+func (f *FillSimulator) record(depth *models.MarketDepth) *depthSnapshot {
+	key := instrumentKey(depth.Exchange, depth.Symbol)
+	snapshots := f.history[key]
+
+	i := sort.Search(len(snapshots), func(i int) bool {
+		return !snapshots[i].depth.LastUpdateTime.Before(depth.LastUpdateTime)
+	})
+	if i < len(snapshots) && snapshots[i].depth.LastUpdateTime.Equal(depth.LastUpdateTime) {
+		return snapshots[i]
+	}
+
+	snapshot := &depthSnapshot{depth: depth, taken: make(map[float64]int)}
+	snapshots = append(snapshots, nil)
+	copy(snapshots[i+1:], snapshots[i:])
+	snapshots[i] = snapshot
+
+	// Keep the snapshot in force at the cutoff and everything after it
+	cutoff := snapshots[len(snapshots)-1].depth.LastUpdateTime.
+		Add(-(f.config.Latency + f.config.LatencyJitter + depthHistory))
+	keep := sort.Search(len(snapshots), func(i int) bool {
+		return snapshots[i].depth.LastUpdateTime.After(cutoff)
+	})
+	if keep > 0 {
+		snapshots = snapshots[keep-1:]
+	}
+
+	f.history[key] = snapshots
+	return snapshot
+}
+
+// snapshotAt returns the depth snapshot in force for an order's instrument
+// at t. Without recorded history it falls back to the current depth.
+func (f *FillSimulator) snapshotAt(order *models.Order, t time.Time) *depthSnapshot {
+	snapshots := f.history[instrumentKey(order.Exchange, order.Symbol)]
+	i := sort.Search(len(snapshots), func(i int) bool {
+		return snapshots[i].depth.LastUpdateTime.After(t)
+	})
+	if i > 0 {
+		return snapshots[i-1]
+	}
+
+	depth, err := f.marketData.GetMarketDepth(order.Symbol, order.Exchange)
+	if err != nil || depth == nil {
+		return nil
+	}
+	return f.record(depth)
+}
+
+// latency returns the delay before an order executes
+func (f *FillSimulator) latency() time.Duration {
+	latency := f.config.Latency
+	if f.config.LatencyJitter > 0 {
+		latency += time.Duration(f.rng.Int63n(int64(f.config.LatencyJitter)))
+	}
+	return latency
+}
+
+// slip moves price against the order by the configured slippage for the
+// level-th level walked and rounds it to the tick in the adverse direction
+func (f *FillSimulator) slip(side models.OrderSide, price float64, level int, tickSize float64) float64 {
+	bps := f.config.ImpactBps * float64(level)
+	if f.config.SlippageBps > 0 {
+		bps += f.rng.Float64() * f.config.SlippageBps
+	}
+
+	if side == models.OrderSideBuy {
+		return roundToTick(price*(1+bps/10000), tickSize, math.Ceil)
+	}
+	return roundToTick(price*(1-bps/10000), tickSize, math.Floor)
+}
+
+// tickSize returns an order's instrument tick size, or a paisa if unknown
+func (f *FillSimulator) tickSize(order *models.Order) float64 {
+	symbol, err := f.marketData.GetSymbol(order.Symbol, order.Exchange)
+	if err != nil || symbol == nil || symbol.TickSize <= 0 {
+		return 0.01
+	}
+	return symbol.TickSize
+}
+
+// roundToTick rounds price to a multiple of tickSize using round
+func roundToTick(price float64, tickSize float64, round func(float64) float64) float64 {
+	// Round off float noise first so exact multiples stay where they are
+	ticks := math.Round(price/tickSize*1e6) / 1e6
+	return roundPaise(round(ticks) * tickSize)
+}
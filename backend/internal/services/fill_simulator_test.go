@@ -0,0 +1,218 @@
+// stock-trading-app/backend/internal/services/fill_simulator_test.go
+
+package services
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/shyamanurag/stock-trading-app/backend/internal/models"
+	"gorm.io/gorm"
+)
+
+// staticDepthMarketData serves one fixed market depth and last price to the
+// fill simulator
+type staticDepthMarketData struct {
+	MarketDataService
+	depth     *models.MarketDepth
+	lastPrice float64
+}
+
+func (m *staticDepthMarketData) GetMarketDepth(symbol string, exchange string) (*models.MarketDepth, error) {
+	return m.depth, nil
+}
+
+func (m *staticDepthMarketData) GetCurrentPrice(ctx context.Context, symbol string) (float64, error) {
+	return m.lastPrice, nil
+}
+
+func (m *staticDepthMarketData) GetSymbol(symbol string, exchange string) (*models.Symbol, error) {
+	return nil, fmt.Errorf("symbol %s not found", symbol)
+}
+
+// simulateSession runs the same sequence of market orders through a new
+// simulator seeded with seed
+func simulateSession(t *testing.T, seed int64) [][]SimulatedFill {
+	t.Helper()
+
+	arrivedAt := time.Date(2024, 3, 14, 10, 0, 0, 0, istLocation)
+	marketData := &staticDepthMarketData{depth: &models.MarketDepth{
+		Symbol:   "TCS",
+		Exchange: "NSE",
+		Bids: []models.DepthLevel{
+			{Price: 3449.95, Quantity: 40},
+			{Price: 3449.90, Quantity: 60},
+			{Price: 3449.80, Quantity: 100},
+		},
+		Asks: []models.DepthLevel{
+			{Price: 3450.05, Quantity: 40},
+			{Price: 3450.10, Quantity: 60},
+			{Price: 3450.20, Quantity: 100},
+		},
+		LastUpdateTime: arrivedAt.Add(-time.Second),
+	}, lastPrice: 3450}
+
+	calendar := NewMarketCalendar()
+	calendar.SetClock(NewVirtualClock(arrivedAt))
+
+	config := DefaultFillSimulatorConfig()
+	config.Seed = seed
+	config.SlippageBps = 10
+	simulator := NewFillSimulator(marketData, calendar, config)
+
+	orders := []*models.Order{
+		{Symbol: "TCS", Exchange: "NSE", Side: models.OrderSideBuy},
+		{Symbol: "TCS", Exchange: "NSE", Side: models.OrderSideBuy},
+		{Symbol: "TCS", Exchange: "NSE", Side: models.OrderSideSell},
+		{Symbol: "TCS", Exchange: "NSE", Side: models.OrderSideBuy},
+	}
+
+	var session [][]SimulatedFill
+	for i, order := range orders {
+		// Outside a managed transaction the liquidity is taken at once
+		executedAt := simulator.ExecutionTime(arrivedAt.Add(time.Duration(i) * time.Second))
+		fills, err := simulator.Simulate(context.Background(), &gorm.DB{}, order, 70, executedAt)
+		if err != nil {
+			t.Fatalf("order %d: %v", i+1, err)
+		}
+		session = append(session, fills)
+	}
+	return session
+}
+
+func TestFillSimulatorIsDeterministicForASeed(t *testing.T) {
+	first := simulateSession(t, 42)
+	second := simulateSession(t, 42)
+
+	if !reflect.DeepEqual(first, second) {
+		t.Fatalf("same seed produced different fills:\n%v\n%v", first, second)
+	}
+
+	// The second buy starts where the first left the offer side
+	if got := first[1][0].Quantity; got != 30 {
+		t.Errorf("second buy took %d from the second ask level, want 30", got)
+	}
+
+	if other := simulateSession(t, 7); reflect.DeepEqual(first, other) {
+		t.Errorf("different seeds produced identical fills")
+	}
+}
+
+func TestFillSimulatorWalk(t *testing.T) {
+	executedAt := time.Date(2024, 3, 14, 10, 0, 0, 0, istLocation)
+	bids := []models.DepthLevel{
+		{Price: 3449.95, Quantity: 40},
+		{Price: 3449.90, Quantity: 60},
+	}
+	asks := []models.DepthLevel{
+		{Price: 3450.05, Quantity: 40},
+		{Price: 3450.10, Quantity: 60},
+	}
+
+	tests := []struct {
+		name            string
+		side            models.OrderSide
+		quantity        int
+		bids            []models.DepthLevel
+		asks            []models.DepthLevel
+		fillBeyondDepth bool
+		want            []SimulatedFill
+	}{
+		{
+			name:     "buy fills within the first level",
+			side:     models.OrderSideBuy,
+			quantity: 30,
+			bids:     bids,
+			asks:     asks,
+			want: []SimulatedFill{
+				{Quantity: 30, Price: 3450.05, Time: executedAt},
+			},
+		},
+		{
+			name:     "sell walks the bids",
+			side:     models.OrderSideSell,
+			quantity: 50,
+			bids:     bids,
+			asks:     asks,
+			want: []SimulatedFill{
+				{Quantity: 40, Price: 3449.95, Time: executedAt},
+				{Quantity: 10, Price: 3449.90, Time: executedAt},
+			},
+		},
+		{
+			name:     "partial fill when the depth runs out",
+			side:     models.OrderSideBuy,
+			quantity: 150,
+			bids:     bids,
+			asks:     asks,
+			want: []SimulatedFill{
+				{Quantity: 40, Price: 3450.05, Time: executedAt},
+				{Quantity: 60, Price: 3450.10, Time: executedAt},
+			},
+		},
+		{
+			name:            "rest fills past the depth when enabled",
+			side:            models.OrderSideBuy,
+			quantity:        150,
+			bids:            bids,
+			asks:            asks,
+			fillBeyondDepth: true,
+			want: []SimulatedFill{
+				{Quantity: 40, Price: 3450.05, Time: executedAt},
+				{Quantity: 60, Price: 3450.10, Time: executedAt},
+				{Quantity: 50, Price: 3450.10, Time: executedAt},
+			},
+		},
+		{
+			name:     "no fills without opposing depth",
+			side:     models.OrderSideBuy,
+			quantity: 50,
+			bids:     bids,
+		},
+		{
+			name:            "last price without opposing depth when enabled",
+			side:            models.OrderSideSell,
+			quantity:        50,
+			asks:            asks,
+			fillBeyondDepth: true,
+			want: []SimulatedFill{
+				{Quantity: 50, Price: 3450, Time: executedAt},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			marketData := &staticDepthMarketData{depth: &models.MarketDepth{
+				Symbol:         "TCS",
+				Exchange:       "NSE",
+				Bids:           tt.bids,
+				Asks:           tt.asks,
+				LastUpdateTime: executedAt.Add(-time.Second),
+			}, lastPrice: 3450}
+
+			calendar := NewMarketCalendar()
+			calendar.SetClock(NewVirtualClock(executedAt))
+
+			// Without slippage every fill is at its level's price
+			config := DefaultFillSimulatorConfig()
+			config.Seed = 1
+			config.SlippageBps = 0
+			config.ImpactBps = 0
+			config.FillBeyondDepth = tt.fillBeyondDepth
+			simulator := NewFillSimulator(marketData, calendar, config)
+
+			order := &models.Order{Symbol: "TCS", Exchange: "NSE", Side: tt.side}
+			fills, _, _, err := simulator.walk(context.Background(), order, tt.quantity, executedAt)
+			if err != nil {
+				t.Fatalf("walk: %v", err)
+			}
+			if len(fills) != len(tt.want) || (len(fills) > 0 && !reflect.DeepEqual(fills, tt.want)) {
+				t.Errorf("fills = %v, want %v", fills, tt.want)
+			}
+		})
+	}
+}
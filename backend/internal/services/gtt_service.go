@@ -0,0 +1,523 @@
+// stock-trading-app/backend/internal/services/gtt_service.go
+
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shyamanurag/stock-trading-app/backend/internal/models"
+	"github.com/shyamanurag/stock-trading-app/backend/internal/repository"
+	"gorm.io/gorm"
+)
+
+// gttMaxLifetime is how long a GTT can stay active
+const gttMaxLifetime = 365 * 24 * time.Hour
+
+// gttExpiryInterval is how often expired GTTs are swept
+const gttExpiryInterval = time.Hour
+
+var (
+	// ErrGTTNotFound is returned when a GTT does not exist or belongs to
+	// another user
+	ErrGTTNotFound = errors.New("GTT not found")
+
+	// errGTTNotActive is returned when a GTT has already triggered, been
+	// cancelled or expired
+	errGTTNotActive = errors.New("GTT is no longer active")
+)
+
+// GTTService manages good-till-triggered conditional orders. Active GTTs are
+// indexed by symbol and checked against every quote; when a leg triggers the
+// GTT is claimed and its order is placed through the TradingService.
+type GTTService struct {
+	gttRepo        *repository.GTTRepository
+	transactionMgr *repository.TransactionManager
+	trading        *TradingService
+	marketData     MarketDataService
+	hub            *WebSocketHub
+	index          map[string]map[string]*models.GTT
+	mutex          sync.Mutex
+}
+
+// NewGTTService creates a new GTTService
+func NewGTTService(
+	gttRepo *repository.GTTRepository,
+	transactionMgr *repository.TransactionManager,
+	trading *TradingService,
+	marketData MarketDataService,
+	hub *WebSocketHub,
+) *GTTService {
+	return &GTTService{
+		gttRepo:        gttRepo,
+		transactionMgr: transactionMgr,
+		trading:        trading,
+		marketData:     marketData,
+		hub:            hub,
+		index:          make(map[string]map[string]*models.GTT),
+	}
+}
+
+// Start loads active GTTs, subscribes to quotes and sweeps expired GTTs
+// until ctx is done
+func (s *GTTService) Start(ctx context.Context) error {
+	gtts, err := s.gttRepo.GetActive(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to load active GTTs: %w", err)
+	}
+
+	for _, gtt := range gtts {
+		s.track(gtt)
+	}
+
+	s.marketData.OnQuoteUpdate(func(quote *models.MarketQuote) {
+		s.onQuote(context.Background(), quote)
+	})
+
+	go s.runExpiry(ctx)
+	return nil
+}
+
+// CreateGTT creates a GTT for a user. Leg trigger directions are set by the
+// current price: a trigger above it fires when the price rises to it and a
+// trigger below it fires when the price falls to it.
+func (s *GTTService) CreateGTT(ctx context.Context, userID string, req *models.CreateGTTRequest) (*models.GTT, error) {
+	if req.Side != models.OrderSideBuy && req.Side != models.OrderSideSell {
+		return nil, fmt.Errorf("invalid order side: %s", req.Side)
+	}
+
+	lastPrice, err := s.marketData.GetCurrentPrice(ctx, req.Symbol)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get current price: %w", err)
+	}
+
+	legs, err := s.buildLegs(req.Type, req.Legs, lastPrice)
+	if err != nil {
+		return nil, err
+	}
+
+	expiresAt, err := gttExpiry(req.ExpiresAt, s.trading.calendar.Now())
+	if err != nil {
+		return nil, err
+	}
+
+	instrumentType := req.InstrumentType
+	if instrumentType == "" {
+		instrumentType = "EQ"
+	}
+
+	gtt := &models.GTT{
+		ID:             uuid.New().String(),
+		UserID:         userID,
+		Type:           req.Type,
+		Status:         models.GTTStatusActive,
+		Symbol:         req.Symbol,
+		Exchange:       req.Exchange,
+		Side:           req.Side,
+		Product:        req.Product,
+		InstrumentType: instrumentType,
+		LastPrice:      lastPrice,
+		Legs:           legs,
+		ExpiresAt:      expiresAt,
+	}
+	for i := range gtt.Legs {
+		gtt.Legs[i].GTTID = gtt.ID
+	}
+
+	err = s.transactionMgr.WithTransaction(ctx, func(tx *gorm.DB) error {
+		gttRepo := repository.NewGTTRepository(tx)
+		if err := gttRepo.Create(ctx, gtt); err != nil {
+			return fmt.Errorf("failed to create GTT: %w", err)
+		}
+		return s.recordStatusChange(ctx, tx, gtt, "", "created", nil)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	s.track(gtt)
+	return gtt, nil
+}
+
+// GetGTT returns one of a user's GTTs
+func (s *GTTService) GetGTT(ctx context.Context, id string, userID string) (*models.GTT, error) {
+	gtt, err := s.gttRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get GTT: %w", err)
+	}
+	if gtt == nil || gtt.UserID != userID {
+		return nil, ErrGTTNotFound
+	}
+	return gtt, nil
+}
+
+// ListGTTs returns a user's GTTs. If status is set only GTTs in that status
+// are returned.
+func (s *GTTService) ListGTTs(ctx context.Context, userID string, status models.GTTStatus) ([]*models.GTT, error) {
+	gtts, err := s.gttRepo.GetByUserID(ctx, userID, status)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get GTTs: %w", err)
+	}
+	return gtts, nil
+}
+
+// GetGTTHistory returns the status history of one of a user's GTTs
+func (s *GTTService) GetGTTHistory(ctx context.Context, id string, userID string) ([]*models.GTTStatusChange, error) {
+	if _, err := s.GetGTT(ctx, id, userID); err != nil {
+		return nil, err
+	}
+
+	changes, err := s.gttRepo.GetStatusHistory(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get GTT history: %w", err)
+	}
+	return changes, nil
+}
+
+// ModifyGTT changes the legs or expiry of an active GTT. New legs are
+// checked against the current price, which becomes the GTT's reference.
+func (s *GTTService) ModifyGTT(ctx context.Context, id string, userID string, req *models.ModifyGTTRequest) (*models.GTT, error) {
+	var modified *models.GTT
+	err := s.transactionMgr.WithTransaction(ctx, func(tx *gorm.DB) error {
+		gttRepo := repository.NewGTTRepository(tx)
+		gtt, err := gttRepo.GetByID(ctx, id)
+		if err != nil {
+			return fmt.Errorf("failed to get GTT: %w", err)
+		}
+		if gtt == nil || gtt.UserID != userID {
+			return ErrGTTNotFound
+		}
+		if gtt.Status != models.GTTStatusActive {
+			return errGTTNotActive
+		}
+
+		if req.Legs != nil {
+			lastPrice, err := s.marketData.GetCurrentPrice(ctx, gtt.Symbol)
+			if err != nil {
+				return fmt.Errorf("failed to get current price: %w", err)
+			}
+			legs, err := s.buildLegs(gtt.Type, req.Legs, lastPrice)
+			if err != nil {
+				return err
+			}
+			for i := range legs {
+				legs[i].GTTID = gtt.ID
+			}
+			if err := gttRepo.ReplaceLegs(ctx, gtt.ID, legs); err != nil {
+				return fmt.Errorf("failed to update GTT legs: %w", err)
+			}
+			gtt.Legs = legs
+			gtt.LastPrice = lastPrice
+		}
+
+		if req.ExpiresAt != nil {
+			expiresAt, err := gttExpiry(req.ExpiresAt, s.trading.calendar.Now())
+			if err != nil {
+				return err
+			}
+			gtt.ExpiresAt = expiresAt
+		}
+
+		if err := gttRepo.Update(ctx, gtt); err != nil {
+			return fmt.Errorf("failed to update GTT: %w", err)
+		}
+		if err := s.recordStatusChange(ctx, tx, gtt, gtt.Status, "modified", nil); err != nil {
+			return err
+		}
+
+		modified = gtt
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	s.untrack(modified)
+	s.track(modified)
+	return modified, nil
+}
+
+// CancelGTT cancels an active GTT
+func (s *GTTService) CancelGTT(ctx context.Context, id string, userID string) error {
+	gtt, err := s.GetGTT(ctx, id, userID)
+	if err != nil {
+		return err
+	}
+
+	if err := s.transition(ctx, gtt, models.GTTStatusActive, models.GTTStatusCancelled, "cancelled by user", nil); err != nil {
+		return err
+	}
+
+	s.untrack(gtt)
+	return nil
+}
+
+// buildLegs validates leg requests against the current price and builds
+// the legs. An OCO GTT needs one trigger on each side of the price.
+func (s *GTTService) buildLegs(gttType models.GTTType, requests []models.GTTLegRequest, lastPrice float64) ([]models.GTTLeg, error) {
+	switch gttType {
+	case models.GTTTypeSingle:
+		if len(requests) != 1 {
+			return nil, fmt.Errorf("single GTTs take exactly one leg")
+		}
+	case models.GTTTypeOCO:
+		if len(requests) != 2 {
+			return nil, fmt.Errorf("OCO GTTs take exactly two legs")
+		}
+		above := requests[0].TriggerPrice > lastPrice
+		if above == (requests[1].TriggerPrice > lastPrice) {
+			return nil, fmt.Errorf("OCO GTTs need one trigger above and one below the current price %.2f", lastPrice)
+		}
+	default:
+		return nil, fmt.Errorf("invalid GTT type: %s", gttType)
+	}
+
+	legs := make([]models.GTTLeg, 0, len(requests))
+	for i, req := range requests {
+		if req.TriggerPrice <= 0 || req.TriggerPrice == lastPrice {
+			return nil, fmt.Errorf("trigger price must differ from the current price %.2f", lastPrice)
+		}
+		if req.Quantity <= 0 {
+			return nil, fmt.Errorf("quantity must be positive")
+		}
+		switch req.OrderType {
+		case models.OrderTypeMarket:
+			if req.Price != nil {
+				return nil, fmt.Errorf("market orders do not take a price")
+			}
+		case models.OrderTypeLimit:
+			if req.Price == nil || *req.Price <= 0 {
+				return nil, fmt.Errorf("limit orders require a valid price")
+			}
+		default:
+			return nil, fmt.Errorf("GTT orders must be market or limit orders")
+		}
+
+		legs = append(legs, models.GTTLeg{
+			ID:           uuid.New().String(),
+			Leg:          i + 1,
+			TriggerPrice: req.TriggerPrice,
+			Quantity:     req.Quantity,
+			OrderType:    req.OrderType,
+			Price:        req.Price,
+		})
+	}
+	return legs, nil
+}
+
+// gttExpiry returns a requested GTT expiry, defaulting to the maximum
+// lifetime from now
+func gttExpiry(requested *time.Time, now time.Time) (time.Time, error) {
+	if requested == nil {
+		return now.Add(gttMaxLifetime), nil
+	}
+	if !requested.After(now) {
+		return time.Time{}, fmt.Errorf("GTT expiry must be in the future")
+	}
+	if requested.After(now.Add(gttMaxLifetime)) {
+		return time.Time{}, fmt.Errorf("GTTs can stay active for at most one year")
+	}
+	return *requested, nil
+}
+
+// transition moves a GTT between statuses and records the change. It
+// returns errGTTNotActive if the GTT had already left the from status.
+func (s *GTTService) transition(ctx context.Context, gtt *models.GTT, from models.GTTStatus, to models.GTTStatus, reason string, orderID *string) error {
+	return s.transactionMgr.WithTransaction(ctx, func(tx *gorm.DB) error {
+		ok, err := repository.NewGTTRepository(tx).TransitionStatus(ctx, gtt.ID, from, to)
+		if err != nil {
+			return fmt.Errorf("failed to update GTT status: %w", err)
+		}
+		if !ok {
+			return errGTTNotActive
+		}
+
+		gtt.Status = to
+		return s.recordStatusChange(ctx, tx, gtt, from, reason, orderID)
+	})
+}
+
+// recordStatusChange adds an entry to a GTT's status history
+func (s *GTTService) recordStatusChange(ctx context.Context, tx *gorm.DB, gtt *models.GTT, from models.GTTStatus, reason string, orderID *string) error {
+	change := &models.GTTStatusChange{
+		ID:         uuid.New().String(),
+		GTTID:      gtt.ID,
+		FromStatus: from,
+		ToStatus:   gtt.Status,
+		Reason:     reason,
+		OrderID:    orderID,
+		CreatedAt:  s.trading.calendar.Now(),
+	}
+	if err := repository.NewGTTRepository(tx).CreateStatusChange(ctx, change); err != nil {
+		return fmt.Errorf("failed to record GTT status change: %w", err)
+	}
+	return nil
+}
+
+// track adds an active GTT to the symbol index
+func (s *GTTService) track(gtt *models.GTT) {
+	key := fmt.Sprintf("%s:%s", gtt.Exchange, gtt.Symbol)
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if _, ok := s.index[key]; !ok {
+		s.index[key] = make(map[string]*models.GTT)
+	}
+	s.index[key][gtt.ID] = gtt
+}
+
+// untrack removes a GTT from the symbol index
+func (s *GTTService) untrack(gtt *models.GTT) {
+	key := fmt.Sprintf("%s:%s", gtt.Exchange, gtt.Symbol)
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	delete(s.index[key], gtt.ID)
+}
+
+// legTriggered reports whether price has reached a leg's trigger from the
+// side the GTT was created on
+func legTriggered(gtt *models.GTT, leg *models.GTTLeg, price float64) bool {
+	if leg.TriggerPrice > gtt.LastPrice {
+		return price >= leg.TriggerPrice
+	}
+	return price <= leg.TriggerPrice
+}
+
+// onQuote fires every GTT for the quote's symbol that the last price has
+// triggered
+func (s *GTTService) onQuote(ctx context.Context, quote *models.MarketQuote) {
+	if quote.LastPrice <= 0 {
+		return
+	}
+
+	type trigger struct {
+		gtt *models.GTT
+		leg *models.GTTLeg
+	}
+
+	key := fmt.Sprintf("%s:%s", quote.Exchange, quote.Symbol)
+	var triggered []trigger
+
+	s.mutex.Lock()
+	for id, gtt := range s.index[key] {
+		for i := range gtt.Legs {
+			if legTriggered(gtt, &gtt.Legs[i], quote.LastPrice) {
+				triggered = append(triggered, trigger{gtt: gtt, leg: &gtt.Legs[i]})
+				delete(s.index[key], id)
+				break
+			}
+		}
+	}
+	s.mutex.Unlock()
+
+	for _, t := range triggered {
+		s.fire(ctx, t.gtt, t.leg, quote.LastPrice)
+	}
+}
+
+// fire claims a triggered GTT and places its order. If the order cannot be
+// placed the GTT is marked rejected. The user is notified either way.
+func (s *GTTService) fire(ctx context.Context, gtt *models.GTT, leg *models.GTTLeg, lastPrice float64) {
+	reason := fmt.Sprintf("leg %d triggered at %.2f", leg.Leg, lastPrice)
+	if err := s.transition(ctx, gtt, models.GTTStatusActive, models.GTTStatusTriggered, reason, nil); err != nil {
+		if !errors.Is(err, errGTTNotActive) {
+			log.Printf("Failed to trigger GTT %s: %v", gtt.ID, err)
+			s.track(gtt)
+		}
+		return
+	}
+
+	now := s.trading.calendar.Now()
+	legNumber := leg.Leg
+	gtt.TriggeredLeg = &legNumber
+	gtt.TriggeredAt = &now
+
+	order := &models.Order{
+		UserID:         gtt.UserID,
+		Symbol:         gtt.Symbol,
+		Exchange:       gtt.Exchange,
+		Quantity:       leg.Quantity,
+		Price:          leg.Price,
+		Type:           leg.OrderType,
+		Side:           gtt.Side,
+		Validity:       models.OrderValidityDay,
+		Product:        gtt.Product,
+		InstrumentType: gtt.InstrumentType,
+		Variety:        models.OrderVarietyRegular,
+		Tag:            "gtt",
+		PlacedBy:       "gtt",
+	}
+
+	placed, placeErr := s.trading.PlaceOrder(ctx, order)
+	if placed != nil {
+		gtt.OrderID = &placed.ID
+	}
+
+	messageType := "gtt_triggered"
+	if placeErr != nil {
+		messageType = "gtt_rejected"
+		gtt.Remarks = placeErr.Error()
+		if err := s.transition(ctx, gtt, models.GTTStatusTriggered, models.GTTStatusRejected, placeErr.Error(), gtt.OrderID); err != nil {
+			log.Printf("Failed to reject GTT %s: %v", gtt.ID, err)
+		}
+	}
+
+	if err := s.gttRepo.Update(ctx, gtt); err != nil {
+		log.Printf("Failed to update triggered GTT %s: %v", gtt.ID, err)
+	}
+
+	s.notify(gtt, messageType, gtt.Remarks)
+}
+
+// runExpiry sweeps expired GTTs until ctx is done. Sweeps follow the
+// trading calendar's clock.
+func (s *GTTService) runExpiry(ctx context.Context) {
+	for {
+		s.expire(ctx)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-s.trading.calendar.After(gttExpiryInterval):
+		}
+	}
+}
+
+// expire expires every active GTT past its expiry
+func (s *GTTService) expire(ctx context.Context) {
+	gtts, err := s.gttRepo.GetExpired(ctx, s.trading.calendar.Now())
+	if err != nil {
+		log.Printf("Failed to load expired GTTs: %v", err)
+		return
+	}
+
+	for _, gtt := range gtts {
+		if err := s.transition(ctx, gtt, models.GTTStatusActive, models.GTTStatusExpired, "validity ended", nil); err != nil {
+			if !errors.Is(err, errGTTNotActive) {
+				log.Printf("Failed to expire GTT %s: %v", gtt.ID, err)
+			}
+			continue
+		}
+		s.untrack(gtt)
+		s.notify(gtt, "gtt_expired", "")
+	}
+}
+
+// notify sends a GTT update to the user's WebSocket connections
+func (s *GTTService) notify(gtt *models.GTT, messageType string, errMessage string) {
+	if s.hub == nil {
+		return
+	}
+	s.hub.SendToUser(gtt.UserID, ServerMessage{
+		Type:      messageType,
+		Data:      gtt,
+		Error:     errMessage,
+		Timestamp: s.trading.calendar.Now().Unix(),
+	})
+}
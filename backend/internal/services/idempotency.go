@@ -0,0 +1,230 @@
+// stock-trading-app/backend/internal/services/idempotency.go
+
+package services
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/shyamanurag/stock-trading-app/backend/internal/models"
+	"github.com/shyamanurag/stock-trading-app/backend/internal/repository"
+)
+
+var (
+	// ErrIdempotencyConflict is returned when a key is reused for a request
+	// that differs from the one it was first used for
+	ErrIdempotencyConflict = errors.New("idempotency key was already used for a different request")
+
+	// ErrRequestInProgress is returned when a key is reused while the first
+	// request with it is still being processed
+	ErrRequestInProgress = errors.New("a request with this idempotency key is still being processed")
+)
+
+const (
+	// idempotencyRetention is how long a key keeps returning the original
+	// result after it was first used
+	idempotencyRetention = 24 * time.Hour
+
+	// idempotencyInFlightTimeout is how long a key can stay claimed without a
+	// result before it is treated as abandoned, e.g. after a crash
+	idempotencyInFlightTimeout = time.Minute
+
+	// idempotencyPurgeInterval is how often expired keys are deleted
+	idempotencyPurgeInterval = time.Hour
+)
+
+// IdempotencyGuard makes requests carrying a client-supplied key safe to
+// retry. The first request with a key claims it; a retry of the same request
+// within the retention window is pointed at what the first one created
+// instead of being processed again.
+type IdempotencyGuard struct {
+	repo      *repository.IdempotencyRepository
+	calendar  *MarketCalendar
+	retention time.Duration
+}
+
+// NewIdempotencyGuard creates a new IdempotencyGuard
+func NewIdempotencyGuard(repo *repository.IdempotencyRepository, calendar *MarketCalendar) *IdempotencyGuard {
+	return &IdempotencyGuard{
+		repo:      repo,
+		calendar:  calendar,
+		retention: idempotencyRetention,
+	}
+}
+
+// Start deletes expired keys until ctx is done
+func (g *IdempotencyGuard) Start(ctx context.Context) {
+	go g.run(ctx)
+}
+
+// run purges expired keys once per purge interval. Time is read from the
+// calendar so keys expire on the replay clock in replay.
+func (g *IdempotencyGuard) run(ctx context.Context) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-g.calendar.After(idempotencyPurgeInterval):
+			if _, err := g.repo.DeleteExpired(ctx, g.calendar.Now()); err != nil {
+				log.Printf("Failed to purge expired idempotency keys: %v", err)
+			}
+		}
+	}
+}
+
+// Begin claims key for a user's request. If the same request already
+// completed with the key, it returns that record with replay set and the
+// caller should return the record's resource instead of acting again.
+func (g *IdempotencyGuard) Begin(ctx context.Context, userID string, scope models.IdempotencyScope, key string, request interface{}) (*models.IdempotencyRecord, bool, error) {
+	hash, err := requestHash(request)
+	if err != nil {
+		return nil, false, err
+	}
+
+	now := g.calendar.Now()
+	record := &models.IdempotencyRecord{
+		UserID:      userID,
+		Scope:       scope,
+		Key:         key,
+		RequestHash: hash,
+		ExpiresAt:   now.Add(g.retention),
+		CreatedAt:   now,
+	}
+
+	claimed, err := g.repo.Claim(ctx, record)
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to claim idempotency key: %w", err)
+	}
+	if claimed {
+		return record, false, nil
+	}
+
+	existing, err := g.repo.Get(ctx, userID, scope, key)
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to get idempotency key: %w", err)
+	}
+
+	switch {
+	case existing == nil:
+		// Deleted since the claim failed; fall through to claim it again
+	case existing.ExpiresAt.Before(now),
+		existing.ResourceID == "" && existing.CreatedAt.Before(now.Add(-idempotencyInFlightTimeout)):
+		// An expired or abandoned key is free to use again
+		if err := g.repo.Delete(ctx, existing.ID); err != nil {
+			return nil, false, fmt.Errorf("failed to release idempotency key: %w", err)
+		}
+	case existing.RequestHash != hash:
+		return nil, false, ErrIdempotencyConflict
+	case existing.ResourceID == "":
+		return nil, false, ErrRequestInProgress
+	default:
+		return existing, true, nil
+	}
+
+	claimed, err = g.repo.Claim(ctx, record)
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to claim idempotency key: %w", err)
+	}
+	if !claimed {
+		return nil, false, ErrRequestInProgress
+	}
+	return record, false, nil
+}
+
+// Complete records the resource a claimed request created, so retries
+// return it
+func (g *IdempotencyGuard) Complete(ctx context.Context, record *models.IdempotencyRecord, resourceID string) {
+	if err := g.repo.Complete(ctx, record.ID, resourceID); err != nil {
+		log.Printf("Failed to complete idempotency key %s: %v", record.Key, err)
+		return
+	}
+	record.ResourceID = resourceID
+}
+
+// Abandon releases a claimed key whose request created nothing, so the
+// client can try again with the same key
+func (g *IdempotencyGuard) Abandon(ctx context.Context, record *models.IdempotencyRecord) {
+	if err := g.repo.Delete(ctx, record.ID); err != nil {
+		log.Printf("Failed to release idempotency key %s: %v", record.Key, err)
+	}
+}
+
+// requestHash fingerprints a request so a reused key can be checked against
+// the request it was first used for
+func requestHash(request interface{}) (string, error) {
+	data, err := json.Marshal(request)
+	if err != nil {
+		return "", fmt.Errorf("failed to encode request: %w", err)
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// SubmitOrder places an order for a user from a place order request. A
+// request carrying a client order ID already used within the retention
+// window returns the original order marked as a duplicate instead of
+// placing another. Rejected orders are returned with Success false.
+func (s *TradingService) SubmitOrder(ctx context.Context, userID string, req *models.PlaceOrderRequest) (*models.OrderResponse, error) {
+	order := orderFromRequest(userID, req)
+	if req.ClientOrderID == "" {
+		return orderResponse(s.PlaceOrder(ctx, order))
+	}
+	order.ClientOrderID = req.ClientOrderID
+
+	record, replay, err := s.idempotency.Begin(ctx, userID, models.IdempotencyScopeOrder, req.ClientOrderID, req)
+	if err != nil {
+		return nil, err
+	}
+	if replay {
+		original, err := s.orderRepo.GetByID(ctx, record.ResourceID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get order: %w", err)
+		}
+		if original == nil {
+			return nil, fmt.Errorf("order not found")
+		}
+		response, _ := orderResponse(original, nil)
+		response.Duplicate = true
+		return response, nil
+	}
+
+	placed, placeErr := s.PlaceOrder(ctx, order)
+	if placed == nil {
+		// The order may have been stored before a later step failed
+		stored, err := s.orderRepo.GetByID(ctx, order.ID)
+		if err != nil || stored == nil {
+			s.idempotency.Abandon(ctx, record)
+			return nil, placeErr
+		}
+		placed = stored
+	}
+	s.idempotency.Complete(ctx, record, placed.ID)
+
+	return orderResponse(placed, placeErr)
+}
+
+// orderResponse builds the response for a placed or rejected order. Errors
+// other than a risk rejection are passed through.
+func orderResponse(order *models.Order, err error) (*models.OrderResponse, error) {
+	var rejection *models.RiskRejection
+	if err != nil && (order == nil || !errors.As(err, &rejection)) {
+		return nil, err
+	}
+
+	response := &models.OrderResponse{
+		Order:   *order,
+		Message: "Order placed",
+		Success: true,
+	}
+	if order.Status == models.OrderStatusRejected {
+		response.Message = order.Error
+		response.Success = false
+	}
+	return response, nil
+}
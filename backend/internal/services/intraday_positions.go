@@ -0,0 +1,168 @@
+// stock-trading-app/backend/internal/services/intraday_positions.go
+
+package services
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shyamanurag/stock-trading-app/backend/internal/models"
+	"github.com/shyamanurag/stock-trading-app/backend/internal/repository"
+	"gorm.io/gorm"
+)
+
+// MIS (intraday) trades settle into a per-day Position rather than the
+// user's delivery holdings. Exit orders reserve quantity on the position the
+// same way delivery sells reserve holdings, and sells beyond the position
+// go short (see short_selling.go).
+
+// isIntraday reports whether an order trades an intraday product
+func isIntraday(order *models.Order) bool {
+	return order.Product == models.ProductMIS
+}
+
+// tradingDate returns the trading day new fills belong to
+func (s *TradingService) tradingDate() time.Time {
+	return s.calendar.midnight(s.calendar.Now())
+}
+
+// getOrCreatePosition returns the user's position for an order's instrument
+// on the current trading day, creating an empty one if needed
+func (s *TradingService) getOrCreatePosition(ctx context.Context, tx *gorm.DB, order *models.Order) (*models.Position, error) {
+	positionRepo := repository.NewPositionRepository(tx)
+	tradingDate := s.tradingDate()
+
+	position, err := positionRepo.Get(ctx, order.UserID, order.Exchange, order.Symbol, order.Product, tradingDate)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get position: %w", err)
+	}
+	if position != nil {
+		return position, nil
+	}
+
+	position = &models.Position{
+		ID:             uuid.New().String(),
+		UserID:         order.UserID,
+		Symbol:         order.Symbol,
+		Exchange:       order.Exchange,
+		Product:        order.Product,
+		InstrumentType: order.InstrumentType,
+		TradingDate:    tradingDate,
+	}
+	if err := positionRepo.Create(ctx, position); err != nil {
+		return nil, fmt.Errorf("failed to create position: %w", err)
+	}
+	return position, nil
+}
+
+// reservePositionQuantity holds quantity of an intraday position for an exit
+// order
+func (s *TradingService) reservePositionQuantity(ctx context.Context, tx *gorm.DB, order *models.Order, quantity int) error {
+	position, err := s.getOrCreatePosition(ctx, tx, order)
+	if err != nil {
+		return err
+	}
+
+	available := position.Quantity - position.ReservedQuantity
+	if available < quantity {
+		return fmt.Errorf("insufficient intraday position: required %d, available %d", quantity, available)
+	}
+
+	position.ReservedQuantity += quantity
+	if err := repository.NewPositionRepository(tx).Update(ctx, position); err != nil {
+		return fmt.Errorf("failed to update position: %w", err)
+	}
+	return nil
+}
+
+// releasePositionQuantity returns quantity held for an exit order to the
+// intraday position
+func (s *TradingService) releasePositionQuantity(ctx context.Context, tx *gorm.DB, order *models.Order, quantity int) error {
+	position, err := s.getOrCreatePosition(ctx, tx, order)
+	if err != nil {
+		return err
+	}
+
+	position.ReservedQuantity -= quantity
+	if position.ReservedQuantity < 0 {
+		position.ReservedQuantity = 0
+	}
+	if err := repository.NewPositionRepository(tx).Update(ctx, position); err != nil {
+		return fmt.Errorf("failed to update position: %w", err)
+	}
+	return nil
+}
+
+// updatePosition applies an intraday fill to the user's position, booking
+// realised P&L on the quantity it closes
+func (s *TradingService) updatePosition(ctx context.Context, tx *gorm.DB, order *models.Order, quantity int, executionPrice float64) error {
+	position, err := s.getOrCreatePosition(ctx, tx, order)
+	if err != nil {
+		return err
+	}
+
+	value := float64(quantity) * executionPrice
+	if order.Side == models.OrderSideBuy {
+		position.BuyQuantity += quantity
+		position.BuyValue += value
+
+		if position.Quantity >= 0 {
+			// Adding to a long position
+			position.AvgPrice = (float64(position.Quantity)*position.AvgPrice + value) / float64(position.Quantity+quantity)
+		} else {
+			// Covering a short position releases its margin
+			covered := minInt(quantity, -position.Quantity)
+			if err := s.releaseShortMargin(ctx, tx, position, covered); err != nil {
+				return err
+			}
+			position.RealisedPL += (position.AvgPrice - executionPrice) * float64(covered)
+			if quantity > covered {
+				position.AvgPrice = executionPrice
+			}
+		}
+		position.Quantity += quantity
+	} else {
+		position.SellQuantity += quantity
+		position.SellValue += value
+
+		if position.Quantity <= 0 {
+			// Adding to a short position
+			position.AvgPrice = (float64(-position.Quantity)*position.AvgPrice + value) / float64(-position.Quantity+quantity)
+		} else {
+			// Closing a long position
+			closed := minInt(quantity, position.Quantity)
+			position.RealisedPL += (executionPrice - position.AvgPrice) * float64(closed)
+			if quantity > closed {
+				position.AvgPrice = executionPrice
+			}
+		}
+		position.Quantity -= quantity
+
+		// The quantity sold out of the position was reserved when the order
+		// was placed; the rest was sold short
+		shortQty, err := s.shortFillQuantity(ctx, tx, order, quantity)
+		if err != nil {
+			return err
+		}
+		position.ReservedQuantity -= minInt(quantity-shortQty, position.ReservedQuantity)
+	}
+
+	if position.Quantity == 0 {
+		position.AvgPrice = 0
+	}
+
+	if err := repository.NewPositionRepository(tx).Update(ctx, position); err != nil {
+		return fmt.Errorf("failed to update position: %w", err)
+	}
+	return nil
+}
+
+// minInt returns the smaller of a and b
+func minInt(a int, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
@@ -0,0 +1,172 @@
+// stock-trading-app/backend/internal/services/intraday_square_off.go
+
+package services
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/shyamanurag/stock-trading-app/backend/internal/models"
+	"github.com/shyamanurag/stock-trading-app/backend/internal/repository"
+)
+
+// IntradaySquareOff flattens every open MIS position a configurable time
+// before each exchange's close, selling longs and buying back shorts. Run
+// times come from the market calendar so holidays and early closes are
+// respected.
+type IntradaySquareOff struct {
+	calendar     *MarketCalendar
+	trading      *TradingService
+	positionRepo *repository.PositionRepository
+	orderRepo    *repository.OrderRepository
+	hub          *WebSocketHub
+	lead         time.Duration
+	exchanges    []string
+	lastRun      map[string]time.Time
+}
+
+// NewIntradaySquareOff creates a new IntradaySquareOff that runs lead before
+// each session close
+func NewIntradaySquareOff(
+	calendar *MarketCalendar,
+	trading *TradingService,
+	positionRepo *repository.PositionRepository,
+	orderRepo *repository.OrderRepository,
+	hub *WebSocketHub,
+	lead time.Duration,
+) *IntradaySquareOff {
+	return &IntradaySquareOff{
+		calendar:     calendar,
+		trading:      trading,
+		positionRepo: positionRepo,
+		orderRepo:    orderRepo,
+		hub:          hub,
+		lead:         lead,
+		exchanges:    []string{"NSE", "BSE"},
+		lastRun:      make(map[string]time.Time),
+	}
+}
+
+// Start runs the square-off schedule until ctx is done
+func (q *IntradaySquareOff) Start(ctx context.Context) {
+	go q.run(ctx)
+}
+
+// nextRun returns the next exchange to square off and when. If the process
+// starts inside the square-off window the run is due immediately.
+func (q *IntradaySquareOff) nextRun(now time.Time) (string, time.Time, time.Time) {
+	var nextExchange string
+	var nextAt, nextClose time.Time
+
+	for _, exchange := range q.exchanges {
+		closeAt := q.calendar.NextSessionClose(exchange, now)
+		if q.lastRun[exchange].Equal(closeAt) {
+			closeAt = q.calendar.NextSessionClose(exchange, closeAt)
+		}
+
+		runAt := closeAt.Add(-q.lead)
+		if runAt.Before(now) {
+			runAt = now
+		}
+
+		if nextExchange == "" || runAt.Before(nextAt) {
+			nextExchange, nextAt, nextClose = exchange, runAt, closeAt
+		}
+	}
+
+	return nextExchange, nextAt, nextClose
+}
+
+// run sleeps until each square-off time and flattens that exchange
+func (q *IntradaySquareOff) run(ctx context.Context) {
+	for {
+		now := q.calendar.Now()
+		exchange, runAt, closeAt := q.nextRun(now)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-q.calendar.After(runAt.Sub(now)):
+		}
+
+		if err := q.SquareOff(ctx, exchange); err != nil {
+			log.Printf("Intraday square-off failed for %s: %v", exchange, err)
+		}
+		q.lastRun[exchange] = closeAt
+	}
+}
+
+// SquareOff cancels open MIS orders on an exchange and places market orders
+// to flatten every open MIS position
+func (q *IntradaySquareOff) SquareOff(ctx context.Context, exchange string) error {
+	// Pending exits hold position quantity, so they are cancelled first
+	orders, err := q.orderRepo.GetOpenByProduct(ctx, exchange, models.ProductMIS)
+	if err != nil {
+		return fmt.Errorf("failed to get open intraday orders: %w", err)
+	}
+
+	for _, order := range orders {
+		if err := q.trading.CancelOrderAsSystem(ctx, order.ID); err != nil {
+			// Bracket legs may already have been cancelled with their entry
+			log.Printf("Failed to cancel intraday order %s before square-off: %v", order.ID, err)
+		}
+	}
+
+	positions, err := q.positionRepo.GetOpen(ctx, exchange, models.ProductMIS, q.trading.tradingDate())
+	if err != nil {
+		return fmt.Errorf("failed to get open intraday positions: %w", err)
+	}
+
+	for _, position := range positions {
+		q.squareOffPosition(ctx, position)
+	}
+
+	return nil
+}
+
+// squareOffPosition places a system market order that flattens a position
+// and tells the user about it
+func (q *IntradaySquareOff) squareOffPosition(ctx context.Context, position *models.Position) {
+	side := models.OrderSideSell
+	quantity := position.Quantity
+	if quantity < 0 {
+		side = models.OrderSideBuy
+		quantity = -quantity
+	}
+
+	order := &models.Order{
+		UserID:         position.UserID,
+		Symbol:         position.Symbol,
+		Exchange:       position.Exchange,
+		Quantity:       quantity,
+		Type:           models.OrderTypeMarket,
+		Side:           side,
+		Validity:       models.OrderValidityDay,
+		Product:        position.Product,
+		InstrumentType: position.InstrumentType,
+		Variety:        models.OrderVarietyRegular,
+		Tag:            "auto-square-off",
+		PlacedBy:       "system",
+	}
+
+	message := ServerMessage{
+		Type:      "intraday_square_off",
+		Timestamp: q.calendar.Now().Unix(),
+	}
+
+	placed, err := q.trading.PlaceOrder(ctx, order)
+	if err != nil {
+		log.Printf("Failed to square off %s position in %s for user %s: %v",
+			position.Product, position.Symbol, position.UserID, err)
+		message.Data = position
+		message.Error = fmt.Sprintf("Failed to square off %s position in %s: %v", position.Product, position.Symbol, err)
+	} else {
+		message.Data = placed
+	}
+
+	if q.hub != nil {
+		q.hub.SendToUser(position.UserID, message)
+	}
+}
@@ -0,0 +1,330 @@
+// stock-trading-app/backend/internal/services/kill_switch.go
+
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shyamanurag/stock-trading-app/backend/internal/models"
+	"github.com/shyamanurag/stock-trading-app/backend/internal/repository"
+)
+
+var (
+	// ErrHaltNotFound is returned when a halt does not exist or belongs to
+	// another user
+	ErrHaltNotFound = errors.New("halt not found")
+
+	// ErrHaltNotPermitted is returned when a user places or lifts a halt
+	// they are not allowed to
+	ErrHaltNotPermitted = errors.New("not permitted to change this halt")
+)
+
+// KillSwitch holds the trading halts in force. Admins can halt a user, an
+// instrument, a segment or the whole platform; users can halt their own
+// trading, optionally until a set time as self-exclusion; risk rules halt
+// users automatically. Halts block new orders and modifications but never
+// cancellations or system orders such as square-offs.
+type KillSwitch struct {
+	calendar     *MarketCalendar
+	haltRepo     *repository.HaltRepository
+	userRepo     *repository.UserRepository
+	halts        map[string]*models.TradingHalt
+	cancelOrders func(ctx context.Context, halt *models.TradingHalt) (int, error)
+	mutex        sync.RWMutex
+}
+
+// NewKillSwitch creates a new KillSwitch
+func NewKillSwitch(calendar *MarketCalendar, haltRepo *repository.HaltRepository, userRepo *repository.UserRepository) *KillSwitch {
+	return &KillSwitch{
+		calendar: calendar,
+		haltRepo: haltRepo,
+		userRepo: userRepo,
+		halts:    make(map[string]*models.TradingHalt),
+	}
+}
+
+// Load reads the halts in force from the database
+func (k *KillSwitch) Load(ctx context.Context) error {
+	halts, err := k.haltRepo.GetActive(ctx, k.calendar.Now())
+	if err != nil {
+		return fmt.Errorf("failed to load trading halts: %w", err)
+	}
+
+	k.mutex.Lock()
+	defer k.mutex.Unlock()
+	k.halts = make(map[string]*models.TradingHalt, len(halts))
+	for _, halt := range halts {
+		k.halts[halt.ID] = halt
+	}
+	return nil
+}
+
+// setOrderCanceller sets how a halt cancels the open orders it covers
+func (k *KillSwitch) setOrderCanceller(cancel func(ctx context.Context, halt *models.TradingHalt) (int, error)) {
+	k.mutex.Lock()
+	defer k.mutex.Unlock()
+	k.cancelOrders = cancel
+}
+
+// Check returns the rejection for an order covered by a halt in force, or
+// nil if the order may trade
+func (k *KillSwitch) Check(order *models.Order) *models.RiskRejection {
+	k.mutex.RLock()
+	defer k.mutex.RUnlock()
+
+	now := k.calendar.Now()
+	for _, halt := range k.halts {
+		if !halt.ActiveAt(now) || !halt.Covers(order) {
+			continue
+		}
+
+		reason := fmt.Sprintf("trading in %s is halted: %s", haltSubject(halt), halt.Reason)
+		if halt.Until != nil {
+			reason += fmt.Sprintf(" (until %s)", halt.Until.Format(time.RFC3339))
+		}
+		return &models.RiskRejection{
+			Rule:   "TRADING_HALT",
+			Reason: reason,
+		}
+	}
+	return nil
+}
+
+// haltSubject describes what a halt covers
+func haltSubject(halt *models.TradingHalt) string {
+	var subject string
+	switch halt.Scope {
+	case models.HaltScopeUser:
+		return "your account"
+	case models.HaltScopeSymbol:
+		subject = halt.Symbol
+	case models.HaltScopeSegment:
+		subject = "the " + halt.Segment + " segment"
+	default:
+		return "all instruments"
+	}
+	if halt.Exchange != "" {
+		subject += " on " + halt.Exchange
+	}
+	return subject
+}
+
+// Halt places a halt requested by actorID. Admins can halt any scope; other
+// users can only halt themselves.
+func (k *KillSwitch) Halt(ctx context.Context, actorID string, req *models.HaltRequest) (*models.TradingHalt, error) {
+	admin, err := k.isAdmin(ctx, actorID)
+	if err != nil {
+		return nil, err
+	}
+
+	source := models.HaltSourceAdmin
+	if !admin {
+		if req.Scope != models.HaltScopeUser {
+			return nil, ErrHaltNotPermitted
+		}
+		req.UserID = actorID
+		source = models.HaltSourceUser
+	}
+
+	halt, err := newTradingHalt(req, source, actorID, k.calendar.Now())
+	if err != nil {
+		return nil, err
+	}
+	return k.place(ctx, halt, req.CancelOpenOrders)
+}
+
+// HaltAsSystem places a halt on behalf of an automated risk rule
+func (k *KillSwitch) HaltAsSystem(ctx context.Context, req *models.HaltRequest) (*models.TradingHalt, error) {
+	halt, err := newTradingHalt(req, models.HaltSourceRisk, "system", k.calendar.Now())
+	if err != nil {
+		return nil, err
+	}
+	return k.place(ctx, halt, req.CancelOpenOrders)
+}
+
+// newTradingHalt validates a halt request made at now and builds the halt
+func newTradingHalt(req *models.HaltRequest, source string, haltedBy string, now time.Time) (*models.TradingHalt, error) {
+	halt := &models.TradingHalt{
+		Scope:    req.Scope,
+		Reason:   req.Reason,
+		Source:   source,
+		HaltedBy: haltedBy,
+		Until:    req.Until,
+	}
+
+	switch req.Scope {
+	case models.HaltScopeUser:
+		if req.UserID == "" {
+			return nil, fmt.Errorf("user is required for a user halt")
+		}
+		userID := req.UserID
+		halt.UserID = &userID
+	case models.HaltScopeSymbol:
+		if req.Symbol == "" {
+			return nil, fmt.Errorf("symbol is required for a symbol halt")
+		}
+		halt.Symbol = req.Symbol
+		halt.Exchange = req.Exchange
+	case models.HaltScopeSegment:
+		if req.Segment != models.SegmentEquity && req.Segment != models.SegmentDerivatives {
+			return nil, fmt.Errorf("segment must be %s or %s", models.SegmentEquity, models.SegmentDerivatives)
+		}
+		halt.Segment = req.Segment
+		halt.Exchange = req.Exchange
+	case models.HaltScopePlatform:
+	default:
+		return nil, fmt.Errorf("invalid halt scope: %s", req.Scope)
+	}
+
+	if halt.Until != nil && !halt.Until.After(now) {
+		return nil, fmt.Errorf("halt end must be in the future")
+	}
+
+	return halt, nil
+}
+
+// place stores a halt, puts it in force and cancels the open orders it
+// covers if asked to. The halt stays in force even if cancelling fails.
+func (k *KillSwitch) place(ctx context.Context, halt *models.TradingHalt, cancelOpenOrders bool) (*models.TradingHalt, error) {
+	if err := k.haltRepo.Create(ctx, halt); err != nil {
+		return nil, fmt.Errorf("failed to create halt: %w", err)
+	}
+
+	k.mutex.Lock()
+	k.halts[halt.ID] = halt
+	cancelOrders := k.cancelOrders
+	k.mutex.Unlock()
+
+	log.Printf("Trading halted (%s %s) by %s: %s", halt.Scope, haltSubject(halt), halt.HaltedBy, halt.Reason)
+
+	if !cancelOpenOrders || cancelOrders == nil {
+		return halt, nil
+	}
+
+	cancelled, cancelErr := cancelOrders(ctx, halt)
+	halt.CancelledOrders = cancelled
+	if err := k.haltRepo.Update(ctx, halt); err != nil {
+		log.Printf("Failed to record orders cancelled by halt %s: %v", halt.ID, err)
+	}
+	if cancelErr != nil {
+		return halt, fmt.Errorf("halt placed but failed to cancel open orders: %w", cancelErr)
+	}
+	return halt, nil
+}
+
+// Lift lifts a halt for actorID. Admins can lift any halt; users can only
+// lift a halt they placed on themselves without an end time, so
+// self-exclusion runs its course.
+func (k *KillSwitch) Lift(ctx context.Context, actorID string, haltID string) (*models.TradingHalt, error) {
+	halt, err := k.haltRepo.GetByID(ctx, haltID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get halt: %w", err)
+	}
+	if halt == nil {
+		return nil, ErrHaltNotFound
+	}
+
+	admin, err := k.isAdmin(ctx, actorID)
+	if err != nil {
+		return nil, err
+	}
+	if !admin {
+		if halt.UserID == nil || *halt.UserID != actorID {
+			return nil, ErrHaltNotFound
+		}
+		if halt.Source != models.HaltSourceUser || halt.Until != nil {
+			return nil, ErrHaltNotPermitted
+		}
+	}
+
+	now := k.calendar.Now()
+	if !halt.ActiveAt(now) {
+		return nil, fmt.Errorf("halt is no longer in force")
+	}
+
+	halt.LiftedAt = &now
+	halt.LiftedBy = actorID
+	if err := k.haltRepo.Update(ctx, halt); err != nil {
+		return nil, fmt.Errorf("failed to update halt: %w", err)
+	}
+
+	k.mutex.Lock()
+	delete(k.halts, halt.ID)
+	k.mutex.Unlock()
+
+	log.Printf("Trading halt %s lifted by %s", halt.ID, actorID)
+	return halt, nil
+}
+
+// Active returns the halts in force that actorID can see: every halt for
+// admins, otherwise the user's own halts and those not aimed at a user
+func (k *KillSwitch) Active(ctx context.Context, actorID string) ([]*models.TradingHalt, error) {
+	halts, err := k.haltRepo.GetActive(ctx, k.calendar.Now())
+	if err != nil {
+		return nil, fmt.Errorf("failed to get halts: %w", err)
+	}
+
+	admin, err := k.isAdmin(ctx, actorID)
+	if err != nil {
+		return nil, err
+	}
+	if admin {
+		return halts, nil
+	}
+
+	visible := make([]*models.TradingHalt, 0, len(halts))
+	for _, halt := range halts {
+		if halt.Scope != models.HaltScopeUser || (halt.UserID != nil && *halt.UserID == actorID) {
+			visible = append(visible, halt)
+		}
+	}
+	return visible, nil
+}
+
+// isAdmin reports whether a user is an admin
+func (k *KillSwitch) isAdmin(ctx context.Context, userID string) (bool, error) {
+	id, err := uuid.Parse(userID)
+	if err != nil {
+		return false, nil
+	}
+
+	user, err := k.userRepo.GetByID(ctx, id)
+	if err != nil {
+		return false, fmt.Errorf("failed to get user: %w", err)
+	}
+	return user != nil && (user.Role == models.UserRoleAdmin || user.Role == models.UserRoleSuperAdmin), nil
+}
+
+// cancelHaltedOrders cancels every open order a halt covers and returns how
+// many were cancelled. Slices are cancelled with their parent order.
+func (s *TradingService) cancelHaltedOrders(ctx context.Context, halt *models.TradingHalt) (int, error) {
+	var userID string
+	if halt.UserID != nil {
+		userID = *halt.UserID
+	}
+
+	orders, err := s.orderRepo.GetOpen(ctx, userID, halt.Exchange, halt.Symbol)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get open orders: %w", err)
+	}
+
+	cancelled := 0
+	for _, order := range orders {
+		if isOrderSlice(order) || !halt.Covers(order) {
+			continue
+		}
+		if err := s.CancelOrderAsSystem(ctx, order.ID); err != nil {
+			log.Printf("Failed to cancel order %s for halt %s: %v", order.ID, halt.ID, err)
+			continue
+		}
+		cancelled++
+	}
+
+	return cancelled, nil
+}
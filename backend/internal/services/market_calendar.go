@@ -0,0 +1,318 @@
+// stock-trading-app/backend/internal/services/market_calendar.go
+
+package services
+
+import (
+	"sync"
+	"time"
+
+	"github.com/shyamanurag/stock-trading-app/backend/internal/models"
+)
+
+// istLocation is Indian Standard Time. India has no daylight saving so a
+// fixed zone avoids depending on the host's tz database.
+var istLocation = time.FixedZone("IST", 5*60*60+30*60)
+
+// SessionTiming is an exchange's regular trading session as offsets from
+// local midnight. PreOpen is zero for exchanges without a pre-open session.
+type SessionTiming struct {
+	PreOpen   time.Duration
+	Open      time.Duration
+	Close     time.Duration
+	PostClose time.Duration // End of the post-close session
+}
+
+// MarketCalendar knows when each exchange's trading sessions open and
+// close, including holidays and early closes. It also carries the clock the
+// services that share it read the time from.
+type MarketCalendar struct {
+	clock    Clock
+	location *time.Location
+	sessions map[string]SessionTiming
+	holidays map[string]models.MarketHoliday
+	mutex    sync.RWMutex
+}
+
+// NewMarketCalendar creates a MarketCalendar with the NSE and BSE equity
+// session timings
+func NewMarketCalendar() *MarketCalendar {
+	regular := SessionTiming{
+		PreOpen:   9 * time.Hour,
+		Open:      9*time.Hour + 15*time.Minute,
+		Close:     15*time.Hour + 30*time.Minute,
+		PostClose: 16 * time.Hour,
+	}
+	return &MarketCalendar{
+		clock:    systemClock{},
+		location: istLocation,
+		sessions: map[string]SessionTiming{
+			"NSE": regular,
+			"BSE": regular,
+		},
+		holidays: make(map[string]models.MarketHoliday),
+	}
+}
+
+// SetClock replaces the system clock, as replay does with its virtual clock.
+// It must be called before the services sharing the calendar are started.
+func (c *MarketCalendar) SetClock(clock Clock) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.clock = clock
+}
+
+// Now returns the current time on the calendar's clock
+func (c *MarketCalendar) Now() time.Time {
+	c.mutex.RLock()
+	clock := c.clock
+	c.mutex.RUnlock()
+	return clock.Now()
+}
+
+// After returns a channel that receives the time once d has passed on the
+// calendar's clock
+func (c *MarketCalendar) After(d time.Duration) <-chan time.Time {
+	c.mutex.RLock()
+	clock := c.clock
+	c.mutex.RUnlock()
+	return clock.After(d)
+}
+
+// AddHolidays registers exchange holidays, early closes and muhurat
+// sessions. A holiday with no exchange applies to every exchange.
+func (c *MarketCalendar) AddHolidays(holidays []models.MarketHoliday) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	for _, holiday := range holidays {
+		c.holidays[c.holidayKey(holiday.Exchange, holiday.Date)] = holiday
+	}
+}
+
+// SetHolidays replaces every registered holiday with holidays
+func (c *MarketCalendar) SetHolidays(holidays []models.MarketHoliday) {
+	c.mutex.Lock()
+	c.holidays = make(map[string]models.MarketHoliday, len(holidays))
+	c.mutex.Unlock()
+
+	c.AddHolidays(holidays)
+}
+
+// holidayKey identifies an exchange's calendar date
+func (c *MarketCalendar) holidayKey(exchange string, t time.Time) string {
+	return exchange + ":" + t.In(c.location).Format("2006-01-02")
+}
+
+// holiday returns the holiday entry for an exchange on t's date, if any
+func (c *MarketCalendar) holiday(exchange string, t time.Time) (models.MarketHoliday, bool) {
+	c.mutex.RLock()
+	defer c.mutex.RUnlock()
+
+	if holiday, ok := c.holidays[c.holidayKey(exchange, t)]; ok {
+		return holiday, true
+	}
+	holiday, ok := c.holidays[c.holidayKey("", t)]
+	return holiday, ok
+}
+
+// session returns the session timing for an exchange, defaulting to NSE
+func (c *MarketCalendar) session(exchange string) SessionTiming {
+	if timing, ok := c.sessions[exchange]; ok {
+		return timing
+	}
+	return c.sessions["NSE"]
+}
+
+// midnight returns the start of t's day in exchange local time
+func (c *MarketCalendar) midnight(t time.Time) time.Time {
+	local := t.In(c.location)
+	return time.Date(local.Year(), local.Month(), local.Day(), 0, 0, 0, 0, c.location)
+}
+
+// localTime returns the HH:MM local time hhmm on day's date
+func localTime(day time.Time, hhmm *string) (time.Time, bool) {
+	if hhmm == nil {
+		return time.Time{}, false
+	}
+	parsed, err := time.Parse("15:04", *hhmm)
+	if err != nil {
+		return time.Time{}, false
+	}
+	return day.Add(time.Duration(parsed.Hour())*time.Hour + time.Duration(parsed.Minute())*time.Minute), true
+}
+
+// sessionHours are an exchange's session times on one date. preOpen equals
+// open for exchanges without a pre-open session.
+type sessionHours struct {
+	preOpen   time.Time
+	open      time.Time
+	close     time.Time
+	postClose time.Time
+}
+
+// hours returns the exchange's session on t's date and whether it trades
+// that day. Days without trading return the regular timings.
+//
+// Early closes move the close and the end of the post-close session. A
+// muhurat session replaces the day's trading, weekend or not, with a session
+// from its open to its close with the regular pre-open and post-close
+// lengths.
+func (c *MarketCalendar) hours(exchange string, t time.Time) (sessionHours, bool) {
+	timing := c.session(exchange)
+	day := c.midnight(t)
+	var preOpenLength time.Duration
+	if timing.PreOpen > 0 {
+		preOpenLength = timing.Open - timing.PreOpen
+	}
+	postCloseLength := timing.PostClose - timing.Close
+
+	regular := sessionHours{
+		preOpen:   day.Add(timing.Open - preOpenLength),
+		open:      day.Add(timing.Open),
+		close:     day.Add(timing.Close),
+		postClose: day.Add(timing.PostClose),
+	}
+
+	holiday, isHoliday := c.holiday(exchange, t)
+	if isHoliday && holiday.Status == models.MarketHolidayMuhurat {
+		openAt, okOpen := localTime(day, holiday.OpenTime)
+		closeAt, okClose := localTime(day, holiday.CloseTime)
+		if !okOpen || !okClose || !closeAt.After(openAt) {
+			return regular, false
+		}
+		return sessionHours{
+			preOpen:   openAt.Add(-preOpenLength),
+			open:      openAt,
+			close:     closeAt,
+			postClose: closeAt.Add(postCloseLength),
+		}, true
+	}
+
+	switch day.Weekday() {
+	case time.Saturday, time.Sunday:
+		return regular, false
+	}
+	if !isHoliday {
+		return regular, true
+	}
+
+	switch holiday.Status {
+	case models.MarketHolidayClosed:
+		return regular, false
+	case models.MarketHolidayEarlyClose:
+		if closeAt, ok := localTime(day, holiday.CloseTime); ok {
+			regular.close = closeAt
+			regular.postClose = closeAt.Add(postCloseLength)
+		}
+	}
+	return regular, true
+}
+
+// IsTradingDay reports whether the exchange trades on t's date
+func (c *MarketCalendar) IsTradingDay(exchange string, t time.Time) bool {
+	_, ok := c.hours(exchange, t)
+	return ok
+}
+
+// SessionStart returns when the session on t's date starts taking orders:
+// the pre-open if the exchange has one, otherwise the open
+func (c *MarketCalendar) SessionStart(exchange string, t time.Time) time.Time {
+	hours, _ := c.hours(exchange, t)
+	return hours.preOpen
+}
+
+// SessionOpen returns the open time of the session on t's date
+func (c *MarketCalendar) SessionOpen(exchange string, t time.Time) time.Time {
+	hours, _ := c.hours(exchange, t)
+	return hours.open
+}
+
+// SessionClose returns the close time of the session on t's date, honouring
+// early closes
+func (c *MarketCalendar) SessionClose(exchange string, t time.Time) time.Time {
+	hours, _ := c.hours(exchange, t)
+	return hours.close
+}
+
+// IsOpen reports whether the exchange is in its trading session at t
+func (c *MarketCalendar) IsOpen(exchange string, t time.Time) bool {
+	return c.StatusAt(exchange, t) == models.MarketStatusOpen
+}
+
+// StatusAt returns the exchange's market status at t. Days marked as
+// holidays are HOLIDAY outside any muhurat session; other days without
+// trading are CLOSED.
+func (c *MarketCalendar) StatusAt(exchange string, t time.Time) models.MarketStatus {
+	closed := models.MarketStatusClosed
+	if holiday, ok := c.holiday(exchange, t); ok && holiday.Status != models.MarketHolidayEarlyClose {
+		closed = models.MarketStatusHoliday
+	}
+
+	hours, ok := c.hours(exchange, t)
+	if !ok {
+		return closed
+	}
+
+	switch {
+	case t.Before(hours.preOpen):
+		return closed
+	case t.Before(hours.open):
+		return models.MarketStatusPreOpen
+	case t.Before(hours.close):
+		return models.MarketStatusOpen
+	case t.Before(hours.postClose):
+		return models.MarketStatusPostClose
+	}
+	return closed
+}
+
+// NextTransition returns when the exchange's market status next changes
+// after t and the status it changes to
+func (c *MarketCalendar) NextTransition(exchange string, t time.Time) (time.Time, models.MarketStatus) {
+	current := c.StatusAt(exchange, t)
+
+	day := c.midnight(t)
+	for i := 0; i < 366; i++ {
+		// Statuses change at session boundaries and, between holidays and
+		// ordinary closed days, at midnight
+		boundaries := []time.Time{day}
+		if hours, ok := c.hours(exchange, day); ok {
+			boundaries = append(boundaries, hours.preOpen, hours.open, hours.close, hours.postClose)
+		}
+
+		for _, at := range boundaries {
+			if !at.After(t) {
+				continue
+			}
+			if status := c.StatusAt(exchange, at); status != current {
+				return at, status
+			}
+		}
+		day = day.AddDate(0, 0, 1)
+	}
+	return day, c.StatusAt(exchange, day)
+}
+
+// NextSessionStart returns the first session start strictly after t
+func (c *MarketCalendar) NextSessionStart(exchange string, t time.Time) time.Time {
+	day := c.midnight(t)
+	for i := 0; i < 366; i++ {
+		if hours, ok := c.hours(exchange, day); ok && hours.preOpen.After(t) {
+			return hours.preOpen
+		}
+		day = day.AddDate(0, 0, 1)
+	}
+	return c.SessionStart(exchange, day)
+}
+
+// NextSessionClose returns the first session close strictly after t
+func (c *MarketCalendar) NextSessionClose(exchange string, t time.Time) time.Time {
+	day := c.midnight(t)
+	for i := 0; i < 366; i++ {
+		if hours, ok := c.hours(exchange, day); ok && hours.close.After(t) {
+			return hours.close
+		}
+		day = day.AddDate(0, 0, 1)
+	}
+	return c.SessionClose(exchange, day)
+}
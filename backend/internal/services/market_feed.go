@@ -0,0 +1,88 @@
+// stock-trading-app/backend/internal/services/market_feed.go
+
+package services
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/shyamanurag/stock-trading-app/backend/internal/models"
+)
+
+// MarketDataFeed is a source of market data. The market data service caches
+// what a feed streams and falls back to it for anything not cached.
+type MarketDataFeed interface {
+	Connect() error
+	Disconnect() error
+	IsConnected() bool
+	Subscribe(symbol string, exchange string) error
+	Unsubscribe(symbol string, exchange string) error
+
+	// OnUpdate sets the handlers the feed streams quotes and depth to
+	OnUpdate(onQuote func(quote *models.MarketQuote), onDepth func(depth *models.MarketDepth))
+
+	FetchQuote(symbol string, exchange string) (*models.MarketQuote, error)
+	FetchMarketDepth(symbol string, exchange string) (*models.MarketDepth, error)
+	FetchHistoricalData(symbol string, exchange string, interval string, startTime time.Time, endTime time.Time) (*models.HistoricalData, error)
+	FetchSymbols() ([]models.Symbol, error)
+	FetchIndices() ([]models.MarketIndex, error)
+}
+
+// MarketDataConfig selects and configures the market data feed. It mirrors
+// services.marketData and features.mockData in the config files.
+type MarketDataConfig struct {
+	MockData  bool                  // Run the built-in simulator instead of the live feed
+	URL       string                // Live feed base URL
+	APIKey    string                // Live feed API key
+	Timeout   time.Duration         // Live feed request timeout
+	Simulator MarketSimulatorConfig // Simulator settings used with MockData
+	Replay    ReplayConfig          // Recorded session played instead of any other feed when its file is set
+}
+
+// NewMarketDataFeed creates the feed selected by config. A replay switches
+// calendar to the replay's virtual clock, so it must be created before the
+// services sharing the calendar are started.
+func NewMarketDataFeed(config MarketDataConfig, calendar *MarketCalendar) (MarketDataFeed, error) {
+	if config.Replay.File != "" {
+		feed, err := NewReplayFeed(config.Replay)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load replay: %w", err)
+		}
+		calendar.SetClock(feed.Clock())
+		return feed, nil
+	}
+	if config.MockData {
+		return NewMarketSimulator(config.Simulator), nil
+	}
+	return NewWebSocketFeed(config.URL, config.APIKey, config.Timeout), nil
+}
+
+// intervalDuration returns the length of a candle interval, defaulting to a
+// day for unknown intervals
+func intervalDuration(interval string) time.Duration {
+	switch interval {
+	case "1m":
+		return time.Minute
+	case "5m":
+		return 5 * time.Minute
+	case "15m":
+		return 15 * time.Minute
+	case "30m":
+		return 30 * time.Minute
+	case "1h":
+		return time.Hour
+	}
+	return 24 * time.Hour
+}
+
+// quoteTime returns when a quote's last trade happened, falling back to when
+// it was last updated and then to the clock's time
+func quoteTime(quote *models.MarketQuote, clock Clock) time.Time {
+	if !quote.LastTradeTime.IsZero() {
+		return quote.LastTradeTime
+	}
+	if !quote.LastUpdateTime.IsZero() {
+		return quote.LastUpdateTime
+	}
+	return clock.Now()
+}
@@ -0,0 +1,186 @@
+// stock-trading-app/backend/internal/services/market_sessions.go
+
+package services
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/shyamanurag/stock-trading-app/backend/internal/models"
+	"github.com/shyamanurag/stock-trading-app/backend/internal/repository"
+)
+
+// holidayReloadInterval is how often exchange holiday lists are reloaded
+const holidayReloadInterval = 12 * time.Hour
+
+// marketStatusTopic returns the hub topic an exchange's status changes are
+// published on
+func marketStatusTopic(exchange string) string {
+	return "market_status:" + exchange
+}
+
+// MarketSessionMonitor runs each exchange's session state machine. It keeps
+// the calendar's holiday lists loaded and, as the calendar's clock crosses a
+// session boundary, publishes the exchange's new status on the
+// market_status:<exchange> topic and passes it to registered listeners.
+type MarketSessionMonitor struct {
+	calendar    *MarketCalendar
+	holidayRepo *repository.HolidayRepository
+	hub         *WebSocketHub
+	exchanges   []string
+	status      map[string]*models.MarketStatusChange
+	listeners   []func(change *models.MarketStatusChange)
+	mutex       sync.RWMutex
+}
+
+// NewMarketSessionMonitor creates a new MarketSessionMonitor
+func NewMarketSessionMonitor(
+	calendar *MarketCalendar,
+	holidayRepo *repository.HolidayRepository,
+	hub *WebSocketHub,
+) *MarketSessionMonitor {
+	return &MarketSessionMonitor{
+		calendar:    calendar,
+		holidayRepo: holidayRepo,
+		hub:         hub,
+		exchanges:   []string{"NSE", "BSE"},
+		status:      make(map[string]*models.MarketStatusChange),
+	}
+}
+
+// OnStatusChange registers a listener for status changes. Listeners are
+// called in order on the monitor's goroutine and should return quickly.
+func (m *MarketSessionMonitor) OnStatusChange(listener func(change *models.MarketStatusChange)) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	m.listeners = append(m.listeners, listener)
+}
+
+// LoadHolidays replaces the calendar's holidays with the lists from a year
+// ago onwards
+func (m *MarketSessionMonitor) LoadHolidays(ctx context.Context) error {
+	holidays, err := m.holidayRepo.GetFrom(ctx, m.calendar.midnight(m.calendar.Now()).AddDate(-1, 0, 0))
+	if err != nil {
+		return fmt.Errorf("failed to load market holidays: %w", err)
+	}
+
+	m.calendar.SetHolidays(holidays)
+	return nil
+}
+
+// Start loads the holiday lists and runs the state machines until ctx is
+// done
+func (m *MarketSessionMonitor) Start(ctx context.Context) error {
+	if err := m.LoadHolidays(ctx); err != nil {
+		return err
+	}
+
+	m.update(m.calendar.Now())
+	go m.run(ctx)
+	return nil
+}
+
+// run sleeps until the next status change on any exchange or the next
+// holiday reload, whichever is first
+func (m *MarketSessionMonitor) run(ctx context.Context) {
+	reloadAt := m.calendar.Now().Add(holidayReloadInterval)
+
+	for {
+		now := m.calendar.Now()
+		if !now.Before(reloadAt) {
+			if err := m.LoadHolidays(ctx); err != nil {
+				log.Printf("Holiday reload failed: %v", err)
+			}
+			reloadAt = now.Add(holidayReloadInterval)
+		}
+		m.update(now)
+
+		wakeAt := reloadAt
+		m.mutex.RLock()
+		for _, change := range m.status {
+			if change.NextAt.Before(wakeAt) {
+				wakeAt = change.NextAt
+			}
+		}
+		m.mutex.RUnlock()
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-m.calendar.After(wakeAt.Sub(now)):
+		}
+	}
+}
+
+// update recomputes every exchange's status at now and publishes those that
+// changed. The first status computed for an exchange is not published.
+func (m *MarketSessionMonitor) update(now time.Time) {
+	var changed []*models.MarketStatusChange
+
+	m.mutex.Lock()
+	for _, exchange := range m.exchanges {
+		status := m.calendar.StatusAt(exchange, now)
+		nextAt, nextStatus := m.calendar.NextTransition(exchange, now)
+
+		previous, known := m.status[exchange]
+		change := &models.MarketStatusChange{
+			Exchange:   exchange,
+			Status:     status,
+			At:         now,
+			NextStatus: nextStatus,
+			NextAt:     nextAt,
+		}
+		if known {
+			if previous.Status == status {
+				// Holidays may have moved the next transition
+				previous.NextStatus, previous.NextAt = nextStatus, nextAt
+				continue
+			}
+			change.Previous = previous.Status
+			published := *change
+			changed = append(changed, &published)
+		}
+		m.status[exchange] = change
+	}
+	listeners := m.listeners
+	m.mutex.Unlock()
+
+	for _, change := range changed {
+		log.Printf("%s market status changed from %s to %s", change.Exchange, change.Previous, change.Status)
+
+		if m.hub != nil {
+			m.hub.SendToTopic(marketStatusTopic(change.Exchange), ServerMessage{
+				Type:      "market_status",
+				Data:      change,
+				Timestamp: change.At.Unix(),
+			})
+		}
+		for _, listener := range listeners {
+			listener(change)
+		}
+	}
+}
+
+// Status returns an exchange's current status and its next change
+func (m *MarketSessionMonitor) Status(exchange string) *models.MarketStatusChange {
+	m.mutex.RLock()
+	if change, ok := m.status[exchange]; ok {
+		current := *change
+		m.mutex.RUnlock()
+		return &current
+	}
+	m.mutex.RUnlock()
+
+	now := m.calendar.Now()
+	nextAt, nextStatus := m.calendar.NextTransition(exchange, now)
+	return &models.MarketStatusChange{
+		Exchange:   exchange,
+		Status:     m.calendar.StatusAt(exchange, now),
+		At:         now,
+		NextStatus: nextStatus,
+		NextAt:     nextAt,
+	}
+}
@@ -0,0 +1,549 @@
+// stock-trading-app/backend/internal/services/market_simulator.go
+
+package services
+
+import (
+	"fmt"
+	"hash/fnv"
+	"log"
+	"math"
+	"math/rand"
+	"sync"
+	"time"
+
+	"github.com/shyamanurag/stock-trading-app/backend/internal/models"
+)
+
+// tradingSecondsPerYear converts tick intervals into the year fractions
+// volatility and drift are quoted in: 252 sessions of 6h15m
+const tradingSecondsPerYear = 252 * 6.25 * 3600
+
+// MarketSimulatorConfig controls the built-in market simulator
+type MarketSimulatorConfig struct {
+	Seed           int64                 // Seed for every price path; the same seed replays the same market
+	TickInterval   time.Duration         // How often subscribed instruments and the indices move
+	Volatility     float64               // Annualised volatility of instrument prices
+	Drift          float64               // Annualised drift of instrument prices
+	DepthLevels    int                   // Levels on each side of the depth ladder
+	CircuitPercent float64               // Price band either side of the previous close
+	Instruments    []SimulatedInstrument // Instrument master; other symbols are simulated with a seeded price
+	Indices        []SimulatedIndex
+}
+
+// SimulatedInstrument is an instrument the simulator lists and the previous
+// close its prices start from
+type SimulatedInstrument struct {
+	Symbol models.Symbol
+	Close  float64
+}
+
+// SimulatedIndex is an index the simulator publishes and its previous close
+type SimulatedIndex struct {
+	Symbol string
+	Name   string
+	Close  float64
+}
+
+// DefaultMarketSimulatorConfig returns the simulator used for development,
+// CI and demos
+func DefaultMarketSimulatorConfig() MarketSimulatorConfig {
+	equity := func(symbol string, name string, isin string, freezeQty int, maxOrderSize int) models.Symbol {
+		return models.Symbol{
+			Symbol:           symbol,
+			Name:             name,
+			Exchange:         "NSE",
+			InstrumentType:   "EQ",
+			Segment:          "NSE",
+			Series:           "EQ",
+			ISIN:             isin,
+			TickSize:         0.05,
+			LotSize:          1,
+			PricePrecision:   2,
+			TradingPermitted: true,
+			MarketLot:        1,
+			FreezeQty:        freezeQty,
+			MaxOrderSize:     maxOrderSize,
+		}
+	}
+
+	return MarketSimulatorConfig{
+		Seed:           1,
+		TickInterval:   time.Second,
+		Volatility:     0.25,
+		Drift:          0.05,
+		DepthLevels:    5,
+		CircuitPercent: 10,
+		Instruments: []SimulatedInstrument{
+			{Symbol: equity("RELIANCE", "Reliance Industries Ltd.", "INE002A01018", 1250000, 1000000), Close: 2445.25},
+			{Symbol: equity("TCS", "Tata Consultancy Services Ltd.", "INE467B01029", 125000, 100000), Close: 3450.40},
+			{Symbol: equity("HDFC", "Housing Development Finance Corporation Ltd.", "INE001A01036", 125000, 100000), Close: 2650.10},
+		},
+		Indices: []SimulatedIndex{
+			{Symbol: "NIFTY 50", Name: "NIFTY 50", Close: 18195.75},
+			{Symbol: "NIFTY BANK", Name: "NIFTY BANK", Close: 42410.55},
+			{Symbol: "NIFTY IT", Name: "NIFTY IT", Close: 32078.65},
+		},
+	}
+}
+
+// simulatedPrice is the state of one simulated price path
+type simulatedPrice struct {
+	symbol    models.Symbol
+	rng       *rand.Rand
+	close     float64
+	open      float64
+	high      float64
+	low       float64
+	last      float64
+	upTick    bool // The last trade lifted the offer
+	volume    int64
+	turnover  float64
+	bids      []models.DepthLevel
+	asks      []models.DepthLevel
+	updatedAt time.Time
+}
+
+// MarketSimulator is a market data feed that runs entirely offline. Each
+// instrument follows its own geometric Brownian motion from its previous
+// close, seeded from the configured seed and the instrument, so a price
+// path does not depend on what else is subscribed. Prices stay on the tick
+// grid within the circuit limits, and the depth ladder is rebuilt around
+// each trade so the quote, depth and last price always agree.
+type MarketSimulator struct {
+	config        MarketSimulatorConfig
+	instruments   map[string]*simulatedPrice
+	indices       map[string]*simulatedPrice
+	subscriptions map[string]bool
+	onQuote       func(quote *models.MarketQuote)
+	onDepth       func(depth *models.MarketDepth)
+	isConnected   bool
+	done          chan struct{}
+	mutex         sync.Mutex
+}
+
+// NewMarketSimulator creates a new MarketSimulator
+func NewMarketSimulator(config MarketSimulatorConfig) *MarketSimulator {
+	defaults := DefaultMarketSimulatorConfig()
+	if config.Volatility <= 0 {
+		config.Volatility = defaults.Volatility
+	}
+	if config.TickInterval <= 0 {
+		config.TickInterval = defaults.TickInterval
+	}
+	if config.DepthLevels <= 0 {
+		config.DepthLevels = defaults.DepthLevels
+	}
+	if config.CircuitPercent <= 0 {
+		config.CircuitPercent = defaults.CircuitPercent
+	}
+	if config.Instruments == nil {
+		config.Instruments = defaults.Instruments
+	}
+	if config.Indices == nil {
+		config.Indices = defaults.Indices
+	}
+
+	s := &MarketSimulator{
+		config:        config,
+		instruments:   make(map[string]*simulatedPrice),
+		indices:       make(map[string]*simulatedPrice),
+		subscriptions: make(map[string]bool),
+	}
+	for _, instrument := range config.Instruments {
+		s.instruments[instrumentKey(instrument.Symbol.Exchange, instrument.Symbol.Symbol)] = s.newPrice(instrument.Symbol, instrument.Close)
+	}
+	for _, index := range config.Indices {
+		s.indices[index.Symbol] = s.newPrice(models.Symbol{Symbol: index.Symbol, Name: index.Name, TickSize: 0.05}, index.Close)
+	}
+	return s
+}
+
+// instrumentKey returns the key an instrument is held under
+func instrumentKey(exchange string, symbol string) string {
+	return fmt.Sprintf("%s:%s", exchange, symbol)
+}
+
+// seedFor returns the seed of the price path for key
+func (s *MarketSimulator) seedFor(key string) int64 {
+	h := fnv.New64a()
+	h.Write([]byte(key))
+	return s.config.Seed ^ int64(h.Sum64())
+}
+
+// newPrice starts a price path at the previous close
+func (s *MarketSimulator) newPrice(symbol models.Symbol, previousClose float64) *simulatedPrice {
+	if symbol.TickSize <= 0 {
+		symbol.TickSize = 0.05
+	}
+
+	p := &simulatedPrice{
+		symbol:    symbol,
+		rng:       rand.New(rand.NewSource(s.seedFor(instrumentKey(symbol.Exchange, symbol.Symbol)))),
+		close:     previousClose,
+		open:      previousClose,
+		high:      previousClose,
+		low:       previousClose,
+		last:      previousClose,
+		updatedAt: time.Now(),
+	}
+	s.rebuildDepth(p)
+	return p
+}
+
+// priceFor returns the price path of an instrument, starting one from a
+// seeded previous close for instruments not in the configured master.
+// Callers hold the mutex.
+func (s *MarketSimulator) priceFor(symbol string, exchange string) *simulatedPrice {
+	key := instrumentKey(exchange, symbol)
+	if p, ok := s.instruments[key]; ok {
+		return p
+	}
+
+	previousClose := roundToTick(100+float64(uint64(s.seedFor(key))%490000)/100, 0.05, math.Round)
+	p := s.newPrice(models.Symbol{
+		Symbol:           symbol,
+		Name:             symbol,
+		Exchange:         exchange,
+		InstrumentType:   "EQ",
+		Segment:          exchange,
+		Series:           "EQ",
+		TickSize:         0.05,
+		LotSize:          1,
+		PricePrecision:   2,
+		TradingPermitted: true,
+		MarketLot:        1,
+	}, previousClose)
+	s.instruments[key] = p
+	return p
+}
+
+// circuitLimits returns the lowest and highest price allowed for a path
+func (s *MarketSimulator) circuitLimits(p *simulatedPrice) (float64, float64) {
+	band := s.config.CircuitPercent / 100
+	return roundToTick(p.close*(1-band), p.symbol.TickSize, math.Ceil),
+		roundToTick(p.close*(1+band), p.symbol.TickSize, math.Floor)
+}
+
+// step moves a price path forward by one tick interval
+func (s *MarketSimulator) step(p *simulatedPrice, volatility float64, now time.Time) {
+	dt := s.config.TickInterval.Seconds() / tradingSecondsPerYear
+	shock := p.rng.NormFloat64()
+	next := p.last * math.Exp((s.config.Drift-volatility*volatility/2)*dt+volatility*math.Sqrt(dt)*shock)
+
+	lower, upper := s.circuitLimits(p)
+	next = math.Min(math.Max(roundToTick(next, p.symbol.TickSize, math.Round), lower), upper)
+
+	lot := maxInt(p.symbol.LotSize, 1)
+	quantity := int64(lot * (1 + int(p.rng.ExpFloat64()*200)))
+
+	p.upTick = next > p.last || (next == p.last && shock > 0)
+	p.last = next
+	p.high = math.Max(p.high, next)
+	p.low = math.Min(p.low, next)
+	p.volume += quantity
+	p.turnover += float64(quantity) * next
+	p.updatedAt = now
+	s.rebuildDepth(p)
+}
+
+// rebuildDepth lays the depth ladder around the last price. A trade that
+// lifted the offer leaves the last price as the best ask; otherwise it is
+// the best bid. Levels outside the circuit limits are left out.
+func (s *MarketSimulator) rebuildDepth(p *simulatedPrice) {
+	tick := p.symbol.TickSize
+	lower, upper := s.circuitLimits(p)
+	lot := maxInt(p.symbol.LotSize, 1)
+
+	bestBid, bestAsk := p.last, p.last+tick
+	if p.upTick {
+		bestBid, bestAsk = p.last-tick, p.last
+	}
+
+	p.bids = p.bids[:0]
+	p.asks = p.asks[:0]
+	for level := 0; level < s.config.DepthLevels; level++ {
+		if bid := roundPaise(bestBid - float64(level)*tick); bid >= lower {
+			quantity := lot * (50 + p.rng.Intn(500)) * (level + 1)
+			p.bids = append(p.bids, models.DepthLevel{Price: bid, Quantity: quantity, Orders: 1 + quantity/(200*lot)})
+		}
+		if ask := roundPaise(bestAsk + float64(level)*tick); ask <= upper {
+			quantity := lot * (50 + p.rng.Intn(500)) * (level + 1)
+			p.asks = append(p.asks, models.DepthLevel{Price: ask, Quantity: quantity, Orders: 1 + quantity/(200*lot)})
+		}
+	}
+}
+
+// quote returns a quote for the current state of a price path
+func (s *MarketSimulator) quote(p *simulatedPrice) *models.MarketQuote {
+	lower, upper := s.circuitLimits(p)
+
+	quote := &models.MarketQuote{
+		Symbol:         p.symbol.Symbol,
+		Exchange:       p.symbol.Exchange,
+		LastPrice:      p.last,
+		Open:           p.open,
+		High:           p.high,
+		Low:            p.low,
+		Close:          p.close,
+		Change:         roundPaise(p.last - p.close),
+		ChangePercent:  math.Round((p.last-p.close)/p.close*10000) / 100,
+		Volume:         p.volume,
+		AveragePrice:   p.last,
+		LowerCircuit:   lower,
+		UpperCircuit:   upper,
+		YearHigh:       math.Max(roundToTick(p.close*1.3, p.symbol.TickSize, math.Round), p.high),
+		YearLow:        math.Min(roundToTick(p.close*0.7, p.symbol.TickSize, math.Round), p.low),
+		LastTradeTime:  p.updatedAt,
+		LastUpdateTime: p.updatedAt,
+	}
+	if p.volume > 0 {
+		quote.AveragePrice = roundPaise(p.turnover / float64(p.volume))
+	}
+	for _, level := range p.bids {
+		quote.TotalBuyQty += int64(level.Quantity)
+	}
+	for _, level := range p.asks {
+		quote.TotalSellQty += int64(level.Quantity)
+	}
+	if len(p.bids) > 0 {
+		quote.Bid, quote.BidQty = p.bids[0].Price, p.bids[0].Quantity
+	}
+	if len(p.asks) > 0 {
+		quote.Ask, quote.AskQty = p.asks[0].Price, p.asks[0].Quantity
+	}
+	return quote
+}
+
+// depth returns the depth ladder of a price path
+func (s *MarketSimulator) depth(p *simulatedPrice) *models.MarketDepth {
+	return &models.MarketDepth{
+		Symbol:         p.symbol.Symbol,
+		Exchange:       p.symbol.Exchange,
+		Bids:           append([]models.DepthLevel(nil), p.bids...),
+		Asks:           append([]models.DepthLevel(nil), p.asks...),
+		LastUpdateTime: p.updatedAt,
+	}
+}
+
+// OnUpdate sets the handlers the simulator streams quotes and depth to
+func (s *MarketSimulator) OnUpdate(onQuote func(quote *models.MarketQuote), onDepth func(depth *models.MarketDepth)) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.onQuote = onQuote
+	s.onDepth = onDepth
+}
+
+// Connect starts moving prices
+func (s *MarketSimulator) Connect() error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if s.isConnected {
+		return nil
+	}
+
+	s.isConnected = true
+	s.done = make(chan struct{})
+	go s.run(s.done)
+
+	log.Printf("Market simulator started with seed %d", s.config.Seed)
+	return nil
+}
+
+// Disconnect stops moving prices
+func (s *MarketSimulator) Disconnect() error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if !s.isConnected {
+		return nil
+	}
+
+	close(s.done)
+	s.isConnected = false
+	return nil
+}
+
+// IsConnected reports whether prices are moving
+func (s *MarketSimulator) IsConnected() bool {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return s.isConnected
+}
+
+// Subscribe streams updates for an instrument
+func (s *MarketSimulator) Subscribe(symbol string, exchange string) error {
+	if err := s.Connect(); err != nil {
+		return err
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.priceFor(symbol, exchange)
+	s.subscriptions[instrumentKey(exchange, symbol)] = true
+	return nil
+}
+
+// Unsubscribe stops streaming updates for an instrument. Its price stops
+// moving until it is subscribed again.
+func (s *MarketSimulator) Unsubscribe(symbol string, exchange string) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	delete(s.subscriptions, instrumentKey(exchange, symbol))
+	return nil
+}
+
+// run moves prices once per tick interval until done is closed
+func (s *MarketSimulator) run(done chan struct{}) {
+	ticker := time.NewTicker(s.config.TickInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-done:
+			return
+		case now := <-ticker.C:
+			s.Tick(now)
+		}
+	}
+}
+
+// Tick moves every subscribed instrument and every index forward by one
+// tick interval and streams the new quotes and depth
+func (s *MarketSimulator) Tick(now time.Time) {
+	s.mutex.Lock()
+	var quotes []*models.MarketQuote
+	var depths []*models.MarketDepth
+	for key := range s.subscriptions {
+		p := s.instruments[key]
+		s.step(p, s.config.Volatility, now)
+		quotes = append(quotes, s.quote(p))
+		depths = append(depths, s.depth(p))
+	}
+	for _, p := range s.indices {
+		s.step(p, s.config.Volatility/2, now)
+	}
+	onQuote, onDepth := s.onQuote, s.onDepth
+	s.mutex.Unlock()
+
+	for i := range quotes {
+		if onQuote != nil {
+			onQuote(quotes[i])
+		}
+		if onDepth != nil {
+			onDepth(depths[i])
+		}
+	}
+}
+
+// FetchQuote returns an instrument's current quote
+func (s *MarketSimulator) FetchQuote(symbol string, exchange string) (*models.MarketQuote, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return s.quote(s.priceFor(symbol, exchange)), nil
+}
+
+// FetchMarketDepth returns an instrument's current depth ladder
+func (s *MarketSimulator) FetchMarketDepth(symbol string, exchange string) (*models.MarketDepth, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return s.depth(s.priceFor(symbol, exchange)), nil
+}
+
+// FetchHistoricalData generates candles ending at the instrument's previous
+// close. The same seed, instrument, interval and range always produce the
+// same candles.
+func (s *MarketSimulator) FetchHistoricalData(symbol string, exchange string, interval string, startTime time.Time, endTime time.Time) (*models.HistoricalData, error) {
+	s.mutex.Lock()
+	p := s.priceFor(symbol, exchange)
+	previousClose, tick := p.close, p.symbol.TickSize
+	s.mutex.Unlock()
+
+	step := intervalDuration(interval)
+	count := int(endTime.Sub(startTime) / step)
+	if count <= 0 {
+		return &models.HistoricalData{Symbol: symbol, Exchange: exchange, Interval: interval, StartTime: startTime, EndTime: endTime}, nil
+	}
+
+	rng := rand.New(rand.NewSource(s.seedFor(fmt.Sprintf("%s:%s:%d", instrumentKey(exchange, symbol), interval, startTime.Unix()))))
+	hours := math.Min(step.Hours(), 6.25) // A daily candle covers one session
+	years := hours * 3600 / tradingSecondsPerYear
+	const subSteps = 4
+	dt := years / subSteps
+	vol := s.config.Volatility
+
+	// Walk backwards from the previous close so the series ends there
+	closes := make([]float64, count)
+	price := previousClose
+	for i := count - 1; i >= 0; i-- {
+		closes[i] = price
+		price /= math.Exp((s.config.Drift-vol*vol/2)*years + vol*math.Sqrt(years)*rng.NormFloat64())
+	}
+
+	candles := make([]models.OHLC, count)
+	open := price
+	for i := 0; i < count; i++ {
+		high, low := math.Max(open, closes[i]), math.Min(open, closes[i])
+		for j := 1; j < subSteps; j++ {
+			mid := open + (closes[i]-open)*float64(j)/subSteps
+			wiggle := mid * vol * math.Sqrt(dt) * rng.NormFloat64()
+			high = math.Max(high, mid+math.Abs(wiggle))
+			low = math.Min(low, mid-math.Abs(wiggle))
+		}
+
+		candles[i] = models.OHLC{
+			Timestamp: startTime.Add(time.Duration(i) * step),
+			Open:      roundToTick(open, tick, math.Round),
+			High:      roundToTick(high, tick, math.Ceil),
+			Low:       roundToTick(low, tick, math.Floor),
+			Close:     roundToTick(closes[i], tick, math.Round),
+			Volume:    int64(1000 + rng.ExpFloat64()*50000*hours),
+		}
+		open = closes[i]
+	}
+
+	return &models.HistoricalData{
+		Symbol:    symbol,
+		Exchange:  exchange,
+		Interval:  interval,
+		StartTime: startTime,
+		EndTime:   endTime,
+		Candles:   candles,
+	}, nil
+}
+
+// FetchSymbols returns the simulated instrument master
+func (s *MarketSimulator) FetchSymbols() ([]models.Symbol, error) {
+	symbols := make([]models.Symbol, 0, len(s.config.Instruments))
+	now := time.Now()
+	for _, instrument := range s.config.Instruments {
+		symbol := instrument.Symbol
+		symbol.LastUpdateTime = now
+		symbols = append(symbols, symbol)
+	}
+	return symbols, nil
+}
+
+// FetchIndices returns the simulated indices
+func (s *MarketSimulator) FetchIndices() ([]models.MarketIndex, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	indices := make([]models.MarketIndex, 0, len(s.config.Indices))
+	for _, index := range s.config.Indices {
+		p := s.indices[index.Symbol]
+		indices = append(indices, models.MarketIndex{
+			Symbol:         index.Symbol,
+			Name:           index.Name,
+			LastPrice:      p.last,
+			Open:           p.open,
+			High:           p.high,
+			Low:            p.low,
+			Close:          p.close,
+			Change:         roundPaise(p.last - p.close),
+			ChangePercent:  math.Round((p.last-p.close)/p.close*10000) / 100,
+			LastUpdateTime: p.updatedAt,
+		})
+	}
+	return indices, nil
+}
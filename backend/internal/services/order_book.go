@@ -0,0 +1,430 @@
+// stock-trading-app/backend/internal/services/order_book.go
+
+package services
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/shyamanurag/stock-trading-app/backend/internal/models"
+)
+
+// maxSettlementFailures is how many fills in a row may fail to settle for a
+// resting order before it is taken out of the book
+const maxSettlementFailures = 3
+
+// bookEntry is a resting order in the order book. Iceberg orders only show
+// Visible of their Remaining quantity; when the visible slice is used up the
+// next slice is disclosed at the back of the queue.
+type bookEntry struct {
+	OrderID   string
+	UserID    string
+	Side      models.OrderSide
+	Price     float64
+	Remaining int
+	Disclosed int // Iceberg slice size, 0 if the whole order is shown
+	Visible   int
+	Sequence  uint64
+	PlacedAt  time.Time
+	Failures  int // Fills in a row that failed to settle
+}
+
+// visible returns the quantity the entry shows to the market
+func (e *bookEntry) visible() int {
+	if e.Disclosed > 0 {
+		return e.Visible
+	}
+	return e.Remaining
+}
+
+// icebergSlice returns an order's disclosed slice size and how much of the
+// current slice is still unfilled. Slices are counted from the start of the
+// order, so the book can be rebuilt from the filled quantity alone.
+func icebergSlice(order *models.Order) (int, int) {
+	if order.DisclosedQty == nil || *order.DisclosedQty <= 0 || *order.DisclosedQty >= order.Quantity {
+		return 0, 0
+	}
+	disclosed := *order.DisclosedQty
+	return disclosed, minInt(disclosed-order.FilledQuantity%disclosed, order.RemainingQty)
+}
+
+// Fill represents a match between a resting order and market liquidity
+type Fill struct {
+	OrderID  string
+	UserID   string
+	Side     models.OrderSide
+	Quantity int
+	Price    float64
+	Time     time.Time
+}
+
+// orderBook holds the resting limit orders for a single symbol. Bids are
+// kept sorted by price descending and asks by price ascending; orders at
+// the same price keep their arrival sequence (price-time priority).
+type orderBook struct {
+	symbol   string
+	exchange string
+	bids     []*bookEntry
+	asks     []*bookEntry
+	quotedAt time.Time // Trade time of the latest quote matched
+	mutex    sync.Mutex
+}
+
+// newOrderBook creates an empty order book for a symbol
+func newOrderBook(exchange string, symbol string) *orderBook {
+	return &orderBook{
+		symbol:   symbol,
+		exchange: exchange,
+	}
+}
+
+// insert adds an entry to the correct side at its priority position. Within
+// a price level entries are ordered by arrival sequence.
+func (b *orderBook) insert(entry *bookEntry) {
+	if entry.Side == models.OrderSideBuy {
+		i := sort.Search(len(b.bids), func(i int) bool {
+			return b.bids[i].Price < entry.Price ||
+				(b.bids[i].Price == entry.Price && b.bids[i].Sequence > entry.Sequence)
+		})
+		b.bids = append(b.bids, nil)
+		copy(b.bids[i+1:], b.bids[i:])
+		b.bids[i] = entry
+		return
+	}
+
+	i := sort.Search(len(b.asks), func(i int) bool {
+		return b.asks[i].Price > entry.Price ||
+			(b.asks[i].Price == entry.Price && b.asks[i].Sequence > entry.Sequence)
+	})
+	b.asks = append(b.asks, nil)
+	copy(b.asks[i+1:], b.asks[i:])
+	b.asks[i] = entry
+}
+
+// remove deletes an entry by order ID and returns it
+func (b *orderBook) remove(orderID string) *bookEntry {
+	for i, entry := range b.bids {
+		if entry.OrderID == orderID {
+			b.bids = append(b.bids[:i], b.bids[i+1:]...)
+			return entry
+		}
+	}
+	for i, entry := range b.asks {
+		if entry.OrderID == orderID {
+			b.asks = append(b.asks[:i], b.asks[i+1:]...)
+			return entry
+		}
+	}
+	return nil
+}
+
+// match walks resting orders in priority order against the liquidity in a
+// quote. apply is called for every candidate fill; the book is only updated
+// when apply succeeds, so a failed settlement leaves the order in place and
+// matching moves on to the next order. An order whose fills fail to settle
+// maxSettlementFailures times in a row is taken out of the book and
+// returned in evicted.
+func (b *orderBook) match(quote *models.MarketQuote, now time.Time, nextSequence func() uint64, apply func(fill Fill) error) (fills []Fill, evicted []string) {
+
+	// Buy orders take from the offer side of the market
+	askPrice, askQty := quote.Ask, quote.AskQty
+	if askPrice <= 0 {
+		askPrice, askQty = quote.LastPrice, 0
+	}
+	for i := 0; i < len(b.bids) && askPrice > 0; {
+		entry := b.bids[i]
+		if entry.Price < askPrice {
+			break
+		}
+
+		quantity := entry.visible()
+		if askQty > 0 && quantity > askQty {
+			quantity = askQty
+		}
+
+		fill := Fill{
+			OrderID:  entry.OrderID,
+			UserID:   entry.UserID,
+			Side:     entry.Side,
+			Quantity: quantity,
+			Price:    askPrice,
+			Time:     now,
+		}
+		if err := apply(fill); err != nil {
+			if entry.Failures++; entry.Failures >= maxSettlementFailures {
+				b.remove(entry.OrderID)
+				evicted = append(evicted, entry.OrderID)
+				continue
+			}
+			i++
+			continue
+		}
+		entry.Failures = 0
+		fills = append(fills, fill)
+
+		b.consume(entry, quantity, nextSequence)
+
+		if askQty > 0 {
+			askQty -= quantity
+			if askQty == 0 {
+				break
+			}
+		}
+	}
+
+	// Sell orders take from the bid side of the market
+	bidPrice, bidQty := quote.Bid, quote.BidQty
+	if bidPrice <= 0 {
+		bidPrice, bidQty = quote.LastPrice, 0
+	}
+	for i := 0; i < len(b.asks) && bidPrice > 0; {
+		entry := b.asks[i]
+		if entry.Price > bidPrice {
+			break
+		}
+
+		quantity := entry.visible()
+		if bidQty > 0 && quantity > bidQty {
+			quantity = bidQty
+		}
+
+		fill := Fill{
+			OrderID:  entry.OrderID,
+			UserID:   entry.UserID,
+			Side:     entry.Side,
+			Quantity: quantity,
+			Price:    bidPrice,
+			Time:     now,
+		}
+		if err := apply(fill); err != nil {
+			if entry.Failures++; entry.Failures >= maxSettlementFailures {
+				b.remove(entry.OrderID)
+				evicted = append(evicted, entry.OrderID)
+				continue
+			}
+			i++
+			continue
+		}
+		entry.Failures = 0
+		fills = append(fills, fill)
+
+		b.consume(entry, quantity, nextSequence)
+
+		if bidQty > 0 {
+			bidQty -= quantity
+			if bidQty == 0 {
+				break
+			}
+		}
+	}
+
+	return fills, evicted
+}
+
+// consume takes a filled quantity off an entry at the front of the book. A
+// filled order leaves the book; an iceberg whose visible slice is used up
+// discloses its next slice at the back of its price level.
+func (b *orderBook) consume(entry *bookEntry, quantity int, nextSequence func() uint64) {
+	entry.Remaining -= quantity
+	if entry.Disclosed > 0 {
+		entry.Visible -= quantity
+	}
+
+	if entry.Remaining > 0 && entry.visible() > 0 {
+		return
+	}
+
+	b.remove(entry.OrderID)
+	if entry.Remaining == 0 {
+		return
+	}
+
+	entry.Visible = minInt(entry.Disclosed, entry.Remaining)
+	entry.Sequence = nextSequence()
+	b.insert(entry)
+}
+
+// snapshot aggregates resting orders into price levels. If userID is set
+// only that user's orders are included, at their full remaining quantity;
+// otherwise iceberg orders only count their visible slice.
+func (b *orderBook) snapshot(userID string, now time.Time) *models.OrderBook {
+	return &models.OrderBook{
+		Symbol:    b.symbol,
+		Exchange:  b.exchange,
+		Bids:      aggregateLevels(b.bids, userID),
+		Asks:      aggregateLevels(b.asks, userID),
+		Timestamp: now,
+	}
+}
+
+// aggregateLevels collapses sorted entries into OrderLevels
+func aggregateLevels(entries []*bookEntry, userID string) []models.OrderLevel {
+	levels := []models.OrderLevel{}
+	for _, entry := range entries {
+		if userID != "" && entry.UserID != userID {
+			continue
+		}
+		quantity := entry.Remaining
+		if userID == "" {
+			quantity = entry.visible()
+		}
+		if n := len(levels); n > 0 && levels[n-1].Price == entry.Price {
+			levels[n-1].Quantity += quantity
+			levels[n-1].Orders++
+			continue
+		}
+		levels = append(levels, models.OrderLevel{
+			Price:    entry.Price,
+			Quantity: quantity,
+			Orders:   1,
+		})
+	}
+	return levels
+}
+
+// MatchingEngine keeps an in-process order book per symbol and matches
+// resting limit orders against incoming market ticks
+type MatchingEngine struct {
+	books    map[string]*orderBook
+	clock    Clock
+	sequence uint64
+	mutex    sync.RWMutex
+}
+
+// NewMatchingEngine creates a new MatchingEngine
+func NewMatchingEngine(clock Clock) *MatchingEngine {
+	return &MatchingEngine{
+		books: make(map[string]*orderBook),
+		clock: clock,
+	}
+}
+
+// book returns the order book for a symbol, creating it if needed
+func (e *MatchingEngine) book(exchange string, symbol string) *orderBook {
+	key := fmt.Sprintf("%s:%s", exchange, symbol)
+
+	e.mutex.RLock()
+	book, ok := e.books[key]
+	e.mutex.RUnlock()
+	if ok {
+		return book
+	}
+
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+	if book, ok = e.books[key]; !ok {
+		book = newOrderBook(exchange, symbol)
+		e.books[key] = book
+	}
+	return book
+}
+
+// nextSequence returns the next arrival sequence number
+func (e *MatchingEngine) nextSequence() uint64 {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+	e.sequence++
+	return e.sequence
+}
+
+// Add rests a limit order in the book at the back of its price level
+func (e *MatchingEngine) Add(order *models.Order) error {
+	if order.Price == nil || *order.Price <= 0 {
+		return fmt.Errorf("order %s has no limit price", order.ID)
+	}
+	if order.RemainingQty <= 0 {
+		return fmt.Errorf("order %s has no remaining quantity", order.ID)
+	}
+
+	book := e.book(order.Exchange, order.Symbol)
+	disclosed, visible := icebergSlice(order)
+	entry := &bookEntry{
+		OrderID:   order.ID,
+		UserID:    order.UserID,
+		Side:      order.Side,
+		Price:     *order.Price,
+		Remaining: order.RemainingQty,
+		Disclosed: disclosed,
+		Visible:   visible,
+		Sequence:  e.nextSequence(),
+		PlacedAt:  order.CreatedAt,
+	}
+
+	book.mutex.Lock()
+	defer book.mutex.Unlock()
+	book.insert(entry)
+	return nil
+}
+
+// Remove takes an order out of the book. It returns false if the order was
+// not resting.
+func (e *MatchingEngine) Remove(exchange string, symbol string, orderID string) bool {
+	book := e.book(exchange, symbol)
+
+	book.mutex.Lock()
+	defer book.mutex.Unlock()
+	return book.remove(orderID) != nil
+}
+
+// Amend updates a resting order's price and remaining quantity. Reducing
+// the quantity at the same price keeps the order's place in the queue; any
+// other change sends it to the back of its new price level.
+func (e *MatchingEngine) Amend(order *models.Order) error {
+	if order.Price == nil || *order.Price <= 0 {
+		return fmt.Errorf("order %s has no limit price", order.ID)
+	}
+	if order.RemainingQty <= 0 {
+		return fmt.Errorf("order %s has no remaining quantity", order.ID)
+	}
+
+	sequence := e.nextSequence()
+	book := e.book(order.Exchange, order.Symbol)
+
+	book.mutex.Lock()
+	defer book.mutex.Unlock()
+
+	entry := book.remove(order.ID)
+	if entry == nil {
+		return fmt.Errorf("order %s is not resting in the book", order.ID)
+	}
+
+	if entry.Price != *order.Price || order.RemainingQty > entry.Remaining {
+		entry.Sequence = sequence
+	}
+	entry.Price = *order.Price
+	entry.Remaining = order.RemainingQty
+	entry.Disclosed, entry.Visible = icebergSlice(order)
+	book.insert(entry)
+	return nil
+}
+
+// Match matches the book for the quote's symbol against the quote. Fills
+// are serialised per symbol, stamped with the quote's trade time and passed
+// to apply for settlement. Quotes delivered out of order are dropped if a
+// later quote was already matched. It returns the fills that settled and
+// the orders taken out of the book because their fills kept failing to
+// settle.
+func (e *MatchingEngine) Match(quote *models.MarketQuote, apply func(fill Fill) error) ([]Fill, []string) {
+	book := e.book(quote.Exchange, quote.Symbol)
+	quotedAt := quoteTime(quote, e.clock)
+
+	book.mutex.Lock()
+	defer book.mutex.Unlock()
+	if quotedAt.Before(book.quotedAt) {
+		return nil, nil
+	}
+	book.quotedAt = quotedAt
+	return book.match(quote, quotedAt, e.nextSequence, apply)
+}
+
+// Snapshot returns the aggregated order book for a symbol. If userID is set
+// only that user's resting orders are included.
+func (e *MatchingEngine) Snapshot(exchange string, symbol string, userID string) *models.OrderBook {
+	book := e.book(exchange, symbol)
+
+	book.mutex.Lock()
+	defer book.mutex.Unlock()
+	return book.snapshot(userID, e.clock.Now())
+}
@@ -0,0 +1,199 @@
+// stock-trading-app/backend/internal/services/order_book_test.go
+
+package services
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/shyamanurag/stock-trading-app/backend/internal/models"
+)
+
+// restingOrder builds an open limit order for the test book
+func restingOrder(id string, side models.OrderSide, price float64, quantity int) *models.Order {
+	return &models.Order{
+		ID:           id,
+		UserID:       "user-" + id,
+		Symbol:       "INFY",
+		Exchange:     "NSE",
+		Side:         side,
+		Type:         models.OrderTypeLimit,
+		Price:        &price,
+		Quantity:     quantity,
+		RemainingQty: quantity,
+	}
+}
+
+// settleAll is an apply func that accepts every fill
+func settleAll(fill Fill) error {
+	return nil
+}
+
+func TestMatchingEnginePriceTimePriority(t *testing.T) {
+	quotedAt := time.Date(2024, 3, 14, 10, 0, 0, 0, istLocation)
+	engine := NewMatchingEngine(NewVirtualClock(quotedAt))
+
+	// The better price goes first and equal prices keep their arrival order
+	for _, order := range []*models.Order{
+		restingOrder("a", models.OrderSideBuy, 1500, 100),
+		restingOrder("b", models.OrderSideBuy, 1501, 100),
+		restingOrder("c", models.OrderSideBuy, 1501, 100),
+	} {
+		if err := engine.Add(order); err != nil {
+			t.Fatalf("add %s: %v", order.ID, err)
+		}
+	}
+
+	fills, evicted := engine.Match(&models.MarketQuote{
+		Symbol:        "INFY",
+		Exchange:      "NSE",
+		Ask:           1500,
+		AskQty:        150,
+		LastTradeTime: quotedAt,
+	}, settleAll)
+	if len(evicted) != 0 {
+		t.Fatalf("evicted %v, want none", evicted)
+	}
+
+	want := []Fill{
+		{OrderID: "b", UserID: "user-b", Side: models.OrderSideBuy, Quantity: 100, Price: 1500, Time: quotedAt},
+		{OrderID: "c", UserID: "user-c", Side: models.OrderSideBuy, Quantity: 50, Price: 1500, Time: quotedAt},
+	}
+	if !reflect.DeepEqual(fills, want) {
+		t.Fatalf("fills = %+v, want %+v", fills, want)
+	}
+
+	book := engine.Snapshot("NSE", "INFY", "")
+	wantBids := []models.OrderLevel{
+		{Price: 1501, Quantity: 50, Orders: 1},
+		{Price: 1500, Quantity: 100, Orders: 1},
+	}
+	if !reflect.DeepEqual(book.Bids, wantBids) {
+		t.Errorf("bids = %+v, want %+v", book.Bids, wantBids)
+	}
+}
+
+func TestMatchingEnginePartialFill(t *testing.T) {
+	quotedAt := time.Date(2024, 3, 14, 10, 0, 0, 0, istLocation)
+	engine := NewMatchingEngine(NewVirtualClock(quotedAt))
+	if err := engine.Add(restingOrder("a", models.OrderSideSell, 1500, 100)); err != nil {
+		t.Fatalf("add: %v", err)
+	}
+
+	// The bid only covers part of the order, which stays in the book
+	fills, _ := engine.Match(&models.MarketQuote{
+		Symbol:        "INFY",
+		Exchange:      "NSE",
+		Bid:           1500.5,
+		BidQty:        30,
+		LastTradeTime: quotedAt,
+	}, settleAll)
+	if len(fills) != 1 || fills[0].Quantity != 30 || fills[0].Price != 1500.5 {
+		t.Fatalf("fills = %+v, want 30 at 1500.50", fills)
+	}
+
+	book := engine.Snapshot("NSE", "INFY", "")
+	if len(book.Asks) != 1 || book.Asks[0].Quantity != 70 {
+		t.Fatalf("asks = %+v, want 70 resting", book.Asks)
+	}
+
+	// A bid below the limit price does not trade
+	fills, _ = engine.Match(&models.MarketQuote{
+		Symbol:        "INFY",
+		Exchange:      "NSE",
+		Bid:           1499,
+		BidQty:        500,
+		LastTradeTime: quotedAt.Add(time.Second),
+	}, settleAll)
+	if len(fills) != 0 {
+		t.Fatalf("fills = %+v, want none below the limit price", fills)
+	}
+
+	// The rest fills and the order leaves the book
+	fills, _ = engine.Match(&models.MarketQuote{
+		Symbol:        "INFY",
+		Exchange:      "NSE",
+		Bid:           1500,
+		BidQty:        500,
+		LastTradeTime: quotedAt.Add(2 * time.Second),
+	}, settleAll)
+	if len(fills) != 1 || fills[0].Quantity != 70 {
+		t.Fatalf("fills = %+v, want the remaining 70", fills)
+	}
+	if book := engine.Snapshot("NSE", "INFY", ""); len(book.Asks) != 0 {
+		t.Errorf("asks = %+v, want an empty book", book.Asks)
+	}
+}
+
+func TestMatchingEngineDropsOutOfOrderQuotes(t *testing.T) {
+	quotedAt := time.Date(2024, 3, 14, 10, 0, 0, 0, istLocation)
+	engine := NewMatchingEngine(NewVirtualClock(quotedAt))
+	if err := engine.Add(restingOrder("a", models.OrderSideBuy, 1500, 100)); err != nil {
+		t.Fatalf("add: %v", err)
+	}
+
+	engine.Match(&models.MarketQuote{
+		Symbol:        "INFY",
+		Exchange:      "NSE",
+		Ask:           1510,
+		AskQty:        100,
+		LastTradeTime: quotedAt,
+	}, settleAll)
+
+	// A tick from before the one already matched is stale
+	fills, _ := engine.Match(&models.MarketQuote{
+		Symbol:        "INFY",
+		Exchange:      "NSE",
+		Ask:           1495,
+		AskQty:        100,
+		LastTradeTime: quotedAt.Add(-time.Second),
+	}, settleAll)
+	if len(fills) != 0 {
+		t.Errorf("fills = %+v, want none from a stale quote", fills)
+	}
+}
+
+func TestMatchingEngineEvictsOrdersThatFailToSettle(t *testing.T) {
+	quotedAt := time.Date(2024, 3, 14, 10, 0, 0, 0, istLocation)
+	engine := NewMatchingEngine(NewVirtualClock(quotedAt))
+	for _, order := range []*models.Order{
+		restingOrder("a", models.OrderSideBuy, 1501, 100),
+		restingOrder("b", models.OrderSideBuy, 1500, 100),
+	} {
+		if err := engine.Add(order); err != nil {
+			t.Fatalf("add %s: %v", order.ID, err)
+		}
+	}
+
+	// Order a never settles, so matching moves on to b each time
+	failA := func(fill Fill) error {
+		if fill.OrderID == "a" {
+			return errors.New("settlement failed")
+		}
+		return nil
+	}
+
+	var evicted []string
+	for i := 0; i < maxSettlementFailures; i++ {
+		var fills []Fill
+		fills, evicted = engine.Match(&models.MarketQuote{
+			Symbol:        "INFY",
+			Exchange:      "NSE",
+			Ask:           1500,
+			AskQty:        10,
+			LastTradeTime: quotedAt.Add(time.Duration(i) * time.Second),
+		}, failA)
+		if len(fills) != 1 || fills[0].OrderID != "b" {
+			t.Fatalf("match %d: fills = %+v, want one fill for b", i+1, fills)
+		}
+	}
+
+	if !reflect.DeepEqual(evicted, []string{"a"}) {
+		t.Fatalf("evicted = %v, want [a]", evicted)
+	}
+	if book := engine.Snapshot("NSE", "INFY", ""); len(book.Bids) != 1 || book.Bids[0].Price != 1500 {
+		t.Errorf("bids = %+v, want only b resting", book.Bids)
+	}
+}
@@ -0,0 +1,145 @@
+// stock-trading-app/backend/internal/services/order_events.go
+
+package services
+
+import (
+	"sync"
+	"time"
+
+	"github.com/shyamanurag/stock-trading-app/backend/internal/models"
+	"github.com/shyamanurag/stock-trading-app/backend/internal/repository"
+	"gorm.io/gorm"
+)
+
+// OrderEventType identifies a change to an order
+type OrderEventType string
+
+// Order event types
+const (
+	OrderPlaced          OrderEventType = "order_placed"
+	OrderModified        OrderEventType = "order_modified"
+	OrderPartiallyFilled OrderEventType = "order_partially_filled"
+	OrderFilled          OrderEventType = "order_filled"
+	OrderCancelled       OrderEventType = "order_cancelled"
+	OrderRejected        OrderEventType = "order_rejected"
+	OrderExpired         OrderEventType = "order_expired"
+	TradeExecuted        OrderEventType = "trade_executed"
+)
+
+// OrderEvent is a change to one of a user's orders. Sequence increases by
+// one with every event sent to the same user, so a client that sees a gap
+// knows it missed an update and should reload its orders. Sequences start
+// again from 1 when the server restarts.
+type OrderEvent struct {
+	Type      OrderEventType `json:"type"`
+	Sequence  uint64         `json:"sequence"`
+	UserID    string         `json:"userId"`
+	Order     *models.Order  `json:"order"`
+	Trade     *models.Trade  `json:"trade,omitempty"`
+	Timestamp time.Time      `json:"timestamp"`
+}
+
+// OrderEventBus numbers order events per user and hands them to every
+// subscriber in sequence order
+type OrderEventBus struct {
+	clock       Clock
+	sequences   map[string]uint64
+	subscribers []func(event *OrderEvent)
+	mutex       sync.Mutex
+}
+
+// NewOrderEventBus creates a new OrderEventBus that stamps events with the
+// clock's time
+func NewOrderEventBus(clock Clock) *OrderEventBus {
+	return &OrderEventBus{
+		clock:     clock,
+		sequences: make(map[string]uint64),
+	}
+}
+
+// Subscribe registers a callback for every order event. Callbacks run in
+// sequence order and must not block.
+func (b *OrderEventBus) Subscribe(callback func(event *OrderEvent)) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	b.subscribers = append(b.subscribers, callback)
+}
+
+// ForwardTo sends every order event to the user's websocket clients
+func (b *OrderEventBus) ForwardTo(hub *WebSocketHub) {
+	b.Subscribe(func(event *OrderEvent) {
+		hub.SendToUser(event.UserID, ServerMessage{
+			Type:      string(event.Type),
+			Data:      event,
+			Timestamp: event.Timestamp.Unix(),
+		})
+	})
+}
+
+// Publish numbers an event and delivers it to the subscribers
+func (b *OrderEventBus) Publish(eventType OrderEventType, order *models.Order, trade *models.Trade) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	b.sequences[order.UserID]++
+	event := &OrderEvent{
+		Type:      eventType,
+		Sequence:  b.sequences[order.UserID],
+		UserID:    order.UserID,
+		Order:     order,
+		Trade:     trade,
+		Timestamp: b.clock.Now(),
+	}
+
+	for _, subscriber := range b.subscribers {
+		subscriber(event)
+	}
+}
+
+// Events returns the bus order events are published on
+func (s *TradingService) Events() *OrderEventBus {
+	return s.events
+}
+
+// emitOrderEvent publishes an event for the order as it is now once tx has
+// committed. Nothing is published if the transaction rolls back.
+func (s *TradingService) emitOrderEvent(tx *gorm.DB, eventType OrderEventType, order *models.Order, trade *models.Trade) {
+	snapshot := *order
+	repository.AfterCommit(tx, func() {
+		s.events.Publish(eventType, &snapshot, trade)
+	})
+}
+
+// emitFillEvents publishes the trade for a fill followed by the order's new
+// fill state
+func (s *TradingService) emitFillEvents(tx *gorm.DB, order *models.Order, trade *models.Trade) {
+	s.emitOrderEvent(tx, TradeExecuted, order, trade)
+	if order.Status == models.OrderStatusCompleted {
+		s.emitOrderEvent(tx, OrderFilled, order, nil)
+	} else {
+		s.emitOrderEvent(tx, OrderPartiallyFilled, order, nil)
+	}
+}
+
+// publishOrderEvent publishes an event for a change made outside a
+// transaction
+func (s *TradingService) publishOrderEvent(eventType OrderEventType, order *models.Order) {
+	snapshot := *order
+	s.events.Publish(eventType, &snapshot, nil)
+}
+
+// statusEvent returns the event for an order that has moved to a closed
+// status
+func statusEvent(status models.OrderStatus) OrderEventType {
+	switch status {
+	case models.OrderStatusCancelled:
+		return OrderCancelled
+	case models.OrderStatusExpired:
+		return OrderExpired
+	case models.OrderStatusRejected:
+		return OrderRejected
+	case models.OrderStatusCompleted:
+		return OrderFilled
+	}
+	return OrderModified
+}
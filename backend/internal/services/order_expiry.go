@@ -0,0 +1,231 @@
+// stock-trading-app/backend/internal/services/order_expiry.go
+
+package services
+
+import (
+	"container/heap"
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/shyamanurag/stock-trading-app/backend/internal/models"
+)
+
+// errOrderNotExpirable is returned when an order has already reached a
+// terminal state before its expiry fired
+var errOrderNotExpirable = errors.New("order is no longer open")
+
+// OrderExpirer loads and expires orders on behalf of the scheduler
+type OrderExpirer interface {
+	ExpirableOrders(ctx context.Context) ([]*models.Order, error)
+	ExpireOrder(ctx context.Context, orderID string, reason string) error
+}
+
+// expiryItem is an order waiting for its validity to run out
+type expiryItem struct {
+	OrderID   string
+	Exchange  string
+	ExpiresAt time.Time
+	Reason    string
+	index     int
+}
+
+// expiryQueue is a min-heap of expiry items ordered by ExpiresAt
+type expiryQueue []*expiryItem
+
+func (q expiryQueue) Len() int { return len(q) }
+
+func (q expiryQueue) Less(i, j int) bool { return q[i].ExpiresAt.Before(q[j].ExpiresAt) }
+
+func (q expiryQueue) Swap(i, j int) {
+	q[i], q[j] = q[j], q[i]
+	q[i].index = i
+	q[j].index = j
+}
+
+func (q *expiryQueue) Push(x interface{}) {
+	item := x.(*expiryItem)
+	item.index = len(*q)
+	*q = append(*q, item)
+}
+
+func (q *expiryQueue) Pop() interface{} {
+	old := *q
+	n := len(old)
+	item := old[n-1]
+	old[n-1] = nil
+	*q = old[:n-1]
+	return item
+}
+
+// OrderExpiryScheduler expires DAY orders at the exchange close and GTD
+// orders at the close of their validity date. Orders expire when the session
+// monitor reports their exchange closing, so a close moved by a holiday list
+// loaded after the order was placed is honoured. Each order is also
+// scheduled at the close the market calendar gives, which expires orders
+// whose close passed while the process was down.
+type OrderExpiryScheduler struct {
+	calendar *MarketCalendar
+	expirer  OrderExpirer
+	queue    expiryQueue
+	items    map[string]*expiryItem
+	wake     chan struct{}
+	mutex    sync.Mutex
+}
+
+// NewOrderExpiryScheduler creates a new OrderExpiryScheduler
+func NewOrderExpiryScheduler(calendar *MarketCalendar, expirer OrderExpirer) *OrderExpiryScheduler {
+	return &OrderExpiryScheduler{
+		calendar: calendar,
+		expirer:  expirer,
+		items:    make(map[string]*expiryItem),
+		wake:     make(chan struct{}, 1),
+	}
+}
+
+// ExpiryTime returns when an order's validity runs out. GTC and IOC orders
+// have no scheduled expiry.
+func (s *OrderExpiryScheduler) ExpiryTime(order *models.Order) (time.Time, string, bool) {
+	switch order.Validity {
+	case models.OrderValidityDay, "":
+		placedAt := order.CreatedAt
+		if placedAt.IsZero() {
+			placedAt = s.calendar.Now()
+		}
+		return s.calendar.NextSessionClose(order.Exchange, placedAt), "DAY order expired at market close", true
+	case models.OrderValidityGTD:
+		if order.ValidityDate == nil {
+			return time.Time{}, "", false
+		}
+		return s.calendar.SessionClose(order.Exchange, *order.ValidityDate), "GTD order reached its validity date", true
+	}
+	return time.Time{}, "", false
+}
+
+// Start rebuilds the schedule from open orders and runs it until ctx is done
+func (s *OrderExpiryScheduler) Start(ctx context.Context) error {
+	orders, err := s.expirer.ExpirableOrders(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to load expirable orders: %w", err)
+	}
+
+	for _, order := range orders {
+		s.Track(order)
+	}
+
+	go s.run(ctx)
+	return nil
+}
+
+// Track schedules an order's expiry. Orders without one are ignored.
+func (s *OrderExpiryScheduler) Track(order *models.Order) {
+	expiresAt, reason, ok := s.ExpiryTime(order)
+	if !ok {
+		return
+	}
+
+	s.mutex.Lock()
+	if existing, ok := s.items[order.ID]; ok {
+		heap.Remove(&s.queue, existing.index)
+	}
+	item := &expiryItem{OrderID: order.ID, Exchange: order.Exchange, ExpiresAt: expiresAt, Reason: reason}
+	heap.Push(&s.queue, item)
+	s.items[order.ID] = item
+	s.mutex.Unlock()
+
+	s.signal()
+}
+
+// Untrack removes an order from the schedule
+func (s *OrderExpiryScheduler) Untrack(orderID string) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if item, ok := s.items[orderID]; ok {
+		heap.Remove(&s.queue, item.index)
+		delete(s.items, orderID)
+	}
+}
+
+// OnStatusChange expires an exchange's orders due at today's close as soon
+// as its session ends
+func (s *OrderExpiryScheduler) OnStatusChange(change *models.MarketStatusChange) {
+	if change.Previous != models.MarketStatusOpen {
+		return
+	}
+
+	day := s.calendar.midnight(change.At)
+	s.mutex.Lock()
+	for _, item := range s.items {
+		if item.Exchange != change.Exchange || !item.ExpiresAt.After(change.At) {
+			continue
+		}
+		if !s.calendar.midnight(item.ExpiresAt).After(day) {
+			item.ExpiresAt = change.At
+			heap.Fix(&s.queue, item.index)
+		}
+	}
+	s.mutex.Unlock()
+
+	s.signal()
+}
+
+// signal wakes the run loop so it can recompute its timer
+func (s *OrderExpiryScheduler) signal() {
+	select {
+	case s.wake <- struct{}{}:
+	default:
+	}
+}
+
+// run sleeps until the earliest expiry and expires every due order. Time
+// is read from the calendar so expiries follow the replay clock in replay.
+func (s *OrderExpiryScheduler) run(ctx context.Context) {
+	for {
+		now := s.calendar.Now()
+		s.expireDue(ctx, now)
+
+		wait := time.Hour
+		s.mutex.Lock()
+		if len(s.queue) > 0 {
+			wait = s.queue[0].ExpiresAt.Sub(now)
+		}
+		s.mutex.Unlock()
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-s.wake:
+		case <-s.calendar.After(wait):
+		}
+	}
+}
+
+// expireDue expires every order whose expiry is at or before now
+func (s *OrderExpiryScheduler) expireDue(ctx context.Context, now time.Time) {
+	for {
+		s.mutex.Lock()
+		if len(s.queue) == 0 || s.queue[0].ExpiresAt.After(now) {
+			s.mutex.Unlock()
+			return
+		}
+		item := heap.Pop(&s.queue).(*expiryItem)
+		if s.calendar.IsOpen(item.Exchange, now) && s.calendar.midnight(item.ExpiresAt).Equal(s.calendar.midnight(now)) {
+			// Today's close was moved later after the order was scheduled
+			item.ExpiresAt = s.calendar.SessionClose(item.Exchange, now)
+			heap.Push(&s.queue, item)
+			s.mutex.Unlock()
+			continue
+		}
+		delete(s.items, item.OrderID)
+		s.mutex.Unlock()
+
+		err := s.expirer.ExpireOrder(ctx, item.OrderID, item.Reason)
+		if err != nil && !errors.Is(err, errOrderNotExpirable) {
+			log.Printf("Failed to expire order %s: %v", item.OrderID, err)
+		}
+	}
+}
@@ -0,0 +1,338 @@
+// stock-trading-app/backend/internal/services/order_slicing.go
+
+package services
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shyamanurag/stock-trading-app/backend/internal/models"
+	"github.com/shyamanurag/stock-trading-app/backend/internal/repository"
+	"gorm.io/gorm"
+)
+
+// Orders larger than an instrument's freeze quantity or maximum order size
+// are split into child slices that each fit within the limits. The parent
+// order never trades; it carries SliceCount and mirrors its slices with the
+// aggregate quantity, fill, average price and status. Each slice is a
+// regular order linked through ParentOrderID that reserves and fills on its
+// own. Cancelling the parent cancels every open slice.
+
+// isSliceParent reports whether an order was split into slices
+func isSliceParent(order *models.Order) bool {
+	return order.SliceCount > 0
+}
+
+// isOrderSlice reports whether an order is one slice of a larger order
+func isOrderSlice(order *models.Order) bool {
+	return order.ParentOrderID != nil && !isBracketVariety(order)
+}
+
+// sliceSize returns the largest quantity a single order in the instrument
+// may have, or 0 if there is no limit. F&O slices are kept to whole lots.
+func (s *TradingService) sliceSize(order *models.Order) int {
+	symbol, err := s.marketData.GetSymbol(order.Symbol, order.Exchange)
+	if err != nil || symbol == nil {
+		return 0
+	}
+
+	size := symbol.FreezeQty
+	if symbol.MaxOrderSize > 0 && (size == 0 || symbol.MaxOrderSize < size) {
+		size = symbol.MaxOrderSize
+	}
+	if size > 0 && symbol.LotSize > 1 {
+		size -= size % symbol.LotSize
+	}
+	return size
+}
+
+// placeSlices places the slices of a sliced order one by one. Each slice is
+// stored in one transaction with the parent, which is created alongside the
+// first slice and brought up to date with every later one, so the parent
+// always covers exactly the slices that were placed. If a slice cannot be
+// placed the rest are not attempted; if none was placed the parent is stored
+// as REJECTED.
+func (s *TradingService) placeSlices(ctx context.Context, parent *models.Order, size int) (*models.Order, error) {
+	total := parent.SliceCount
+
+	placed := 0
+	var placeErr error
+	for remaining := parent.Quantity; remaining > 0; remaining -= size {
+		slice := s.newOrderSlice(parent, minInt(size, remaining))
+		if placeErr = s.prepareOrder(ctx, slice); placeErr != nil {
+			break
+		}
+
+		var executedAt time.Time
+		if executesOnPlacement(slice) {
+			if executedAt, placeErr = s.awaitExecution(ctx); placeErr != nil {
+				break
+			}
+		}
+
+		placeErr = s.transactionMgr.WithTransaction(ctx, func(tx *gorm.DB) error {
+			return s.storeSlice(ctx, tx, parent, slice, placed+1, executedAt)
+		})
+		if placeErr != nil {
+			break
+		}
+		placed++
+
+		if _, err := s.activateOrder(ctx, slice); err != nil {
+			log.Printf("Failed to activate slice %s of order %s: %v", slice.ID, parent.ID, err)
+		}
+	}
+
+	if placed == 0 {
+		parent.SliceCount = total
+		parent.Status = models.OrderStatusRejected
+		parent.Error = placeErr.Error()
+		if err := s.orderRepo.Create(ctx, parent); err != nil {
+			return nil, fmt.Errorf("failed to store rejected order: %w", err)
+		}
+		s.publishOrderEvent(OrderRejected, parent)
+		return nil, placeErr
+	}
+
+	if placeErr != nil {
+		err := s.transactionMgr.WithTransaction(ctx, func(tx *gorm.DB) error {
+			orderRepo := repository.NewOrderRepository(tx)
+			order, err := orderRepo.GetByIDForUpdate(ctx, parent.ID)
+			if err != nil {
+				return fmt.Errorf("failed to get order: %w", err)
+			}
+			if order == nil {
+				return fmt.Errorf("order not found")
+			}
+
+			order.Error = fmt.Sprintf("placed %d of %d slices: %v", placed, total, placeErr)
+			if err := orderRepo.Update(ctx, order); err != nil {
+				return fmt.Errorf("failed to update sliced order: %w", err)
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	updated, err := s.orderRepo.GetByID(ctx, parent.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get order: %w", err)
+	}
+	if updated == nil {
+		return nil, fmt.Errorf("order not found")
+	}
+	return updated, nil
+}
+
+// storeSlice reserves for and stores the n-th slice of a sliced order within
+// a transaction and brings the parent up to date with it. The first slice
+// also creates the parent. A market slice executes at executedAt.
+func (s *TradingService) storeSlice(ctx context.Context, tx *gorm.DB, parent *models.Order, slice *models.Order, n int, executedAt time.Time) error {
+	orderRepo := repository.NewOrderRepository(tx)
+	if n == 1 {
+		order := *parent
+		order.SliceCount = n
+		if err := orderRepo.Create(ctx, &order); err != nil {
+			return fmt.Errorf("failed to create order: %w", err)
+		}
+		s.emitOrderEvent(tx, OrderPlaced, &order, nil)
+	} else {
+		order, err := orderRepo.GetByIDForUpdate(ctx, parent.ID)
+		if err != nil {
+			return fmt.Errorf("failed to get order: %w", err)
+		}
+		if order == nil {
+			return fmt.Errorf("order not found")
+		}
+
+		order.SliceCount = n
+		if err := orderRepo.Update(ctx, order); err != nil {
+			return fmt.Errorf("failed to update sliced order: %w", err)
+		}
+	}
+
+	if err := s.storeOrder(ctx, tx, slice, executedAt); err != nil {
+		return err
+	}
+	return s.onSliceChange(ctx, tx, slice)
+}
+
+// newOrderSlice builds one slice of a sliced order
+func (s *TradingService) newOrderSlice(parent *models.Order, quantity int) *models.Order {
+	parentID := parent.ID
+
+	// Each iceberg slice discloses no more than its own quantity
+	var disclosedQty *int
+	if parent.DisclosedQty != nil {
+		disclosed := minInt(*parent.DisclosedQty, quantity)
+		disclosedQty = &disclosed
+	}
+
+	return &models.Order{
+		ID:             uuid.New().String(),
+		UserID:         parent.UserID,
+		Symbol:         parent.Symbol,
+		Exchange:       parent.Exchange,
+		Quantity:       quantity,
+		Price:          parent.Price,
+		TriggerPrice:   parent.TriggerPrice,
+		Type:           parent.Type,
+		Side:           parent.Side,
+		Validity:       parent.Validity,
+		ValidityDate:   parent.ValidityDate,
+		Product:        parent.Product,
+		InstrumentType: parent.InstrumentType,
+		ExpiryDate:     parent.ExpiryDate,
+		StrikePrice:    parent.StrikePrice,
+		OptionType:     parent.OptionType,
+		DisclosedQty:   disclosedQty,
+		TrailAmount:    parent.TrailAmount,
+		TrailPercent:   parent.TrailPercent,
+		Tag:            parent.Tag,
+		ParentOrderID:  &parentID,
+		Variety:        parent.Variety,
+		StrategyID:     parent.StrategyID,
+		PlacedBy:       parent.PlacedBy,
+	}
+}
+
+// onSliceChange brings a sliced order's parent up to date after one of its
+// slices was filled, modified, cancelled or expired
+func (s *TradingService) onSliceChange(ctx context.Context, tx *gorm.DB, order *models.Order) error {
+	if !isOrderSlice(order) {
+		return nil
+	}
+
+	// Slices of the same order can settle concurrently, so the parent row is
+	// locked while it is recomputed
+	parent, err := repository.NewOrderRepository(tx).GetByIDForUpdate(ctx, *order.ParentOrderID)
+	if err != nil {
+		return fmt.Errorf("failed to get parent order: %w", err)
+	}
+	if parent == nil || !isSliceParent(parent) {
+		return nil
+	}
+
+	return s.aggregateSlices(ctx, tx, parent)
+}
+
+// aggregateSlices recomputes a sliced order's quantity, fill, average price,
+// charges and status from its slices
+func (s *TradingService) aggregateSlices(ctx context.Context, tx *gorm.DB, parent *models.Order) error {
+	orderRepo := repository.NewOrderRepository(tx)
+	slices, err := orderRepo.GetByParentID(ctx, parent.ID)
+	if err != nil {
+		return fmt.Errorf("failed to get order slices: %w", err)
+	}
+
+	previousStatus, previousFilled := parent.Status, parent.FilledQuantity
+
+	var quantity, filled, remaining int
+	var filledValue float64
+	var executedAt *time.Time
+	charges := &models.ChargesBreakup{}
+	statuses := make(map[models.OrderStatus]int)
+	for _, slice := range slices {
+		quantity += slice.Quantity
+		filled += slice.FilledQuantity
+		if slice.AvgExecutionPrice != nil {
+			filledValue += float64(slice.FilledQuantity) * *slice.AvgExecutionPrice
+		}
+		if !isTerminalStatus(slice.Status) {
+			remaining += slice.RemainingQty
+		}
+		if slice.ExecutedAt != nil && (executedAt == nil || slice.ExecutedAt.After(*executedAt)) {
+			executedAt = slice.ExecutedAt
+		}
+		charges.Add(models.ChargesBreakupFromJSON(slice.Charges))
+		statuses[slice.Status]++
+	}
+
+	parent.Quantity = quantity
+	parent.FilledQuantity = filled
+	parent.RemainingQty = remaining
+	parent.Charges = charges.ToJSON()
+	if filled > 0 {
+		avgPrice := filledValue / float64(filled)
+		parent.AvgExecutionPrice = &avgPrice
+	}
+
+	open := statuses[models.OrderStatusPending] + statuses[models.OrderStatusOpen] +
+		statuses[models.OrderStatusPartial] + statuses[models.OrderStatusQueued]
+	switch {
+	case open > 0 && filled > 0:
+		parent.Status = models.OrderStatusPartial
+	case open > 0 && statuses[models.OrderStatusQueued] == open:
+		parent.Status = models.OrderStatusQueued
+	case open > 0 && statuses[models.OrderStatusPending] == open:
+		parent.Status = models.OrderStatusPending
+	case open > 0:
+		parent.Status = models.OrderStatusOpen
+	case filled == quantity:
+		parent.Status = models.OrderStatusCompleted
+		parent.ExecutedAt = executedAt
+	case statuses[models.OrderStatusCancelled] > 0:
+		parent.Status = models.OrderStatusCancelled
+	case statuses[models.OrderStatusExpired] > 0:
+		parent.Status = models.OrderStatusExpired
+	default:
+		parent.Status = models.OrderStatusRejected
+	}
+
+	if err := orderRepo.Update(ctx, parent); err != nil {
+		return fmt.Errorf("failed to update sliced order: %w", err)
+	}
+
+	// The parent's events follow its slices
+	switch {
+	case parent.FilledQuantity > previousFilled && parent.Status == models.OrderStatusCompleted:
+		s.emitOrderEvent(tx, OrderFilled, parent, nil)
+	case parent.FilledQuantity > previousFilled:
+		s.emitOrderEvent(tx, OrderPartiallyFilled, parent, nil)
+	case parent.Status != previousStatus:
+		s.emitOrderEvent(tx, statusEvent(parent.Status), parent, nil)
+	}
+	return nil
+}
+
+// cancelSlices cancels every open slice of a sliced order
+func (s *TradingService) cancelSlices(ctx context.Context, tx *gorm.DB, parent *models.Order, cancelledBy string) error {
+	orderRepo := repository.NewOrderRepository(tx)
+	slices, err := orderRepo.GetByParentID(ctx, parent.ID)
+	if err != nil {
+		return fmt.Errorf("failed to get order slices: %w", err)
+	}
+
+	now := s.calendar.Now()
+	for _, slice := range slices {
+		if isTerminalStatus(slice.Status) {
+			continue
+		}
+
+		holdsReservation := s.holdsReservation(slice)
+
+		slice.Status = models.OrderStatusCancelled
+		slice.CancelledAt = &now
+		slice.CancelledBy = cancelledBy
+		if err := orderRepo.Update(ctx, slice); err != nil {
+			return fmt.Errorf("failed to cancel slice: %w", err)
+		}
+		s.emitOrderEvent(tx, OrderCancelled, slice, nil)
+		s.untrackAfterCommit(tx, slice)
+
+		if holdsReservation {
+			if err := s.releaseReservation(ctx, tx, slice); err != nil {
+				return err
+			}
+		}
+	}
+
+	parent.CancelledAt = &now
+	parent.CancelledBy = cancelledBy
+	return s.aggregateSlices(ctx, tx, parent)
+}
@@ -0,0 +1,293 @@
+// stock-trading-app/backend/internal/services/replay_feed.go
+
+package services
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/shyamanurag/stock-trading-app/backend/internal/models"
+)
+
+// errReplayPlayed is returned when connecting a replay that has already run
+var errReplayPlayed = errors.New("replay has already been played")
+
+// ReplayConfig selects a recorded session to replay instead of the live feed
+type ReplayConfig struct {
+	File  string  // Replay file written by the market data recorder
+	Speed float64 // 1 plays in real time, N at N times speed, 0 as fast as possible
+}
+
+// ReplayFeed is a market data feed that plays back a replay file. It drives
+// a virtual clock that is set to each event's time before the event is
+// delivered; services reading the time from a calendar on that clock run
+// on the replayed day, so sessions, expiries and order timestamps behave
+// as they did when the file was recorded. Every recorded instrument is
+// played whether or not it is subscribed. Replays should run without a
+// time series store so recorded ticks are not stored twice.
+type ReplayFeed struct {
+	config      ReplayConfig
+	clock       *VirtualClock
+	symbols     []models.Symbol
+	indices     []models.MarketIndex
+	quotes      map[string]*models.MarketQuote
+	depths      map[string]*models.MarketDepth
+	onQuote     func(quote *models.MarketQuote)
+	onDepth     func(depth *models.MarketDepth)
+	isConnected bool
+	played      bool
+	done        chan struct{}
+	finished    chan struct{}
+	mutex       sync.RWMutex
+}
+
+// NewReplayFeed creates a feed for a replay file. The file's leading
+// reference data is loaded and the clock is stopped at its first event
+// until the feed is connected.
+func NewReplayFeed(config ReplayConfig) (*ReplayFeed, error) {
+	if config.Speed < 0 {
+		return nil, fmt.Errorf("replay speed must not be negative")
+	}
+
+	reader, err := openReplayFile(config.File)
+	if err != nil {
+		return nil, err
+	}
+	defer reader.Close()
+
+	f := &ReplayFeed{
+		config:   config,
+		quotes:   make(map[string]*models.MarketQuote),
+		depths:   make(map[string]*models.MarketDepth),
+		finished: make(chan struct{}),
+	}
+
+	for {
+		event, err := reader.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		if f.clock == nil {
+			f.clock = NewVirtualClock(event.Time)
+		}
+		if event.Type != models.ReplayEventSymbols && event.Type != models.ReplayEventIndices {
+			break
+		}
+		f.apply(event)
+	}
+
+	if f.clock == nil {
+		return nil, fmt.Errorf("replay file %s has no events", config.File)
+	}
+	return f, nil
+}
+
+// Clock returns the virtual clock the replay drives
+func (f *ReplayFeed) Clock() *VirtualClock {
+	return f.clock
+}
+
+// Finished returns a channel that is closed when playback stops
+func (f *ReplayFeed) Finished() <-chan struct{} {
+	return f.finished
+}
+
+// OnUpdate sets the handlers the feed streams quotes and depth to
+func (f *ReplayFeed) OnUpdate(onQuote func(quote *models.MarketQuote), onDepth func(depth *models.MarketDepth)) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	f.onQuote = onQuote
+	f.onDepth = onDepth
+}
+
+// Connect starts playback. A replay plays once; it cannot be restarted
+// after it has been disconnected or has finished.
+func (f *ReplayFeed) Connect() error {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	if f.isConnected {
+		return nil
+	}
+	if f.played {
+		return errReplayPlayed
+	}
+
+	reader, err := openReplayFile(f.config.File)
+	if err != nil {
+		return err
+	}
+
+	f.isConnected = true
+	f.played = true
+	f.done = make(chan struct{})
+	go f.play(reader, f.done)
+	return nil
+}
+
+// Disconnect stops playback
+func (f *ReplayFeed) Disconnect() error {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	if !f.isConnected {
+		return nil
+	}
+	close(f.done)
+	f.isConnected = false
+	return nil
+}
+
+// IsConnected reports whether playback is running
+func (f *ReplayFeed) IsConnected() bool {
+	f.mutex.RLock()
+	defer f.mutex.RUnlock()
+	return f.isConnected
+}
+
+// Subscribe is a no-op; every recorded instrument is played
+func (f *ReplayFeed) Subscribe(symbol string, exchange string) error {
+	return nil
+}
+
+// Unsubscribe is a no-op; every recorded instrument is played
+func (f *ReplayFeed) Unsubscribe(symbol string, exchange string) error {
+	return nil
+}
+
+// play delivers the file's events paced by their times until the file
+// ends or done is closed. Pacing is anchored to the first event so delays
+// in delivery do not accumulate.
+func (f *ReplayFeed) play(reader *replayReader, done chan struct{}) {
+	defer close(f.finished)
+	defer reader.Close()
+
+	var firstAt, startedAt time.Time
+	for {
+		select {
+		case <-done:
+			return
+		default:
+		}
+
+		event, err := reader.Next()
+		if err == io.EOF {
+			log.Printf("Replay of %s finished at %s", f.config.File, f.clock.Now().Format(time.RFC3339))
+			break
+		}
+		if err != nil {
+			log.Printf("Replay of %s stopped: %v", f.config.File, err)
+			break
+		}
+
+		if f.config.Speed > 0 {
+			if firstAt.IsZero() {
+				firstAt, startedAt = event.Time, time.Now()
+			}
+			due := startedAt.Add(time.Duration(float64(event.Time.Sub(firstAt)) / f.config.Speed))
+			if wait := time.Until(due); wait > 0 {
+				select {
+				case <-done:
+					return
+				case <-time.After(wait):
+				}
+			}
+		}
+
+		f.clock.Set(event.Time)
+		f.apply(event)
+	}
+
+	f.mutex.Lock()
+	if f.isConnected {
+		close(f.done)
+		f.isConnected = false
+	}
+	f.mutex.Unlock()
+}
+
+// apply takes in an event's data and streams quotes and depth to the
+// handlers
+func (f *ReplayFeed) apply(event *models.ReplayEvent) {
+	f.mutex.Lock()
+	var onQuote func(quote *models.MarketQuote)
+	var onDepth func(depth *models.MarketDepth)
+	switch event.Type {
+	case models.ReplayEventSymbols:
+		f.symbols = event.Symbols
+	case models.ReplayEventIndices:
+		f.indices = event.Indices
+	case models.ReplayEventQuote:
+		f.quotes[instrumentKey(event.Quote.Exchange, event.Quote.Symbol)] = event.Quote
+		onQuote = f.onQuote
+	case models.ReplayEventDepth:
+		f.depths[instrumentKey(event.Depth.Exchange, event.Depth.Symbol)] = event.Depth
+		onDepth = f.onDepth
+	}
+	f.mutex.Unlock()
+
+	if onQuote != nil {
+		onQuote(event.Quote)
+	}
+	if onDepth != nil {
+		onDepth(event.Depth)
+	}
+}
+
+// FetchQuote returns the last quote replayed for a symbol
+func (f *ReplayFeed) FetchQuote(symbol string, exchange string) (*models.MarketQuote, error) {
+	f.mutex.RLock()
+	defer f.mutex.RUnlock()
+
+	quote, ok := f.quotes[instrumentKey(exchange, symbol)]
+	if !ok {
+		return nil, fmt.Errorf("no quote replayed for %s:%s", exchange, symbol)
+	}
+	return quote, nil
+}
+
+// FetchMarketDepth returns the last depth replayed for a symbol
+func (f *ReplayFeed) FetchMarketDepth(symbol string, exchange string) (*models.MarketDepth, error) {
+	f.mutex.RLock()
+	defer f.mutex.RUnlock()
+
+	depth, ok := f.depths[instrumentKey(exchange, symbol)]
+	if !ok {
+		return nil, fmt.Errorf("no market depth replayed for %s:%s", exchange, symbol)
+	}
+	return depth, nil
+}
+
+// FetchHistoricalData is not available from a replay
+func (f *ReplayFeed) FetchHistoricalData(symbol string, exchange string, interval string, startTime time.Time, endTime time.Time) (*models.HistoricalData, error) {
+	return nil, fmt.Errorf("historical data is not available in replay")
+}
+
+// FetchSymbols returns the recorded instrument master
+func (f *ReplayFeed) FetchSymbols() ([]models.Symbol, error) {
+	f.mutex.RLock()
+	defer f.mutex.RUnlock()
+
+	if f.symbols == nil {
+		return nil, fmt.Errorf("replay file %s has no symbols", f.config.File)
+	}
+	return f.symbols, nil
+}
+
+// FetchIndices returns the recorded market indices
+func (f *ReplayFeed) FetchIndices() ([]models.MarketIndex, error) {
+	f.mutex.RLock()
+	defer f.mutex.RUnlock()
+
+	if f.indices == nil {
+		return nil, fmt.Errorf("replay file %s has no indices", f.config.File)
+	}
+	return f.indices, nil
+}
@@ -0,0 +1,206 @@
+// stock-trading-app/backend/internal/services/replay_file.go
+
+package services
+
+import (
+	"bufio"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/shyamanurag/stock-trading-app/backend/internal/models"
+)
+
+// errReplayWriterClosed is returned when writing to a closed replay file
+var errReplayWriterClosed = errors.New("replay file is closed")
+
+// ReplayWriter writes market data events to a replay file in the format
+// described on models.ReplayEvent
+type ReplayWriter struct {
+	file    *os.File
+	gzip    *gzip.Writer
+	buffer  *bufio.Writer
+	encoder *json.Encoder
+	closed  bool
+	mutex   sync.Mutex
+}
+
+// CreateReplayFile creates a replay file at path, gzip-compressed if the
+// name ends in .gz. An existing file is truncated.
+func CreateReplayFile(path string) (*ReplayWriter, error) {
+	file, err := os.Create(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create replay file: %w", err)
+	}
+
+	w := &ReplayWriter{file: file}
+	var out io.Writer = file
+	if strings.HasSuffix(path, ".gz") {
+		w.gzip = gzip.NewWriter(file)
+		out = w.gzip
+	}
+	w.buffer = bufio.NewWriter(out)
+	w.encoder = json.NewEncoder(w.buffer)
+	return w, nil
+}
+
+// Write appends an event. Events without a time are stamped with the time
+// they are written, so concurrent writers still produce a file in time
+// order.
+func (w *ReplayWriter) Write(event *models.ReplayEvent) error {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+
+	if w.closed {
+		return errReplayWriterClosed
+	}
+	if event.Time.IsZero() {
+		event.Time = time.Now()
+	}
+	return w.encoder.Encode(event)
+}
+
+// Close flushes buffered events and closes the file
+func (w *ReplayWriter) Close() error {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+
+	if w.closed {
+		return nil
+	}
+	w.closed = true
+
+	if err := w.buffer.Flush(); err != nil {
+		w.file.Close()
+		return fmt.Errorf("failed to flush replay file: %w", err)
+	}
+	if w.gzip != nil {
+		if err := w.gzip.Close(); err != nil {
+			w.file.Close()
+			return fmt.Errorf("failed to finish replay file: %w", err)
+		}
+	}
+	return w.file.Close()
+}
+
+// replayReader reads events from a replay file in order
+type replayReader struct {
+	file    *os.File
+	gzip    *gzip.Reader
+	decoder *json.Decoder
+}
+
+// openReplayFile opens a replay file for reading
+func openReplayFile(path string) (*replayReader, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open replay file: %w", err)
+	}
+
+	r := &replayReader{file: file}
+	var in io.Reader = bufio.NewReader(file)
+	if strings.HasSuffix(path, ".gz") {
+		if r.gzip, err = gzip.NewReader(in); err != nil {
+			file.Close()
+			return nil, fmt.Errorf("failed to read replay file: %w", err)
+		}
+		in = r.gzip
+	}
+	r.decoder = json.NewDecoder(in)
+	return r, nil
+}
+
+// Next returns the next event, skipping unknown types. It returns io.EOF
+// at the end of the file.
+func (r *replayReader) Next() (*models.ReplayEvent, error) {
+	for {
+		var event models.ReplayEvent
+		if err := r.decoder.Decode(&event); err != nil {
+			if errors.Is(err, io.EOF) {
+				return nil, io.EOF
+			}
+			return nil, fmt.Errorf("failed to read replay event: %w", err)
+		}
+
+		switch event.Type {
+		case models.ReplayEventSymbols, models.ReplayEventIndices:
+			return &event, nil
+		case models.ReplayEventQuote:
+			if event.Quote != nil {
+				return &event, nil
+			}
+		case models.ReplayEventDepth:
+			if event.Depth != nil {
+				return &event, nil
+			}
+		}
+	}
+}
+
+// Close closes the file
+func (r *replayReader) Close() error {
+	if r.gzip != nil {
+		r.gzip.Close()
+	}
+	return r.file.Close()
+}
+
+// MarketDataRecorder records what the market data service receives to a
+// replay file: the reference data when it starts, then every quote and
+// depth update stamped with the time it arrived
+type MarketDataRecorder struct {
+	marketData MarketDataService
+	writer     *ReplayWriter
+}
+
+// NewMarketDataRecorder creates a new MarketDataRecorder
+func NewMarketDataRecorder(marketData MarketDataService, writer *ReplayWriter) *MarketDataRecorder {
+	return &MarketDataRecorder{
+		marketData: marketData,
+		writer:     writer,
+	}
+}
+
+// Start records until ctx is done, then closes the replay file
+func (r *MarketDataRecorder) Start(ctx context.Context) {
+	if symbols, err := r.marketData.GetSymbols(); err != nil {
+		log.Printf("Recording without symbols: %v", err)
+	} else {
+		r.write(&models.ReplayEvent{Type: models.ReplayEventSymbols, Symbols: symbols})
+	}
+	if indices, err := r.marketData.GetIndices(); err != nil {
+		log.Printf("Recording without indices: %v", err)
+	} else {
+		r.write(&models.ReplayEvent{Type: models.ReplayEventIndices, Indices: indices})
+	}
+
+	r.marketData.OnQuoteUpdate(func(quote *models.MarketQuote) {
+		r.write(&models.ReplayEvent{Type: models.ReplayEventQuote, Quote: quote})
+	})
+	r.marketData.OnDepthUpdate(func(depth *models.MarketDepth) {
+		r.write(&models.ReplayEvent{Type: models.ReplayEventDepth, Depth: depth})
+	})
+
+	go func() {
+		<-ctx.Done()
+		if err := r.writer.Close(); err != nil {
+			log.Printf("Failed to close market data recording: %v", err)
+		}
+	}()
+}
+
+// write records an event. Updates arriving after the recording stopped are
+// dropped.
+func (r *MarketDataRecorder) write(event *models.ReplayEvent) {
+	if err := r.writer.Write(event); err != nil && !errors.Is(err, errReplayWriterClosed) {
+		log.Printf("Failed to record %s event: %v", event.Type, err)
+	}
+}
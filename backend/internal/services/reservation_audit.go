@@ -0,0 +1,78 @@
+// stock-trading-app/backend/internal/services/reservation_audit.go
+
+package services
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/shyamanurag/stock-trading-app/backend/internal/models"
+	"github.com/shyamanurag/stock-trading-app/backend/internal/repository"
+)
+
+// holdBalanceTolerance absorbs floating point rounding when comparing a
+// wallet's HoldBalance with the funds its reservations and short positions
+// hold
+const holdBalanceTolerance = 0.01
+
+// ReservationAuditor checks the reservation ledger invariants: every
+// wallet's HoldBalance equals the funds held by its active reservations plus
+// the margin blocked on its short positions, and no closed order still holds
+// a reservation
+type ReservationAuditor struct {
+	reservationRepo *repository.ReservationRepository
+	calendar        *MarketCalendar
+}
+
+// NewReservationAuditor creates a new ReservationAuditor
+func NewReservationAuditor(reservationRepo *repository.ReservationRepository, calendar *MarketCalendar) *ReservationAuditor {
+	return &ReservationAuditor{
+		reservationRepo: reservationRepo,
+		calendar:        calendar,
+	}
+}
+
+// Check runs the invariant checks once
+func (a *ReservationAuditor) Check(ctx context.Context) (*models.ReservationAudit, error) {
+	mismatches, err := a.reservationRepo.FindHoldBalanceMismatches(ctx, holdBalanceTolerance)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check hold balances: %w", err)
+	}
+
+	orphaned, err := a.reservationRepo.FindOrphaned(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check orphaned reservations: %w", err)
+	}
+
+	return &models.ReservationAudit{
+		CheckedAt:             a.calendar.Now(),
+		HoldBalanceMismatches: mismatches,
+		OrphanedReservations:  orphaned,
+	}, nil
+}
+
+// Run checks the invariants every interval on the calendar's clock and logs
+// any violations until ctx is done
+func (a *ReservationAuditor) Run(ctx context.Context, interval time.Duration) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-a.calendar.After(interval):
+			audit, err := a.Check(ctx)
+			if err != nil {
+				log.Printf("Reservation audit failed: %v", err)
+				continue
+			}
+			for _, mismatch := range audit.HoldBalanceMismatches {
+				log.Printf("Hold balance mismatch for user %s: wallet holds %.2f, reservations hold %.2f, short margin %.2f",
+					mismatch.UserID, mismatch.HoldBalance, mismatch.Reserved, mismatch.MarginBlocked)
+			}
+			for _, reservation := range audit.OrphanedReservations {
+				log.Printf("Reservation %s is still active for closed order %s", reservation.ID, reservation.OrderID)
+			}
+		}
+	}
+}
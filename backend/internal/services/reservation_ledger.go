@@ -0,0 +1,151 @@
+// stock-trading-app/backend/internal/services/reservation_ledger.go
+
+package services
+
+import (
+	"context"
+	"fmt"
+	"math"
+
+	"github.com/google/uuid"
+	"github.com/shyamanurag/stock-trading-app/backend/internal/models"
+	"github.com/shyamanurag/stock-trading-app/backend/internal/repository"
+	"gorm.io/gorm"
+)
+
+// Every hold placed on a wallet or on holdings for an order is recorded as
+// an OrderReservation. Fills draw the reservation down by what they actually
+// cost, and cancellation or expiry releases exactly what is left, so the
+// wallet's HoldBalance always equals the sum of its active reservations.
+
+// recordReservation adds funds or securities to the reservation for an
+// order, creating it on first use
+func (s *TradingService) recordReservation(ctx context.Context, tx *gorm.DB, order *models.Order, amount float64, quantity int) error {
+	reservationRepo := repository.NewReservationRepository(tx)
+	reservation, err := reservationRepo.GetByOrderID(ctx, order.ID)
+	if err != nil {
+		return fmt.Errorf("failed to get reservation: %w", err)
+	}
+
+	if reservation == nil {
+		reservation = &models.OrderReservation{
+			ID:               uuid.New().String(),
+			OrderID:          order.ID,
+			UserID:           order.UserID,
+			Symbol:           order.Symbol,
+			Side:             order.Side,
+			Amount:           amount,
+			Currency:         "INR",
+			Quantity:         quantity,
+			ReservedAmount:   amount,
+			ReservedQuantity: quantity,
+			Status:           models.ReservationStatusActive,
+		}
+		if err := reservationRepo.Create(ctx, reservation); err != nil {
+			return fmt.Errorf("failed to create reservation: %w", err)
+		}
+		return nil
+	}
+
+	reservation.Amount += amount
+	reservation.Quantity += quantity
+	if amount > 0 {
+		reservation.ReservedAmount += amount
+	}
+	if quantity > 0 {
+		reservation.ReservedQuantity += quantity
+	}
+	reservation.Status = models.ReservationStatusActive
+	if err := reservationRepo.Update(ctx, reservation); err != nil {
+		return fmt.Errorf("failed to update reservation: %w", err)
+	}
+	return nil
+}
+
+// reservationFor returns the reservation backing an order, or nil for orders
+// placed before the ledger existed. Bracket target legs share the
+// reservation of their stop-loss sibling.
+func (s *TradingService) reservationFor(ctx context.Context, tx *gorm.DB, order *models.Order) (*models.OrderReservation, error) {
+	ownerID := order.ID
+	if order.ParentOrderID != nil && isBracketVariety(order) && order.Type == models.OrderTypeLimit {
+		siblings, err := repository.NewOrderRepository(tx).GetByParentID(ctx, *order.ParentOrderID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get sibling legs: %w", err)
+		}
+		for _, sibling := range siblings {
+			if sibling.ID != order.ID && sibling.TriggerPrice != nil {
+				ownerID = sibling.ID
+				break
+			}
+		}
+	}
+
+	reservation, err := repository.NewReservationRepository(tx).GetByOrderID(ctx, ownerID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get reservation: %w", err)
+	}
+	return reservation, nil
+}
+
+// consumeReservation draws a fill of quantity costing amount out of the
+// reservation backing an order. It returns the funds taken from the hold,
+// which is less than amount when a market order fills above its buffered
+// estimate, and the funds left over once the order has completed, which the
+// caller must return to the available balance.
+func (s *TradingService) consumeReservation(ctx context.Context, tx *gorm.DB, order *models.Order, amount float64, quantity int) (float64, float64, error) {
+	reservation, err := s.reservationFor(ctx, tx, order)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	// Orders placed before the ledger existed settle against the hold as
+	// they always have
+	if reservation == nil {
+		return amount, 0, nil
+	}
+
+	consumed := math.Min(amount, reservation.Amount)
+	reservation.Amount -= consumed
+	reservation.Quantity -= quantity
+	if reservation.Quantity < 0 {
+		reservation.Quantity = 0
+	}
+
+	var leftover float64
+	if order.Status == models.OrderStatusCompleted {
+		leftover = reservation.Amount
+		reservation.Amount = 0
+		reservation.Quantity = 0
+		reservation.Status = models.ReservationStatusConsumed
+	}
+
+	if err := repository.NewReservationRepository(tx).Update(ctx, reservation); err != nil {
+		return 0, 0, fmt.Errorf("failed to update reservation: %w", err)
+	}
+	return consumed, leftover, nil
+}
+
+// closeReservation releases everything still held by an order's reservation
+// and returns the funds and securities that were released. ok is false if
+// the order has no reservation in the ledger.
+func (s *TradingService) closeReservation(ctx context.Context, tx *gorm.DB, order *models.Order) (float64, int, bool, error) {
+	reservation, err := s.reservationFor(ctx, tx, order)
+	if err != nil {
+		return 0, 0, false, err
+	}
+	if reservation == nil {
+		return 0, 0, false, nil
+	}
+	if reservation.Status != models.ReservationStatusActive {
+		return 0, 0, true, nil
+	}
+
+	amount, quantity := reservation.Amount, reservation.Quantity
+	reservation.Amount = 0
+	reservation.Quantity = 0
+	reservation.Status = models.ReservationStatusReleased
+	if err := repository.NewReservationRepository(tx).Update(ctx, reservation); err != nil {
+		return 0, 0, false, fmt.Errorf("failed to update reservation: %w", err)
+	}
+	return amount, quantity, true, nil
+}
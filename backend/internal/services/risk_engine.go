@@ -0,0 +1,190 @@
+// stock-trading-app/backend/internal/services/risk_engine.go
+
+package services
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/google/uuid"
+	"github.com/shyamanurag/stock-trading-app/backend/internal/models"
+	"github.com/shyamanurag/stock-trading-app/backend/internal/repository"
+)
+
+// RiskCheck is everything a risk rule needs to judge an order
+type RiskCheck struct {
+	Order     *models.Order
+	Limits    models.RiskLimits
+	Symbol    *models.Symbol      // nil if the instrument master has no entry
+	Quote     *models.MarketQuote // nil if no quote is available
+	Price     float64             // Price the order is expected to trade at
+	Amendment bool                // The order is already open and being modified
+}
+
+// RiskRule is one pre-trade check. Check returns nil if the order passes.
+type RiskRule interface {
+	Name() string
+	Check(ctx context.Context, check *RiskCheck) (*models.RiskRejection, error)
+}
+
+// DefaultRiskLimits returns the limits for each risk profile
+func DefaultRiskLimits() map[string]models.RiskLimits {
+	return map[string]models.RiskLimits{
+		models.RiskProfileConservative: {
+			MaxOrderValue:            200000,
+			MaxOrderQuantity:         5000,
+			MaxPriceDeviationPercent: 5,
+			MaxDailyLoss:             10000,
+			MaxOpenOrders:            20,
+		},
+		models.RiskProfileModerate: {
+			MaxOrderValue:            1000000,
+			MaxOrderQuantity:         25000,
+			MaxPriceDeviationPercent: 10,
+			MaxDailyLoss:             50000,
+			MaxOpenOrders:            50,
+		},
+		models.RiskProfileAggressive: {
+			MaxOrderValue:            5000000,
+			MaxOrderQuantity:         100000,
+			MaxPriceDeviationPercent: 20,
+			MaxDailyLoss:             200000,
+			MaxOpenOrders:            200,
+		},
+	}
+}
+
+// RiskEngine runs a chain of pre-trade risk rules in front of order
+// placement, with limits chosen by the user's risk profile
+type RiskEngine struct {
+	rules      []RiskRule
+	limits     map[string]models.RiskLimits
+	userRepo   *repository.UserRepository
+	marketData MarketDataService
+	mutex      sync.RWMutex
+}
+
+// NewRiskEngine creates a RiskEngine with the default limits and no rules
+func NewRiskEngine(userRepo *repository.UserRepository, marketData MarketDataService) *RiskEngine {
+	return &RiskEngine{
+		limits:     DefaultRiskLimits(),
+		userRepo:   userRepo,
+		marketData: marketData,
+	}
+}
+
+// NewDefaultRiskEngine creates a RiskEngine with the standard rule chain
+func NewDefaultRiskEngine(
+	userRepo *repository.UserRepository,
+	orderRepo *repository.OrderRepository,
+	positionRepo *repository.PositionRepository,
+	calendar *MarketCalendar,
+	marketData MarketDataService,
+	killSwitch *KillSwitch,
+) *RiskEngine {
+	e := NewRiskEngine(userRepo, marketData)
+	e.Use(&TradingPermittedRule{})
+	e.Use(&OrderQuantityRule{})
+	e.Use(&OrderValueRule{})
+	e.Use(&PriceBandRule{})
+	e.Use(&PriceDeviationRule{})
+	e.Use(NewDailyLossRule(positionRepo, calendar, killSwitch))
+	e.Use(NewOpenOrdersRule(orderRepo))
+	return e
+}
+
+// Use appends a rule to the chain
+func (e *RiskEngine) Use(rule RiskRule) {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+	e.rules = append(e.rules, rule)
+}
+
+// SetLimits sets the limits for a risk profile
+func (e *RiskEngine) SetLimits(profile string, limits models.RiskLimits) {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+	e.limits[profile] = limits
+}
+
+// LimitsFor returns the limits for a user's risk profile, falling back to
+// the moderate profile
+func (e *RiskEngine) LimitsFor(ctx context.Context, userID string) (models.RiskLimits, error) {
+	profile := models.RiskProfileModerate
+
+	if id, err := uuid.Parse(userID); err == nil && e.userRepo != nil {
+		user, err := e.userRepo.GetByID(ctx, id)
+		if err != nil {
+			return models.RiskLimits{}, fmt.Errorf("failed to get user: %w", err)
+		}
+		if user != nil && user.RiskProfile != "" {
+			profile = user.RiskProfile
+		}
+	}
+
+	e.mutex.RLock()
+	defer e.mutex.RUnlock()
+	if limits, ok := e.limits[profile]; ok {
+		return limits, nil
+	}
+	return e.limits[models.RiskProfileModerate], nil
+}
+
+// Check runs every rule against an order. The first rule to object returns
+// its rejection as the error.
+func (e *RiskEngine) Check(ctx context.Context, order *models.Order) error {
+	return e.check(ctx, order, false)
+}
+
+// CheckAmendment runs every rule against an open order as it would stand
+// after a modification
+func (e *RiskEngine) CheckAmendment(ctx context.Context, order *models.Order) error {
+	return e.check(ctx, order, true)
+}
+
+// check runs every rule against an order
+func (e *RiskEngine) check(ctx context.Context, order *models.Order, amendment bool) error {
+	limits, err := e.LimitsFor(ctx, order.UserID)
+	if err != nil {
+		return err
+	}
+
+	check := &RiskCheck{
+		Order:     order,
+		Limits:    limits,
+		Amendment: amendment,
+	}
+	if symbol, err := e.marketData.GetSymbol(order.Symbol, order.Exchange); err == nil {
+		check.Symbol = symbol
+	}
+	if quote, err := e.marketData.GetQuote(order.Symbol, order.Exchange); err == nil {
+		check.Quote = quote
+	}
+
+	switch {
+	case order.Price != nil:
+		check.Price = *order.Price
+	case order.TriggerPrice != nil:
+		check.Price = *order.TriggerPrice
+	case check.Quote != nil:
+		check.Price = check.Quote.LastPrice
+	}
+
+	e.mutex.RLock()
+	rules := make([]RiskRule, len(e.rules))
+	copy(rules, e.rules)
+	e.mutex.RUnlock()
+
+	for _, rule := range rules {
+		rejection, err := rule.Check(ctx, check)
+		if err != nil {
+			return fmt.Errorf("risk check %s failed: %w", rule.Name(), err)
+		}
+		if rejection != nil {
+			return rejection
+		}
+	}
+
+	return nil
+}
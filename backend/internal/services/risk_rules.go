@@ -0,0 +1,270 @@
+// stock-trading-app/backend/internal/services/risk_rules.go
+
+package services
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"math"
+
+	"github.com/shyamanurag/stock-trading-app/backend/internal/models"
+	"github.com/shyamanurag/stock-trading-app/backend/internal/repository"
+)
+
+// TradingPermittedRule rejects orders in instruments that are suspended or
+// otherwise closed for trading
+type TradingPermittedRule struct{}
+
+// Name returns the rule name
+func (r *TradingPermittedRule) Name() string { return "TRADING_PERMITTED" }
+
+// Check applies the rule
+func (r *TradingPermittedRule) Check(ctx context.Context, check *RiskCheck) (*models.RiskRejection, error) {
+	if check.Symbol != nil && !check.Symbol.TradingPermitted {
+		return &models.RiskRejection{
+			Rule:   r.Name(),
+			Reason: fmt.Sprintf("trading in %s is not permitted", check.Order.Symbol),
+		}, nil
+	}
+	return nil, nil
+}
+
+// OrderQuantityRule enforces the exchange freeze quantity, the instrument's
+// maximum order size and the user's maximum order quantity. Sliced orders
+// are already split within the instrument limits.
+type OrderQuantityRule struct{}
+
+// Name returns the rule name
+func (r *OrderQuantityRule) Name() string { return "ORDER_QUANTITY" }
+
+// Check applies the rule
+func (r *OrderQuantityRule) Check(ctx context.Context, check *RiskCheck) (*models.RiskRejection, error) {
+	quantity := check.Order.Quantity
+
+	if check.Symbol != nil && check.Order.SliceCount == 0 {
+		if check.Symbol.FreezeQty > 0 && quantity > check.Symbol.FreezeQty {
+			return &models.RiskRejection{
+				Rule:   r.Name(),
+				Reason: fmt.Sprintf("quantity %d exceeds the freeze quantity %d", quantity, check.Symbol.FreezeQty),
+				Limit:  float64(check.Symbol.FreezeQty),
+				Actual: float64(quantity),
+			}, nil
+		}
+		if check.Symbol.MaxOrderSize > 0 && quantity > check.Symbol.MaxOrderSize {
+			return &models.RiskRejection{
+				Rule:   r.Name(),
+				Reason: fmt.Sprintf("quantity %d exceeds the maximum order size %d", quantity, check.Symbol.MaxOrderSize),
+				Limit:  float64(check.Symbol.MaxOrderSize),
+				Actual: float64(quantity),
+			}, nil
+		}
+	}
+
+	if limit := check.Limits.MaxOrderQuantity; limit > 0 && quantity > limit {
+		return &models.RiskRejection{
+			Rule:   r.Name(),
+			Reason: fmt.Sprintf("quantity %d exceeds your limit of %d", quantity, limit),
+			Limit:  float64(limit),
+			Actual: float64(quantity),
+		}, nil
+	}
+
+	return nil, nil
+}
+
+// OrderValueRule caps the notional value of a single order
+type OrderValueRule struct{}
+
+// Name returns the rule name
+func (r *OrderValueRule) Name() string { return "ORDER_VALUE" }
+
+// Check applies the rule
+func (r *OrderValueRule) Check(ctx context.Context, check *RiskCheck) (*models.RiskRejection, error) {
+	limit := check.Limits.MaxOrderValue
+	value := check.Price * float64(check.Order.Quantity)
+	if limit > 0 && value > limit {
+		return &models.RiskRejection{
+			Rule:   r.Name(),
+			Reason: fmt.Sprintf("order value %.2f exceeds your limit of %.2f", value, limit),
+			Limit:  limit,
+			Actual: value,
+		}, nil
+	}
+	return nil, nil
+}
+
+// PriceBandRule rejects limit and trigger prices outside the day's circuit
+// limits
+type PriceBandRule struct{}
+
+// Name returns the rule name
+func (r *PriceBandRule) Name() string { return "PRICE_BAND" }
+
+// Check applies the rule
+func (r *PriceBandRule) Check(ctx context.Context, check *RiskCheck) (*models.RiskRejection, error) {
+	if check.Quote == nil {
+		return nil, nil
+	}
+
+	lower, upper := check.Quote.LowerCircuit, check.Quote.UpperCircuit
+	for _, price := range []*float64{check.Order.Price, check.Order.TriggerPrice} {
+		if price == nil {
+			continue
+		}
+		if lower > 0 && *price < lower {
+			return &models.RiskRejection{
+				Rule:   r.Name(),
+				Reason: fmt.Sprintf("price %.2f is below the lower circuit %.2f", *price, lower),
+				Limit:  lower,
+				Actual: *price,
+			}, nil
+		}
+		if upper > 0 && *price > upper {
+			return &models.RiskRejection{
+				Rule:   r.Name(),
+				Reason: fmt.Sprintf("price %.2f is above the upper circuit %.2f", *price, upper),
+				Limit:  upper,
+				Actual: *price,
+			}, nil
+		}
+	}
+
+	return nil, nil
+}
+
+// PriceDeviationRule catches fat-finger limit prices that are too far from
+// the last traded price
+type PriceDeviationRule struct{}
+
+// Name returns the rule name
+func (r *PriceDeviationRule) Name() string { return "PRICE_DEVIATION" }
+
+// Check applies the rule
+func (r *PriceDeviationRule) Check(ctx context.Context, check *RiskCheck) (*models.RiskRejection, error) {
+	limit := check.Limits.MaxPriceDeviationPercent
+	if limit <= 0 || check.Order.Price == nil || check.Quote == nil || check.Quote.LastPrice <= 0 {
+		return nil, nil
+	}
+
+	lastPrice := check.Quote.LastPrice
+	deviation := math.Abs(*check.Order.Price-lastPrice) / lastPrice * 100
+	if deviation > limit {
+		return &models.RiskRejection{
+			Rule:   r.Name(),
+			Reason: fmt.Sprintf("price %.2f is %.2f%% away from the last price %.2f", *check.Order.Price, deviation, lastPrice),
+			Limit:  limit,
+			Actual: deviation,
+		}, nil
+	}
+	return nil, nil
+}
+
+// DailyLossRule blocks new orders once a user's realised intraday loss for
+// the day reaches their limit. With a kill switch it also halts the user
+// for the rest of the day and cancels their open orders.
+type DailyLossRule struct {
+	positionRepo *repository.PositionRepository
+	calendar     *MarketCalendar
+	killSwitch   *KillSwitch
+}
+
+// NewDailyLossRule creates a new DailyLossRule. killSwitch may be nil.
+func NewDailyLossRule(positionRepo *repository.PositionRepository, calendar *MarketCalendar, killSwitch *KillSwitch) *DailyLossRule {
+	return &DailyLossRule{
+		positionRepo: positionRepo,
+		calendar:     calendar,
+		killSwitch:   killSwitch,
+	}
+}
+
+// Name returns the rule name
+func (r *DailyLossRule) Name() string { return "DAILY_LOSS" }
+
+// Check applies the rule
+func (r *DailyLossRule) Check(ctx context.Context, check *RiskCheck) (*models.RiskRejection, error) {
+	limit := check.Limits.MaxDailyLoss
+	if limit <= 0 {
+		return nil, nil
+	}
+
+	positions, err := r.positionRepo.GetByUserID(ctx, check.Order.UserID, r.calendar.midnight(r.calendar.Now()))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get positions: %w", err)
+	}
+
+	var realised float64
+	for _, position := range positions {
+		realised += position.RealisedPL
+	}
+
+	if -realised >= limit {
+		rejection := &models.RiskRejection{
+			Rule:   r.Name(),
+			Reason: fmt.Sprintf("realised loss today %.2f has reached your limit of %.2f", -realised, limit),
+			Limit:  limit,
+			Actual: -realised,
+		}
+		r.haltUser(ctx, check.Order.UserID, rejection)
+		return rejection, nil
+	}
+	return nil, nil
+}
+
+// haltUser halts a user who reached their loss limit until the next day
+func (r *DailyLossRule) haltUser(ctx context.Context, userID string, rejection *models.RiskRejection) {
+	if r.killSwitch == nil {
+		return
+	}
+
+	until := r.calendar.midnight(r.calendar.Now()).AddDate(0, 0, 1)
+	_, err := r.killSwitch.HaltAsSystem(ctx, &models.HaltRequest{
+		Scope:            models.HaltScopeUser,
+		UserID:           userID,
+		Reason:           rejection.Reason,
+		Until:            &until,
+		CancelOpenOrders: true,
+	})
+	if err != nil {
+		log.Printf("Failed to halt user %s after reaching the daily loss limit: %v", userID, err)
+	}
+}
+
+// OpenOrdersRule caps how many orders a user can have working at once. An
+// amended order is already counted, so amendments always pass.
+type OpenOrdersRule struct {
+	orderRepo *repository.OrderRepository
+}
+
+// NewOpenOrdersRule creates a new OpenOrdersRule
+func NewOpenOrdersRule(orderRepo *repository.OrderRepository) *OpenOrdersRule {
+	return &OpenOrdersRule{
+		orderRepo: orderRepo,
+	}
+}
+
+// Name returns the rule name
+func (r *OpenOrdersRule) Name() string { return "OPEN_ORDERS" }
+
+// Check applies the rule
+func (r *OpenOrdersRule) Check(ctx context.Context, check *RiskCheck) (*models.RiskRejection, error) {
+	limit := check.Limits.MaxOpenOrders
+	if limit <= 0 || check.Amendment {
+		return nil, nil
+	}
+
+	count, err := r.orderRepo.CountOpenByUserID(ctx, check.Order.UserID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to count open orders: %w", err)
+	}
+
+	if int(count) >= limit {
+		return &models.RiskRejection{
+			Rule:   r.Name(),
+			Reason: fmt.Sprintf("you already have %d open orders, the limit is %d", count, limit),
+			Limit:  float64(limit),
+			Actual: float64(count),
+		}, nil
+	}
+	return nil, nil
+}
@@ -0,0 +1,338 @@
+// stock-trading-app/backend/internal/services/short_selling.go
+
+package services
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"math"
+	"time"
+
+	"github.com/shyamanurag/stock-trading-app/backend/internal/models"
+	"github.com/shyamanurag/stock-trading-app/backend/internal/repository"
+	"gorm.io/gorm"
+)
+
+// An intraday (MIS) sell larger than the open long position sells the rest
+// short. The short part holds a margin on the wallet instead of position
+// quantity; once it fills the margin moves onto the position, where it is
+// marked to market against live quotes until the short is bought back.
+// Delivery sells are always limited to the user's holdings.
+
+const (
+	// shortMarginRate is the share of a short's value blocked as margin
+	shortMarginRate = 0.20
+
+	// shortMarkInterval is how often open shorts are marked to market
+	shortMarkInterval = 5 * time.Second
+)
+
+// shortMargin returns the margin to block for quantity sold short at price
+func shortMargin(quantity int, price float64) float64 {
+	return roundPaise(float64(quantity) * price * shortMarginRate)
+}
+
+// reserveIntradaySell holds quantity of the open long position for the part
+// of an intraday sell that closes it, and margin for the part sold short
+func (s *TradingService) reserveIntradaySell(ctx context.Context, tx *gorm.DB, order *models.Order) error {
+	position, err := s.getOrCreatePosition(ctx, tx, order)
+	if err != nil {
+		return err
+	}
+
+	exitQty := minInt(order.RemainingQty, maxInt(position.Quantity-position.ReservedQuantity, 0))
+	if exitQty > 0 {
+		if err := s.reservePositionQuantity(ctx, tx, order, exitQty); err != nil {
+			return err
+		}
+	}
+
+	var margin float64
+	if shortQty := order.RemainingQty - exitQty; shortQty > 0 {
+		price, err := s.estimatedPrice(ctx, order)
+		if err != nil {
+			return err
+		}
+		margin = shortMargin(shortQty, price)
+		if err := s.holdFunds(ctx, tx, order.UserID, margin); err != nil {
+			return fmt.Errorf("insufficient margin to sell %d short: %w", shortQty, err)
+		}
+	}
+
+	return s.recordReservation(ctx, tx, order, margin, exitQty)
+}
+
+// shortFillQuantity returns how much of a sell fill opens or adds to a short.
+// Fills close the reserved long quantity first and sell short after that.
+func (s *TradingService) shortFillQuantity(ctx context.Context, tx *gorm.DB, order *models.Order, quantity int) (int, error) {
+	if !isIntraday(order) || order.Side != models.OrderSideSell {
+		return 0, nil
+	}
+
+	reservation, err := s.reservationFor(ctx, tx, order)
+	if err != nil {
+		return 0, err
+	}
+	if reservation == nil {
+		return 0, nil
+	}
+	return quantity - minInt(quantity, reservation.Quantity), nil
+}
+
+// blockShortMargin moves margin drawn from a filled short sale's reservation
+// onto the position it opened. The funds stay on hold.
+func (s *TradingService) blockShortMargin(ctx context.Context, tx *gorm.DB, order *models.Order, margin float64) error {
+	position, err := s.getOrCreatePosition(ctx, tx, order)
+	if err != nil {
+		return err
+	}
+
+	position.MarginBlocked += margin
+	if err := repository.NewPositionRepository(tx).Update(ctx, position); err != nil {
+		return fmt.Errorf("failed to update position: %w", err)
+	}
+	return nil
+}
+
+// releaseShortMargin releases the share of a short position's margin that
+// covered of its quantity no longer needs. The caller saves the position.
+func (s *TradingService) releaseShortMargin(ctx context.Context, tx *gorm.DB, position *models.Position, covered int) error {
+	if position.Quantity >= 0 || position.MarginBlocked <= 0 {
+		return nil
+	}
+
+	released := position.MarginBlocked
+	if covered < -position.Quantity {
+		released = roundPaise(position.MarginBlocked * float64(covered) / float64(-position.Quantity))
+	}
+	position.MarginBlocked -= released
+
+	return s.releaseFunds(ctx, tx, position.UserID, released)
+}
+
+// drawCoverMargin funds up to amount of an order buying back a short from the
+// margin blocked on the position, so a cover can always be placed while the
+// short is margined. It returns the amount drawn, which is already on hold.
+func (s *TradingService) drawCoverMargin(ctx context.Context, tx *gorm.DB, order *models.Order, amount float64) (float64, error) {
+	if !isIntraday(order) || order.Side != models.OrderSideBuy {
+		return 0, nil
+	}
+
+	position, err := s.getOrCreatePosition(ctx, tx, order)
+	if err != nil {
+		return 0, err
+	}
+	if position.Quantity >= 0 || position.MarginBlocked <= 0 {
+		return 0, nil
+	}
+
+	drawn := math.Min(amount, position.MarginBlocked)
+	position.MarginBlocked -= drawn
+	if err := repository.NewPositionRepository(tx).Update(ctx, position); err != nil {
+		return 0, fmt.Errorf("failed to update position: %w", err)
+	}
+	return drawn, nil
+}
+
+// MarkShortPosition marks a short position to market at lastPrice and tops
+// up or releases its margin to cover the short's value and any loss on it.
+// It returns the margin the wallet could not cover.
+func (s *TradingService) MarkShortPosition(ctx context.Context, position *models.Position, lastPrice float64) (float64, error) {
+	var shortfall float64
+	err := s.transactionMgr.WithTransaction(ctx, func(tx *gorm.DB) error {
+		positionRepo := repository.NewPositionRepository(tx)
+		current, err := positionRepo.Get(ctx, position.UserID, position.Exchange, position.Symbol, position.Product, position.TradingDate)
+		if err != nil {
+			return fmt.Errorf("failed to get position: %w", err)
+		}
+		if current == nil || current.Quantity >= 0 {
+			return nil
+		}
+
+		short := -current.Quantity
+		current.LastPrice = lastPrice
+		current.UnrealisedPL = roundPaise((current.AvgPrice - lastPrice) * float64(short))
+
+		required := shortMargin(short, lastPrice) + math.Max(-current.UnrealisedPL, 0)
+		switch delta := roundPaise(required - current.MarginBlocked); {
+		case delta > 0:
+			wallet, err := repository.NewWalletRepository(tx).GetByUserID(ctx, current.UserID)
+			if err != nil {
+				return fmt.Errorf("failed to get wallet: %w", err)
+			}
+			if wallet == nil {
+				return fmt.Errorf("wallet not found for user")
+			}
+			topUp := math.Min(delta, math.Max(wallet.Balance, 0))
+			shortfall = delta - topUp
+			if topUp > 0 {
+				if err := s.holdFunds(ctx, tx, current.UserID, topUp); err != nil {
+					return err
+				}
+				current.MarginBlocked += topUp
+			}
+		case delta < 0:
+			if err := s.releaseFunds(ctx, tx, current.UserID, -delta); err != nil {
+				return err
+			}
+			current.MarginBlocked += delta
+		}
+
+		if err := positionRepo.Update(ctx, current); err != nil {
+			return fmt.Errorf("failed to update position: %w", err)
+		}
+		*position = *current
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	return shortfall, nil
+}
+
+// holdFunds moves amount of a user's available balance onto hold
+func (s *TradingService) holdFunds(ctx context.Context, tx *gorm.DB, userID string, amount float64) error {
+	walletRepo := repository.NewWalletRepository(tx)
+	wallet, err := walletRepo.GetByUserID(ctx, userID)
+	if err != nil {
+		return fmt.Errorf("failed to get wallet: %w", err)
+	}
+
+	if wallet == nil {
+		return fmt.Errorf("wallet not found for user")
+	}
+
+	if wallet.Balance < amount {
+		return fmt.Errorf("insufficient funds: required %.2f, available %.2f", amount, wallet.Balance)
+	}
+
+	wallet.Balance -= amount
+	wallet.HoldBalance += amount
+	if err := walletRepo.Update(ctx, wallet); err != nil {
+		return fmt.Errorf("failed to update wallet: %w", err)
+	}
+	return nil
+}
+
+// releaseFunds moves amount of a user's held funds back to their available
+// balance
+func (s *TradingService) releaseFunds(ctx context.Context, tx *gorm.DB, userID string, amount float64) error {
+	if amount == 0 {
+		return nil
+	}
+
+	walletRepo := repository.NewWalletRepository(tx)
+	wallet, err := walletRepo.GetByUserID(ctx, userID)
+	if err != nil {
+		return fmt.Errorf("failed to get wallet: %w", err)
+	}
+
+	if wallet == nil {
+		return fmt.Errorf("wallet not found for user")
+	}
+
+	wallet.HoldBalance -= amount
+	wallet.Balance += amount
+	if err := walletRepo.Update(ctx, wallet); err != nil {
+		return fmt.Errorf("failed to update wallet: %w", err)
+	}
+	return nil
+}
+
+// maxInt returns the larger of a and b
+func maxInt(a int, b int) int {
+	if a > b {
+		return a
+	}
+	return b
+}
+
+// ShortMarginMonitor marks every open intraday short to market against live
+// quotes and tells users whose wallet cannot cover the margin. Shorts are
+// bought back by the intraday square-off before the close.
+type ShortMarginMonitor struct {
+	trading      *TradingService
+	positionRepo *repository.PositionRepository
+	marketData   MarketDataService
+	hub          *WebSocketHub
+	interval     time.Duration
+	alerted      map[string]bool
+}
+
+// NewShortMarginMonitor creates a new ShortMarginMonitor
+func NewShortMarginMonitor(
+	trading *TradingService,
+	positionRepo *repository.PositionRepository,
+	marketData MarketDataService,
+	hub *WebSocketHub,
+) *ShortMarginMonitor {
+	return &ShortMarginMonitor{
+		trading:      trading,
+		positionRepo: positionRepo,
+		marketData:   marketData,
+		hub:          hub,
+		interval:     shortMarkInterval,
+		alerted:      make(map[string]bool),
+	}
+}
+
+// Start marks shorts to market until ctx is done
+func (m *ShortMarginMonitor) Start(ctx context.Context) {
+	go m.run(ctx)
+}
+
+// run marks every open short once per interval on the calendar's clock
+func (m *ShortMarginMonitor) run(ctx context.Context) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-m.trading.calendar.After(m.interval):
+			if err := m.MarkToMarket(ctx); err != nil {
+				log.Printf("Short margin mark-to-market failed: %v", err)
+			}
+		}
+	}
+}
+
+// MarkToMarket marks every open intraday short at its latest quote
+func (m *ShortMarginMonitor) MarkToMarket(ctx context.Context) error {
+	positions, err := m.positionRepo.GetShort(ctx, models.ProductMIS, m.trading.tradingDate())
+	if err != nil {
+		return fmt.Errorf("failed to get short positions: %w", err)
+	}
+
+	for _, position := range positions {
+		quote, err := m.marketData.GetQuote(position.Symbol, position.Exchange)
+		if err != nil || quote == nil || quote.LastPrice <= 0 {
+			continue
+		}
+
+		shortfall, err := m.trading.MarkShortPosition(ctx, position, quote.LastPrice)
+		if err != nil {
+			log.Printf("Failed to mark short position %s to market: %v", position.ID, err)
+			continue
+		}
+
+		// Tell the user once each time their margin falls short
+		if shortfall <= 0 {
+			delete(m.alerted, position.ID)
+			continue
+		}
+		if m.alerted[position.ID] {
+			continue
+		}
+		m.alerted[position.ID] = true
+		if m.hub != nil {
+			m.hub.SendToUser(position.UserID, ServerMessage{
+				Type:      "margin_shortfall",
+				Data:      position,
+				Error:     fmt.Sprintf("Add %.2f to cover the margin on your short position in %s", shortfall, position.Symbol),
+				Timestamp: m.trading.calendar.Now().Unix(),
+			})
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,303 @@
+// stock-trading-app/backend/internal/services/stop_trigger_monitor.go
+
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/shyamanurag/stock-trading-app/backend/internal/models"
+)
+
+// errOrderNotTriggerable is returned when a stop order has already been
+// triggered, cancelled or otherwise left the PENDING state
+var errOrderNotTriggerable = errors.New("order is not a pending stop order")
+
+// QuoteFeed is a source of live quotes. MarketDataService satisfies it; tests
+// can supply a fake feed that pushes quotes directly.
+type QuoteFeed interface {
+	OnQuoteUpdate(callback func(quote *models.MarketQuote))
+}
+
+// StopOrderExecutor loads, trails and triggers stop orders on behalf of the
+// monitor
+type StopOrderExecutor interface {
+	PendingStopOrders(ctx context.Context) ([]*models.Order, error)
+	TriggerStopOrder(ctx context.Context, orderID string) error
+	TrailStopOrder(ctx context.Context, orderID string, triggerPrice float64, anchorPrice float64) error
+}
+
+// stopEntry is a stop order waiting for its trigger price. Trailing stops
+// also carry their trail and the best price seen so far.
+type stopEntry struct {
+	OrderID      string
+	Side         models.OrderSide
+	TriggerPrice float64
+	Trailing     bool
+	TrailAmount  float64
+	TrailPercent float64
+	Anchor       float64
+}
+
+// stopIndex holds the stop orders for one symbol. Buy stops fire when the
+// price rises to the trigger and are kept in ascending trigger order; sell
+// stops fire when the price falls to the trigger and are kept descending, so
+// triggered orders are always a prefix of each slice.
+type stopIndex struct {
+	buys  []*stopEntry
+	sells []*stopEntry
+}
+
+// StopTriggerMonitor watches live quotes and fires stop-loss, stop-limit and
+// trailing stop orders when the last traded price crosses their trigger.
+// Trailing stop triggers are ratcheted before each tick is checked, and
+// ticks older than the latest one applied for a symbol are dropped.
+type StopTriggerMonitor struct {
+	feed     QuoteFeed
+	executor StopOrderExecutor
+	clock    Clock
+	index    map[string]*stopIndex
+	symbols  map[string]string
+	quotedAt map[string]time.Time // Trade time of the latest quote applied per symbol
+	mutex    sync.Mutex
+}
+
+// NewStopTriggerMonitor creates a new StopTriggerMonitor
+func NewStopTriggerMonitor(feed QuoteFeed, executor StopOrderExecutor, clock Clock) *StopTriggerMonitor {
+	return &StopTriggerMonitor{
+		feed:     feed,
+		executor: executor,
+		clock:    clock,
+		index:    make(map[string]*stopIndex),
+		symbols:  make(map[string]string),
+		quotedAt: make(map[string]time.Time),
+	}
+}
+
+// Start rebuilds the index from pending stop orders and subscribes to quotes
+func (m *StopTriggerMonitor) Start(ctx context.Context) error {
+	orders, err := m.executor.PendingStopOrders(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to load pending stop orders: %w", err)
+	}
+
+	for _, order := range orders {
+		if err := m.Track(order); err != nil {
+			log.Printf("Skipping stop order %s while rebuilding index: %v", order.ID, err)
+		}
+	}
+
+	m.feed.OnQuoteUpdate(func(quote *models.MarketQuote) {
+		m.onQuote(context.Background(), quote)
+	})
+
+	return nil
+}
+
+// Track adds a pending stop order to the index
+func (m *StopTriggerMonitor) Track(order *models.Order) error {
+	if order.TriggerPrice == nil || *order.TriggerPrice <= 0 {
+		return fmt.Errorf("order %s has no trigger price", order.ID)
+	}
+
+	key := fmt.Sprintf("%s:%s", order.Exchange, order.Symbol)
+	entry := &stopEntry{
+		OrderID:      order.ID,
+		Side:         order.Side,
+		TriggerPrice: *order.TriggerPrice,
+	}
+
+	if order.Type == models.OrderTypeTrailingStop {
+		if order.TrailAnchor == nil || *order.TrailAnchor <= 0 {
+			return fmt.Errorf("trailing stop %s has no anchor price", order.ID)
+		}
+		entry.Trailing = true
+		entry.Anchor = *order.TrailAnchor
+		if order.TrailAmount != nil {
+			entry.TrailAmount = *order.TrailAmount
+		}
+		if order.TrailPercent != nil {
+			entry.TrailPercent = *order.TrailPercent
+		}
+	}
+
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	m.insert(key, entry)
+	return nil
+}
+
+// Untrack removes an order from the index. It returns false if the order was
+// not being tracked.
+func (m *StopTriggerMonitor) Untrack(orderID string) bool {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	key, ok := m.symbols[orderID]
+	if !ok {
+		return false
+	}
+	delete(m.symbols, orderID)
+
+	idx := m.index[key]
+	for i, entry := range idx.buys {
+		if entry.OrderID == orderID {
+			idx.buys = append(idx.buys[:i], idx.buys[i+1:]...)
+			return true
+		}
+	}
+	for i, entry := range idx.sells {
+		if entry.OrderID == orderID {
+			idx.sells = append(idx.sells[:i], idx.sells[i+1:]...)
+			return true
+		}
+	}
+	return true
+}
+
+// insert places an entry at its trigger position. Callers must hold the mutex.
+func (m *StopTriggerMonitor) insert(key string, entry *stopEntry) {
+	idx, ok := m.index[key]
+	if !ok {
+		idx = &stopIndex{}
+		m.index[key] = idx
+	}
+	m.symbols[entry.OrderID] = key
+
+	if entry.Side == models.OrderSideBuy {
+		i := sort.Search(len(idx.buys), func(i int) bool {
+			return idx.buys[i].TriggerPrice > entry.TriggerPrice
+		})
+		idx.buys = append(idx.buys, nil)
+		copy(idx.buys[i+1:], idx.buys[i:])
+		idx.buys[i] = entry
+		return
+	}
+
+	i := sort.Search(len(idx.sells), func(i int) bool {
+		return idx.sells[i].TriggerPrice < entry.TriggerPrice
+	})
+	idx.sells = append(idx.sells, nil)
+	copy(idx.sells[i+1:], idx.sells[i:])
+	idx.sells[i] = entry
+}
+
+// apply ratchets the trailing stops for a symbol and takes the entries
+// crossed by a tick's price. A tick older than the latest one applied for
+// the symbol changes nothing.
+func (m *StopTriggerMonitor) apply(key string, quotedAt time.Time, price float64) ([]stopEntry, []*stopEntry) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	if quotedAt.Before(m.quotedAt[key]) {
+		return nil, nil
+	}
+	m.quotedAt[key] = quotedAt
+	return m.ratchet(key, price), m.takeTriggered(key, price)
+}
+
+// ratchet moves the trailing stops for a symbol along with a favourable
+// price: sell stops follow new highs up and buy stops follow new lows down.
+// Triggers never move back. It returns copies of the entries that moved.
+// Callers must hold the mutex.
+func (m *StopTriggerMonitor) ratchet(key string, price float64) []stopEntry {
+	idx, ok := m.index[key]
+	if !ok {
+		return nil
+	}
+
+	var moved []stopEntry
+	for _, entry := range idx.sells {
+		if !entry.Trailing || price <= entry.Anchor {
+			continue
+		}
+		entry.Anchor = price
+		if trigger := trailingTrigger(entry.Side, price, entry.TrailAmount, entry.TrailPercent); trigger > entry.TriggerPrice {
+			entry.TriggerPrice = trigger
+			moved = append(moved, *entry)
+		}
+	}
+	for _, entry := range idx.buys {
+		if !entry.Trailing || price >= entry.Anchor {
+			continue
+		}
+		entry.Anchor = price
+		if trigger := trailingTrigger(entry.Side, price, entry.TrailAmount, entry.TrailPercent); trigger < entry.TriggerPrice {
+			entry.TriggerPrice = trigger
+			moved = append(moved, *entry)
+		}
+	}
+
+	if len(moved) > 0 {
+		sort.SliceStable(idx.buys, func(i, j int) bool {
+			return idx.buys[i].TriggerPrice < idx.buys[j].TriggerPrice
+		})
+		sort.SliceStable(idx.sells, func(i, j int) bool {
+			return idx.sells[i].TriggerPrice > idx.sells[j].TriggerPrice
+		})
+	}
+	return moved
+}
+
+// takeTriggered removes and returns every entry crossed by price. Callers
+// must hold the mutex.
+func (m *StopTriggerMonitor) takeTriggered(key string, price float64) []*stopEntry {
+	idx, ok := m.index[key]
+	if !ok {
+		return nil
+	}
+
+	var triggered []*stopEntry
+
+	n := sort.Search(len(idx.buys), func(i int) bool {
+		return idx.buys[i].TriggerPrice > price
+	})
+	triggered = append(triggered, idx.buys[:n]...)
+	idx.buys = idx.buys[n:]
+
+	n = sort.Search(len(idx.sells), func(i int) bool {
+		return idx.sells[i].TriggerPrice < price
+	})
+	triggered = append(triggered, idx.sells[:n]...)
+	idx.sells = idx.sells[n:]
+
+	for _, entry := range triggered {
+		delete(m.symbols, entry.OrderID)
+	}
+	return triggered
+}
+
+// onQuote fires every stop order crossed by the quote's last price
+func (m *StopTriggerMonitor) onQuote(ctx context.Context, quote *models.MarketQuote) {
+	if quote.LastPrice <= 0 {
+		return
+	}
+
+	key := fmt.Sprintf("%s:%s", quote.Exchange, quote.Symbol)
+	moved, triggered := m.apply(key, quoteTime(quote, m.clock), quote.LastPrice)
+
+	for _, entry := range moved {
+		err := m.executor.TrailStopOrder(ctx, entry.OrderID, entry.TriggerPrice, entry.Anchor)
+		if err != nil && !errors.Is(err, errOrderNotTriggerable) {
+			log.Printf("Failed to trail stop order %s: %v", entry.OrderID, err)
+		}
+	}
+
+	for _, entry := range triggered {
+		err := m.executor.TriggerStopOrder(ctx, entry.OrderID)
+		if err == nil || errors.Is(err, errOrderNotTriggerable) {
+			continue
+		}
+
+		// Put the order back so the next tick retries it
+		log.Printf("Failed to trigger stop order %s: %v", entry.OrderID, err)
+		m.mutex.Lock()
+		m.insert(key, entry)
+		m.mutex.Unlock()
+	}
+}
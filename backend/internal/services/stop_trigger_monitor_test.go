@@ -0,0 +1,117 @@
+// stock-trading-app/backend/internal/services/stop_trigger_monitor_test.go
+
+package services
+
+import (
+	"context"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/shyamanurag/stock-trading-app/backend/internal/models"
+)
+
+// pushQuoteFeed hands quotes straight to the subscribed callback
+type pushQuoteFeed struct {
+	callback func(quote *models.MarketQuote)
+}
+
+func (f *pushQuoteFeed) OnQuoteUpdate(callback func(quote *models.MarketQuote)) {
+	f.callback = callback
+}
+
+// recordingStopExecutor serves fixed pending orders and records the
+// triggers and trails the monitor asks for
+type recordingStopExecutor struct {
+	pending   []*models.Order
+	triggered []string
+	trailed   map[string]float64
+	mutex     sync.Mutex
+}
+
+func (e *recordingStopExecutor) PendingStopOrders(ctx context.Context) ([]*models.Order, error) {
+	return e.pending, nil
+}
+
+func (e *recordingStopExecutor) TriggerStopOrder(ctx context.Context, orderID string) error {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+	e.triggered = append(e.triggered, orderID)
+	return nil
+}
+
+func (e *recordingStopExecutor) TrailStopOrder(ctx context.Context, orderID string, triggerPrice float64, anchorPrice float64) error {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+	e.trailed[orderID] = triggerPrice
+	return nil
+}
+
+// stopOrder builds a pending stop-loss order
+func stopOrder(id string, side models.OrderSide, triggerPrice float64) *models.Order {
+	return &models.Order{
+		ID:           id,
+		Symbol:       "INFY",
+		Exchange:     "NSE",
+		Side:         side,
+		Type:         models.OrderTypeStopLoss,
+		Status:       models.OrderStatusPending,
+		TriggerPrice: &triggerPrice,
+	}
+}
+
+func TestStopTriggerMonitorTriggersCrossedStops(t *testing.T) {
+	quotedAt := time.Date(2024, 3, 14, 10, 0, 0, 0, istLocation)
+	anchor, trail := 1500.0, 10.0
+	trailing := stopOrder("trailing", models.OrderSideSell, 1490)
+	trailing.Type = models.OrderTypeTrailingStop
+	trailing.TrailAnchor = &anchor
+	trailing.TrailAmount = &trail
+
+	feed := &pushQuoteFeed{}
+	executor := &recordingStopExecutor{
+		pending: []*models.Order{
+			stopOrder("sell", models.OrderSideSell, 1480),
+			stopOrder("buy", models.OrderSideBuy, 1520),
+			trailing,
+		},
+		trailed: make(map[string]float64),
+	}
+	monitor := NewStopTriggerMonitor(feed, executor, NewVirtualClock(quotedAt))
+	if err := monitor.Start(context.Background()); err != nil {
+		t.Fatalf("start: %v", err)
+	}
+
+	quote := func(price float64, at time.Time) {
+		feed.callback(&models.MarketQuote{Symbol: "INFY", Exchange: "NSE", LastPrice: price, LastTradeTime: at})
+	}
+
+	// A new high ratchets the trailing stop up without triggering anything
+	quote(1510, quotedAt)
+	if got := executor.trailed["trailing"]; got != 1500 {
+		t.Fatalf("trailing trigger = %.2f, want 1500", got)
+	}
+	if len(executor.triggered) != 0 {
+		t.Fatalf("triggered %v, want none", executor.triggered)
+	}
+
+	// A stale tick from before the high is dropped
+	quote(1470, quotedAt.Add(-time.Second))
+	if len(executor.triggered) != 0 {
+		t.Fatalf("triggered %v from a stale tick, want none", executor.triggered)
+	}
+
+	// Falling through both sell triggers fires them, tightest first
+	quote(1479, quotedAt.Add(time.Second))
+	if want := []string{"trailing", "sell"}; !reflect.DeepEqual(executor.triggered, want) {
+		t.Fatalf("triggered %v, want %v", executor.triggered, want)
+	}
+
+	// Each stop fires once
+	quote(1470, quotedAt.Add(2*time.Second))
+	quote(1525, quotedAt.Add(3*time.Second))
+	if want := []string{"trailing", "sell", "buy"}; !reflect.DeepEqual(executor.triggered, want) {
+		t.Errorf("triggered %v, want %v", executor.triggered, want)
+	}
+}
@@ -0,0 +1,320 @@
+// stock-trading-app/backend/internal/services/time_series_store.go
+
+package services
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/shyamanurag/stock-trading-app/backend/internal/models"
+	"github.com/shyamanurag/stock-trading-app/backend/internal/repository"
+)
+
+// tickRetention is the retention key for ticks; bars use their interval
+const tickRetention = "ticks"
+
+// partitionMaintenanceInterval is how often partitions are created ahead
+// and expired ones dropped
+const partitionMaintenanceInterval = time.Hour
+
+// TimeSeriesConfig controls the tick and candle store. It mirrors
+// timeSeries in the config files.
+type TimeSeriesConfig struct {
+	BufferSize    int                      // Ticks waiting to be written before new ones are dropped
+	BatchSize     int                      // Most ticks written in one insert
+	FlushInterval time.Duration            // Longest a tick waits in the buffer
+	Retention     map[string]time.Duration // How long ticks and each bar interval are kept; zero keeps them forever
+}
+
+// DefaultTimeSeriesConfig returns the store settings used when none are
+// configured
+func DefaultTimeSeriesConfig() TimeSeriesConfig {
+	return TimeSeriesConfig{
+		BufferSize:    10000,
+		BatchSize:     500,
+		FlushInterval: time.Second,
+		Retention: map[string]time.Duration{
+			tickRetention: 7 * 24 * time.Hour,
+			"1m":          30 * 24 * time.Hour,
+			"5m":          90 * 24 * time.Hour,
+			"15m":         180 * 24 * time.Hour,
+			"30m":         365 * 24 * time.Hour,
+			"1h":          2 * 365 * 24 * time.Hour,
+			"1d":          0,
+		},
+	}
+}
+
+// timeSeriesPartitioning describes how one table is split into time ranges.
+// Partitions are named <parent>_p<start> and bounded in UTC, which keeps
+// every Indian session inside a single day.
+type timeSeriesPartitioning struct {
+	parent    string
+	layout    string                      // Layout of the start time in partition names
+	truncate  func(t time.Time) time.Time // Start of the partition holding t
+	next      func(t time.Time) time.Time // Start of the partition after the one starting at t
+	ahead     int                         // Partitions created beyond the current one
+	retention time.Duration
+}
+
+func startOfDay(t time.Time) time.Time {
+	t = t.UTC()
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
+
+func nextDay(t time.Time) time.Time {
+	return t.AddDate(0, 0, 1)
+}
+
+func startOfMonth(t time.Time) time.Time {
+	t = t.UTC()
+	return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, time.UTC)
+}
+
+func nextMonth(t time.Time) time.Time {
+	return t.AddDate(0, 1, 0)
+}
+
+// TimeSeriesStore stores ticks and closed bars in partitioned tables. Ticks
+// are buffered and written in batches by a single writer; when the buffer is
+// full new ticks are dropped rather than holding up the feed. Partitions are
+// created ahead of time and dropped once they fall out of retention.
+type TimeSeriesStore struct {
+	tickRepo      *repository.TickRepository
+	candleRepo    *repository.CandleRepository
+	partitionRepo *repository.PartitionRepository
+	calendar      *MarketCalendar
+	config        TimeSeriesConfig
+	ticks         chan *models.Tick
+	dropped       int64 // Ticks dropped since the last report, updated atomically
+}
+
+// NewTimeSeriesStore creates a new TimeSeriesStore
+func NewTimeSeriesStore(
+	tickRepo *repository.TickRepository,
+	candleRepo *repository.CandleRepository,
+	partitionRepo *repository.PartitionRepository,
+	calendar *MarketCalendar,
+	config TimeSeriesConfig,
+) *TimeSeriesStore {
+	defaults := DefaultTimeSeriesConfig()
+	if config.BufferSize <= 0 {
+		config.BufferSize = defaults.BufferSize
+	}
+	if config.BatchSize <= 0 {
+		config.BatchSize = defaults.BatchSize
+	}
+	if config.FlushInterval <= 0 {
+		config.FlushInterval = defaults.FlushInterval
+	}
+	if config.Retention == nil {
+		config.Retention = defaults.Retention
+	}
+
+	return &TimeSeriesStore{
+		tickRepo:      tickRepo,
+		candleRepo:    candleRepo,
+		partitionRepo: partitionRepo,
+		calendar:      calendar,
+		config:        config,
+		ticks:         make(chan *models.Tick, config.BufferSize),
+	}
+}
+
+// Start prepares partitions and writes buffered ticks until ctx is done, then
+// writes whatever is still buffered
+func (s *TimeSeriesStore) Start(ctx context.Context) {
+	s.MaintainPartitions(ctx, s.calendar.Now())
+	go s.write(ctx)
+	go s.maintain(ctx)
+}
+
+// RecordQuote buffers a quote to be stored as a tick. It never blocks.
+func (s *TimeSeriesStore) RecordQuote(quote *models.MarketQuote) {
+	tick := &models.Tick{
+		Exchange:     quote.Exchange,
+		Symbol:       quote.Symbol,
+		Timestamp:    quoteTime(quote, s.calendar),
+		LastPrice:    quote.LastPrice,
+		Volume:       quote.Volume,
+		Bid:          quote.Bid,
+		BidQty:       quote.BidQty,
+		Ask:          quote.Ask,
+		AskQty:       quote.AskQty,
+		OpenInterest: quote.OpenInterest,
+	}
+
+	select {
+	case s.ticks <- tick:
+	default:
+		atomic.AddInt64(&s.dropped, 1)
+	}
+}
+
+// write batches buffered ticks into inserts of up to BatchSize, writing a
+// partial batch once FlushInterval passes
+func (s *TimeSeriesStore) write(ctx context.Context) {
+	ticker := time.NewTicker(s.config.FlushInterval)
+	defer ticker.Stop()
+
+	batch := make([]*models.Tick, 0, s.config.BatchSize)
+	add := func(tick *models.Tick) {
+		batch = append(batch, tick)
+		if len(batch) >= s.config.BatchSize {
+			s.flush(batch)
+			batch = batch[:0]
+		}
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			for {
+				select {
+				case tick := <-s.ticks:
+					add(tick)
+				default:
+					s.flush(batch)
+					return
+				}
+			}
+		case tick := <-s.ticks:
+			add(tick)
+		case <-ticker.C:
+			s.flush(batch)
+			batch = batch[:0]
+
+			if dropped := atomic.SwapInt64(&s.dropped, 0); dropped > 0 {
+				log.Printf("Tick buffer full, dropped %d ticks", dropped)
+			}
+		}
+	}
+}
+
+// flush writes a batch of ticks. It uses its own context so the final
+// flush still runs after the store is stopped.
+func (s *TimeSeriesStore) flush(batch []*models.Tick) {
+	if len(batch) == 0 {
+		return
+	}
+	if err := s.tickRepo.SaveBatch(context.Background(), batch); err != nil {
+		log.Printf("Failed to store %d ticks: %v", len(batch), err)
+	}
+}
+
+// SaveCandles stores closed bars
+func (s *TimeSeriesStore) SaveCandles(ctx context.Context, candles []*models.Candle) error {
+	if err := s.candleRepo.SaveBatch(ctx, candles); err != nil {
+		return fmt.Errorf("failed to store candles: %w", err)
+	}
+	return nil
+}
+
+// Candles returns an instrument's stored bars for an interval that opened in
+// [startTime, endTime), oldest first
+func (s *TimeSeriesStore) Candles(ctx context.Context, exchange string, symbol string, interval string, startTime time.Time, endTime time.Time) ([]*models.Candle, error) {
+	candles, err := s.candleRepo.GetRange(ctx, exchange, symbol, interval, startTime, endTime)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get candles: %w", err)
+	}
+	return candles, nil
+}
+
+// Ticks returns up to limit of an instrument's stored ticks in
+// [startTime, endTime), oldest first. A limit of zero returns every tick.
+func (s *TimeSeriesStore) Ticks(ctx context.Context, exchange string, symbol string, startTime time.Time, endTime time.Time, limit int) ([]*models.Tick, error) {
+	ticks, err := s.tickRepo.GetRange(ctx, exchange, symbol, startTime, endTime, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get ticks: %w", err)
+	}
+	return ticks, nil
+}
+
+// maintain keeps partitions ahead of time and applies retention once per
+// maintenance interval on the calendar's clock
+func (s *TimeSeriesStore) maintain(ctx context.Context) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-s.calendar.After(partitionMaintenanceInterval):
+			s.MaintainPartitions(ctx, now)
+		}
+	}
+}
+
+// partitionings returns how ticks and each bar interval are partitioned:
+// ticks by day, bars by month
+func (s *TimeSeriesStore) partitionings() []timeSeriesPartitioning {
+	partitionings := []timeSeriesPartitioning{{
+		parent:    "market_ticks",
+		layout:    "20060102",
+		truncate:  startOfDay,
+		next:      nextDay,
+		ahead:     2,
+		retention: s.config.Retention[tickRetention],
+	}}
+	for _, interval := range candleIntervals {
+		partitionings = append(partitionings, timeSeriesPartitioning{
+			parent:    "candles_" + interval,
+			layout:    "200601",
+			truncate:  startOfMonth,
+			next:      nextMonth,
+			ahead:     1,
+			retention: s.config.Retention[interval],
+		})
+	}
+	return partitionings
+}
+
+// MaintainPartitions creates the partitions needed from now on and drops
+// those holding only data older than their retention. Failures are logged
+// and retried at the next maintenance.
+func (s *TimeSeriesStore) MaintainPartitions(ctx context.Context, now time.Time) {
+	for _, partitioning := range s.partitionings() {
+		start := partitioning.truncate(now)
+		for i := 0; i <= partitioning.ahead; i++ {
+			end := partitioning.next(start)
+			name := partitioning.parent + "_p" + start.Format(partitioning.layout)
+			if err := s.partitionRepo.CreateRangePartition(ctx, partitioning.parent, name, start, end); err != nil {
+				log.Printf("Failed to create partition %s: %v", name, err)
+			}
+			start = end
+		}
+
+		if partitioning.retention <= 0 {
+			continue
+		}
+
+		names, err := s.partitionRepo.ListPartitions(ctx, partitioning.parent)
+		if err != nil {
+			log.Printf("Failed to list partitions of %s: %v", partitioning.parent, err)
+			continue
+		}
+
+		cutoff := now.Add(-partitioning.retention)
+		prefix := partitioning.parent + "_p"
+		for _, name := range names {
+			if !strings.HasPrefix(name, prefix) {
+				continue
+			}
+			start, err := time.ParseInLocation(partitioning.layout, strings.TrimPrefix(name, prefix), time.UTC)
+			if err != nil {
+				continue // Not one of ours
+			}
+			if partitioning.next(start).After(cutoff) {
+				continue
+			}
+
+			if err := s.partitionRepo.DropPartition(ctx, name); err != nil {
+				log.Printf("Failed to drop expired partition %s: %v", name, err)
+				continue
+			}
+			log.Printf("Dropped expired partition %s", name)
+		}
+	}
+}
@@ -4,12 +4,16 @@ package services
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"log"
+	"sync"
 	"time"
 
 	"github.com/google/uuid"
 	"github.com/shyamanurag/stock-trading-app/backend/internal/models"
 	"github.com/shyamanurag/stock-trading-app/backend/internal/repository"
+	"gorm.io/gorm"
 )
 
 // TradingService handles trade order execution
@@ -17,9 +21,23 @@ type TradingService struct {
 	orderRepo      *repository.OrderRepository
 	holdingRepo    *repository.HoldingRepository
 	walletRepo     *repository.WalletRepository
-	tradeHistRepo  *repository.TradeHistoryRepository
+	tradeRepo      *repository.TradeRepository
 	transactionMgr *repository.TransactionManager
-	marketData     *MarketDataService
+	marketData     MarketDataService
+	matchingEngine *MatchingEngine
+	stopMonitor    *StopTriggerMonitor
+	expiry         *OrderExpiryScheduler
+	calendar       *MarketCalendar
+	sessions       *MarketSessionMonitor
+	charges        *ChargesCalculator
+	risk           *RiskEngine
+	fills          *FillSimulator
+	idempotency    *IdempotencyGuard
+	killSwitch     *KillSwitch
+	events         *OrderEventBus
+
+	expiredCallbacks []func(order *models.Order)
+	callbackMutex    sync.RWMutex
 }
 
 // NewTradingService creates a new TradingService
@@ -27,99 +45,456 @@ func NewTradingService(
 	orderRepo *repository.OrderRepository,
 	holdingRepo *repository.HoldingRepository,
 	walletRepo *repository.WalletRepository,
-	tradeHistRepo *repository.TradeHistoryRepository,
+	tradeRepo *repository.TradeRepository,
 	transactionMgr *repository.TransactionManager,
-	marketData *MarketDataService,
+	marketData MarketDataService,
+	calendar *MarketCalendar,
+	sessions *MarketSessionMonitor,
+	charges *ChargesCalculator,
+	risk *RiskEngine,
+	fills *FillSimulator,
+	idempotency *IdempotencyGuard,
+	killSwitch *KillSwitch,
 ) *TradingService {
-	return &TradingService{
+	s := &TradingService{
 		orderRepo:      orderRepo,
 		holdingRepo:    holdingRepo,
 		walletRepo:     walletRepo,
-		tradeHistRepo:  tradeHistRepo,
+		tradeRepo:      tradeRepo,
 		transactionMgr: transactionMgr,
 		marketData:     marketData,
+		matchingEngine: NewMatchingEngine(calendar),
+		calendar:       calendar,
+		sessions:       sessions,
+		charges:        charges,
+		risk:           risk,
+		fills:          fills,
+		idempotency:    idempotency,
+		killSwitch:     killSwitch,
+		events:         NewOrderEventBus(calendar),
+	}
+	s.stopMonitor = NewStopTriggerMonitor(marketData, s, calendar)
+	s.expiry = NewOrderExpiryScheduler(calendar, s)
+	killSwitch.setOrderCanceller(s.cancelHaltedOrders)
+	return s
+}
+
+// Start loads the trading halts in force, rebuilds the order book from open
+// limit orders, the stop trigger index from pending stop orders and the
+// expiry schedule from DAY and GTD orders, then begins recording depth for
+// the fill simulator, matching against live quotes and expiring orders as
+// sessions close
+func (s *TradingService) Start(ctx context.Context) error {
+	if err := s.killSwitch.Load(ctx); err != nil {
+		return err
+	}
+
+	orders, err := s.orderRepo.GetByStatusAndType(ctx,
+		[]models.OrderStatus{models.OrderStatusOpen, models.OrderStatusPartial},
+		[]models.OrderType{models.OrderTypeLimit},
+	)
+	if err != nil {
+		return fmt.Errorf("failed to load open orders: %w", err)
+	}
+
+	for _, order := range orders {
+		if err := s.matchingEngine.Add(order); err != nil {
+			log.Printf("Skipping order %s while rebuilding order book: %v", order.ID, err)
+		}
+	}
+
+	s.fills.Start()
+	s.marketData.OnQuoteUpdate(func(quote *models.MarketQuote) {
+		s.matchQuote(context.Background(), quote)
+	})
+
+	if err := s.stopMonitor.Start(ctx); err != nil {
+		return err
 	}
+
+	s.sessions.OnStatusChange(s.expiry.OnStatusChange)
+	return s.expiry.Start(ctx)
 }
 
-// PlaceOrder handles placing a new order
+// PlaceOrder handles placing a new order. Orders that fail a pre-trade risk
+// check are stored as REJECTED and returned along with the rejection.
 func (s *TradingService) PlaceOrder(ctx context.Context, order *models.Order) (*models.Order, error) {
+	if err := s.prepareOrder(ctx, order); err != nil {
+		return nil, err
+	}
+
+	// Orders above the instrument's freeze quantity are split into slices
+	var sliceSize int
+	if order.ParentOrderID == nil && !isBracketVariety(order) {
+		if sliceSize = s.sliceSize(order); sliceSize > 0 && order.Quantity > sliceSize {
+			order.SliceCount = (order.Quantity + sliceSize - 1) / sliceSize
+		}
+	}
+
+	// Run the trading halt and pre-trade risk checks. System orders such as
+	// square-offs must always go through, and slices were checked as part of
+	// their parent.
+	if order.PlacedBy != "system" && !isOrderSlice(order) {
+		if rejection := s.killSwitch.Check(order); rejection != nil {
+			return s.rejectOrder(ctx, order, rejection)
+		}
+		if err := s.risk.Check(ctx, order); err != nil {
+			var rejection *models.RiskRejection
+			if !errors.As(err, &rejection) {
+				return nil, err
+			}
+			return s.rejectOrder(ctx, order, rejection)
+		}
+	}
+
+	if isSliceParent(order) {
+		return s.placeSlices(ctx, order, sliceSize)
+	}
+
+	var executedAt time.Time
+	if executesOnPlacement(order) {
+		var err error
+		if executedAt, err = s.awaitExecution(ctx); err != nil {
+			return nil, err
+		}
+	}
+
+	// Handle the order within a transaction
+	err := s.transactionMgr.WithTransaction(ctx, func(tx *gorm.DB) error {
+		return s.storeOrder(ctx, tx, order, executedAt)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return s.activateOrder(ctx, order)
+}
+
+// prepareOrder sets a new order's defaults and validates it
+func (s *TradingService) prepareOrder(ctx context.Context, order *models.Order) error {
 	// Set default values
-	if order.ID == uuid.Nil {
-		order.ID = uuid.New()
+	if order.ID == "" {
+		order.ID = uuid.New().String()
 	}
-	order.Status = "PENDING"
-	order.RemainingQuantity = order.Quantity
+	order.Status = models.OrderStatusPending
+	order.RemainingQty = order.Quantity
 	order.FilledQuantity = 0
+	order.CreatedAt = s.calendar.Now()
+	order.UpdatedAt = order.CreatedAt
+	if order.Validity == "" {
+		order.Validity = models.OrderValidityDay
+	}
 
 	// Validate order
+	if isBracketVariety(order) && order.ParentOrderID != nil {
+		return fmt.Errorf("bracket and cover legs cannot be placed directly")
+	}
+	if order.Type == models.OrderTypeTrailingStop {
+		if err := s.initTrailingStop(ctx, order); err != nil {
+			return err
+		}
+	}
 	if err := s.validateOrder(ctx, order); err != nil {
-		return nil, err
+		return err
 	}
 
-	// Handle the order within a transaction
-	err := s.transactionMgr.WithTransaction(ctx, func(tx *gorm.DB) error {
-		// Step 1: Reserve funds or securities depending on order type
-		if err := s.reserveFundsOrSecurities(ctx, tx, order); err != nil {
+	// Limit orders rest in the order book until matched
+	if order.Type == models.OrderTypeLimit {
+		order.Status = models.OrderStatusOpen
+	}
+
+	// After-market orders wait for the next session
+	if order.Variety == models.OrderVarietyAMO {
+		return s.queueAfterMarketOrder(order)
+	}
+	return nil
+}
+
+// storeOrder reserves for and stores a validated order within a
+// transaction. Market orders are executed immediately at executedAt, which
+// the caller waited for with awaitExecution before opening the transaction.
+func (s *TradingService) storeOrder(ctx context.Context, tx *gorm.DB, order *models.Order, executedAt time.Time) error {
+	// Step 1: Reserve funds or securities depending on order type
+	if err := s.reserveFundsOrSecurities(ctx, tx, order); err != nil {
+		return err
+	}
+
+	// Step 2: Store the order
+	orderRepo := repository.NewOrderRepository(tx)
+	if err := orderRepo.Create(ctx, order); err != nil {
+		return fmt.Errorf("failed to create order: %w", err)
+	}
+	s.emitOrderEvent(tx, OrderPlaced, order, nil)
+
+	// Bracket and cover orders carry inactive exit legs
+	if isBracketVariety(order) {
+		if err := s.createBracketLegs(ctx, tx, order); err != nil {
 			return err
 		}
+	}
+
+	// Step 3: If it's a market order, try to execute it immediately
+	if executesOnPlacement(order) {
+		if err := s.executeMarketOrder(ctx, tx, order, executedAt); err != nil {
+			return fmt.Errorf("failed to execute market order: %w", err)
+		}
+	}
 
-		// Step 2: Store the order
+	return nil
+}
+
+// activateOrder hands a stored order to the book, trigger index or expiry
+// scheduler once its transaction has committed, and returns its latest state
+func (s *TradingService) activateOrder(ctx context.Context, order *models.Order) (*models.Order, error) {
+	// Queued after-market orders are activated when they are released
+	if order.Status == models.OrderStatusQueued {
+		return order, nil
+	}
+
+	// Step 4: Rest limit orders in the book or start watching stop triggers
+	switch order.Type {
+	case models.OrderTypeLimit:
+		if err := s.restLimitOrder(ctx, order); err != nil {
+			return nil, err
+		}
+		if updated, err := s.orderRepo.GetByID(ctx, order.ID); err == nil && updated != nil {
+			order = updated
+		}
+	case models.OrderTypeStopLoss, models.OrderTypeStopLimit, models.OrderTypeTrailingStop:
+		if err := s.stopMonitor.Track(order); err != nil {
+			return nil, fmt.Errorf("failed to track stop order: %w", err)
+		}
+	}
+
+	// Step 5: IOC orders give up whatever the matching attempt left unfilled;
+	// everything else waits for its validity to run out
+	if order.Validity == models.OrderValidityIOC {
+		if !isTerminalStatus(order.Status) {
+			if err := s.ExpireOrder(ctx, order.ID, "unfilled IOC remainder cancelled"); err != nil && !errors.Is(err, errOrderNotExpirable) {
+				return nil, fmt.Errorf("failed to cancel IOC remainder: %w", err)
+			}
+			if updated, err := s.orderRepo.GetByID(ctx, order.ID); err == nil && updated != nil {
+				order = updated
+			}
+		}
+	} else if !isTerminalStatus(order.Status) {
+		s.expiry.Track(order)
+	}
+
+	return order, nil
+}
+
+// rejectOrder stores an order that failed a risk check as REJECTED
+func (s *TradingService) rejectOrder(ctx context.Context, order *models.Order, rejection *models.RiskRejection) (*models.Order, error) {
+	order.Status = models.OrderStatusRejected
+	order.Error = rejection.Error()
+	if err := s.orderRepo.Create(ctx, order); err != nil {
+		return nil, fmt.Errorf("failed to store rejected order: %w", err)
+	}
+	s.publishOrderEvent(OrderRejected, order)
+	return order, rejection
+}
+
+// restLimitOrder adds an open limit order to the book and matches it against
+// the current quote
+func (s *TradingService) restLimitOrder(ctx context.Context, order *models.Order) error {
+	if err := s.matchingEngine.Add(order); err != nil {
+		return fmt.Errorf("failed to add order to book: %w", err)
+	}
+	if quote, err := s.marketData.GetQuote(order.Symbol, order.Exchange); err == nil && quote != nil {
+		s.matchQuote(ctx, quote)
+	}
+	return nil
+}
+
+// PendingStopOrders returns the stop orders still waiting for their trigger
+func (s *TradingService) PendingStopOrders(ctx context.Context) ([]*models.Order, error) {
+	return s.orderRepo.GetByStatusAndType(ctx,
+		[]models.OrderStatus{models.OrderStatusPending},
+		[]models.OrderType{models.OrderTypeStopLoss, models.OrderTypeStopLimit, models.OrderTypeTrailingStop},
+	)
+}
+
+// TriggerStopOrder converts a triggered stop order into a market order, which
+// is filled against the market depth like any other market order, or a
+// limit order, which is rested in the book
+func (s *TradingService) TriggerStopOrder(ctx context.Context, orderID string) error {
+	order, err := s.orderRepo.GetByID(ctx, orderID)
+	if err != nil {
+		return fmt.Errorf("failed to get order: %w", err)
+	}
+
+	if order == nil || order.Status != models.OrderStatusPending {
+		return errOrderNotTriggerable
+	}
+
+	// A stop-loss becomes a market order, whose latency is waited out
+	// before the order is locked
+	var executedAt time.Time
+	if order.Type == models.OrderTypeStopLoss || order.Type == models.OrderTypeTrailingStop {
+		if executedAt, err = s.awaitExecution(ctx); err != nil {
+			return err
+		}
+	}
+
+	var triggered *models.Order
+	err = s.transactionMgr.WithTransaction(ctx, func(tx *gorm.DB) error {
 		orderRepo := repository.NewOrderRepository(tx)
-		if err := orderRepo.Create(ctx, order); err != nil {
-			return fmt.Errorf("failed to create order: %w", err)
+		order, err := orderRepo.GetByIDForUpdate(ctx, orderID)
+		if err != nil {
+			return fmt.Errorf("failed to get order: %w", err)
 		}
 
-		// Step 3: If it's a market order, try to execute it immediately
-		if order.OrderType == "MARKET" {
-			if err := s.executeMarketOrder(ctx, tx, order); err != nil {
-				return fmt.Errorf("failed to execute market order: %w", err)
+		if order == nil || order.Status != models.OrderStatusPending {
+			return errOrderNotTriggerable
+		}
+
+		switch order.Type {
+		case models.OrderTypeStopLoss, models.OrderTypeTrailingStop:
+			order.Type = models.OrderTypeMarket
+			order.Status = models.OrderStatusOpen
+			if err := orderRepo.Update(ctx, order); err != nil {
+				return fmt.Errorf("failed to update order: %w", err)
+			}
+			s.emitOrderEvent(tx, OrderModified, order, nil)
+			if err := s.executeMarketOrder(ctx, tx, order, executedAt); err != nil {
+				return fmt.Errorf("failed to execute triggered order: %w", err)
+			}
+		case models.OrderTypeStopLimit:
+			order.Type = models.OrderTypeLimit
+			order.Status = models.OrderStatusOpen
+			if err := orderRepo.Update(ctx, order); err != nil {
+				return fmt.Errorf("failed to update order: %w", err)
 			}
+			s.emitOrderEvent(tx, OrderModified, order, nil)
+		default:
+			return errOrderNotTriggerable
 		}
 
+		triggered = order
 		return nil
 	})
-
 	if err != nil {
-		return nil, err
+		return err
 	}
 
-	return order, nil
+	if triggered.Type == models.OrderTypeLimit {
+		return s.restLimitOrder(ctx, triggered)
+	}
+	return nil
 }
 
 // validateOrder validates an order
 func (s *TradingService) validateOrder(ctx context.Context, order *models.Order) error {
 	// Check if order has required fields
-	if order.UserID == uuid.Nil || order.Symbol == "" || order.Quantity <= 0 {
+	if order.UserID == "" || order.Symbol == "" || order.Quantity <= 0 {
 		return fmt.Errorf("invalid order parameters")
 	}
 
 	// Validate OrderType
-	switch order.OrderType {
-	case "MARKET":
+	switch order.Type {
+	case models.OrderTypeMarket:
 		// Market orders don't need a price
-	case "LIMIT":
+	case models.OrderTypeLimit:
 		if order.Price == nil || *order.Price <= 0 {
 			return fmt.Errorf("limit orders require a valid price")
 		}
-	case "STOP":
-		if order.StopPrice == nil || *order.StopPrice <= 0 {
-			return fmt.Errorf("stop orders require a valid stop price")
+	case models.OrderTypeStopLoss:
+		if order.TriggerPrice == nil || *order.TriggerPrice <= 0 {
+			return fmt.Errorf("stop orders require a valid trigger price")
+		}
+	case models.OrderTypeStopLimit:
+		if order.TriggerPrice == nil || *order.TriggerPrice <= 0 {
+			return fmt.Errorf("stop orders require a valid trigger price")
+		}
+		if order.Price == nil || *order.Price <= 0 {
+			return fmt.Errorf("stop-limit orders require a valid price")
+		}
+	case models.OrderTypeTrailingStop:
+		if err := validateTrailingStop(order); err != nil {
+			return err
+		}
+		if order.TriggerPrice == nil || *order.TriggerPrice <= 0 {
+			return fmt.Errorf("stop orders require a valid trigger price")
 		}
 	default:
-		return fmt.Errorf("invalid order type: %s", order.OrderType)
+		return fmt.Errorf("invalid order type: %s", order.Type)
+	}
+
+	// Validate disclosed quantity
+	if err := s.validateDisclosedQuantity(order); err != nil {
+		return err
 	}
 
 	// Validate Side
-	if order.Side != "BUY" && order.Side != "SELL" {
+	if order.Side != models.OrderSideBuy && order.Side != models.OrderSideSell {
 		return fmt.Errorf("invalid order side: %s", order.Side)
 	}
 
+	// Validate validity
+	switch order.Validity {
+	case models.OrderValidityDay, models.OrderValidityGTC:
+	case models.OrderValidityIOC:
+		if order.Type != models.OrderTypeMarket && order.Type != models.OrderTypeLimit {
+			return fmt.Errorf("IOC validity is only allowed for market and limit orders")
+		}
+		if isBracketVariety(order) {
+			return fmt.Errorf("IOC validity is not allowed for %s orders", order.Variety)
+		}
+	case models.OrderValidityGTD:
+		if order.ValidityDate == nil {
+			return fmt.Errorf("GTD orders require a validity date")
+		}
+		if expiresAt, _, _ := s.expiry.ExpiryTime(order); !expiresAt.After(s.calendar.Now()) {
+			return fmt.Errorf("validity date %s has already passed", order.ValidityDate.Format("2006-01-02"))
+		}
+	default:
+		return fmt.Errorf("invalid order validity: %s", order.Validity)
+	}
+
+	// Validate bracket and cover order legs. Legs are created by the system
+	// and validated with their entry order.
+	if isBracketVariety(order) && order.ParentOrderID == nil {
+		return s.validateBracketOrder(ctx, order)
+	}
+
+	return nil
+}
+
+// minDisclosedFraction is the smallest share of an order's quantity the
+// exchange allows an iceberg order to disclose
+const minDisclosedFraction = 0.10
+
+// validateDisclosedQuantity enforces the exchange rules for iceberg orders
+func (s *TradingService) validateDisclosedQuantity(order *models.Order) error {
+	if order.DisclosedQty == nil {
+		return nil
+	}
+
+	disclosed := *order.DisclosedQty
+	if disclosed <= 0 || disclosed > order.Quantity {
+		return fmt.Errorf("disclosed quantity must be between 1 and the order quantity %d", order.Quantity)
+	}
+	if order.Type != models.OrderTypeLimit && order.Type != models.OrderTypeStopLimit {
+		return fmt.Errorf("disclosed quantity is only allowed for limit and stop-limit orders")
+	}
+	if isBracketVariety(order) {
+		return fmt.Errorf("disclosed quantity is not allowed for %s orders", order.Variety)
+	}
+	if order.InstrumentType != "" && order.InstrumentType != "EQ" {
+		return fmt.Errorf("disclosed quantity is only allowed for equity orders")
+	}
+	if float64(disclosed) < minDisclosedFraction*float64(order.Quantity) {
+		return fmt.Errorf("disclosed quantity %d is less than %.0f%% of the order quantity %d",
+			disclosed, minDisclosedFraction*100, order.Quantity)
+	}
+
 	return nil
 }
 
 // reserveFundsOrSecurities reserves funds for buy orders or securities for sell orders
 func (s *TradingService) reserveFundsOrSecurities(ctx context.Context, tx *gorm.DB, order *models.Order) error {
-	if order.Side == "BUY" {
+	if order.Side == models.OrderSideBuy {
 		// For buy orders, reserve funds in the wallet
 		return s.reserveFunds(ctx, tx, order)
 	} else {
@@ -141,46 +516,60 @@ func (s *TradingService) reserveFunds(ctx context.Context, tx *gorm.DB, order *m
 	}
 
 	// Calculate required funds
-	var requiredFunds float64
-	if order.OrderType == "MARKET" {
-		// For market orders, use current price plus a buffer (e.g., 5%)
-		marketPrice, err := s.marketData.GetCurrentPrice(ctx, order.Symbol)
-		if err != nil {
-			return fmt.Errorf("failed to get current price: %w", err)
-		}
-		requiredFunds = marketPrice * order.Quantity * 1.05 // 5% buffer
-	} else if order.OrderType == "LIMIT" {
-		// For limit orders, use the limit price
-		requiredFunds = *order.Price * order.Quantity
-	} else {
-		// For stop orders
-		requiredFunds = *order.StopPrice * order.Quantity
+	requiredFunds, err := s.requiredFunds(ctx, order)
+	if err != nil {
+		return err
 	}
 
-	// Add estimated fees (0.1%)
-	requiredFunds += requiredFunds * 0.001
+	// Buying back a short may draw on the margin blocked for it
+	var fromMargin float64
+	if wallet.Balance < requiredFunds {
+		if fromMargin, err = s.drawCoverMargin(ctx, tx, order, requiredFunds-wallet.Balance); err != nil {
+			return err
+		}
+	}
 
 	// Check if the wallet has enough funds
-	if wallet.Balance < requiredFunds {
+	if wallet.Balance+fromMargin < requiredFunds {
 		return fmt.Errorf("insufficient funds: required %.2f, available %.2f", requiredFunds, wallet.Balance)
 	}
 
 	// Update wallet balance
-	wallet.Balance -= requiredFunds
-	wallet.HoldBalance += requiredFunds
+	wallet.Balance -= requiredFunds - fromMargin
+	wallet.HoldBalance += requiredFunds - fromMargin
 
 	// Update wallet
 	if err := walletRepo.Update(ctx, wallet); err != nil {
 		return fmt.Errorf("failed to update wallet: %w", err)
 	}
 
-	return nil
+	// Record the hold so fills and cancellation settle exactly this amount
+	return s.recordReservation(ctx, tx, order, requiredFunds, 0)
 }
 
-// reserveSecurities checks if the user has enough securities for a sell order
+// reserveSecurities reserves securities for a sell order
 func (s *TradingService) reserveSecurities(ctx context.Context, tx *gorm.DB, order *models.Order) error {
+	// Intraday sells beyond the open position are sold short
+	if isIntraday(order) {
+		return s.reserveIntradaySell(ctx, tx, order)
+	}
+
+	if err := s.takeSecurities(ctx, tx, order, order.Quantity); err != nil {
+		return err
+	}
+	return s.recordReservation(ctx, tx, order, 0, order.Quantity)
+}
+
+// takeSecurities checks if the user has enough securities for a sell order
+// and takes quantity out of their holdings
+func (s *TradingService) takeSecurities(ctx context.Context, tx *gorm.DB, order *models.Order, quantity int) error {
+	// Intraday exits are held against the day's position, not holdings
+	if isIntraday(order) {
+		return s.reservePositionQuantity(ctx, tx, order, quantity)
+	}
+
 	holdingRepo := repository.NewHoldingRepository(tx)
-	
+
 	// Get all holdings for the user and symbol
 	holdings, err := holdingRepo.GetByUserIDAndSymbol(ctx, order.UserID, order.Symbol)
 	if err != nil {
@@ -193,13 +582,15 @@ func (s *TradingService) reserveSecurities(ctx context.Context, tx *gorm.DB, ord
 		totalQuantity += holding.Quantity
 	}
 
-	if totalQuantity < order.Quantity {
-		return fmt.Errorf("insufficient securities: required %.2f, available %.2f", order.Quantity, totalQuantity)
+	// Only intraday orders may sell short
+	if totalQuantity < float64(quantity) {
+		return fmt.Errorf("insufficient securities: required %d, available %.2f; short selling is only allowed for %s orders",
+			quantity, totalQuantity, models.ProductMIS)
 	}
 
 	// Mark securities as reserved (in a real system, you might have a more sophisticated approach)
 	// For simplicity, we'll just reduce the quantity from the first available holding
-	remainingToReserve := order.Quantity
+	remainingToReserve := float64(quantity)
 	for _, holding := range holdings {
 		if holding.Quantity >= remainingToReserve {
 			holding.Quantity -= remainingToReserve
@@ -219,20 +610,124 @@ func (s *TradingService) reserveSecurities(ctx context.Context, tx *gorm.DB, ord
 	return nil
 }
 
-// executeMarketOrder executes a market order immediately
-func (s *TradingService) executeMarketOrder(ctx context.Context, tx *gorm.DB, order *models.Order) error {
-	// Get current market price
-	marketPrice, err := s.marketData.GetCurrentPrice(ctx, order.Symbol)
+// executesOnPlacement reports whether an order is a market order executed
+// as soon as it is stored
+func executesOnPlacement(order *models.Order) bool {
+	return order.Type == models.OrderTypeMarket && order.Status != models.OrderStatusQueued
+}
+
+// awaitExecution draws the simulated latency of a market order arriving now
+// and waits until the calendar's clock reaches its execution time, which it
+// returns. It must be called before the order's transaction is opened so no
+// transaction is held open while waiting.
+func (s *TradingService) awaitExecution(ctx context.Context) (time.Time, error) {
+	executedAt := s.fills.ExecutionTime(s.calendar.Now())
+	if err := s.fills.Wait(ctx, executedAt); err != nil {
+		return time.Time{}, err
+	}
+	return executedAt, nil
+}
+
+// executeMarketOrder executes a market order against the market depth at
+// executedAt. Each level it takes liquidity from is recorded as a separate
+// trade; quantity the book cannot absorb is left unfilled.
+func (s *TradingService) executeMarketOrder(ctx context.Context, tx *gorm.DB, order *models.Order, executedAt time.Time) error {
+	fills, err := s.fills.Simulate(ctx, tx, order, order.RemainingQty, executedAt)
 	if err != nil {
-		return fmt.Errorf("failed to get current price: %w", err)
+		return err
 	}
 
+	for _, fill := range fills {
+		if err := s.fillOrder(ctx, tx, order, fill.Quantity, fill.Price, fill.Time); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// matchQuote matches the order book for the quote's symbol and settles
+// every resulting fill in its own transaction. Orders the book gave up on
+// because their fills kept failing to settle are cancelled, so they do not
+// stay open without resting in the book.
+func (s *TradingService) matchQuote(ctx context.Context, quote *models.MarketQuote) {
+	_, evicted := s.matchingEngine.Match(quote, func(fill Fill) error {
+		err := s.transactionMgr.WithTransaction(ctx, func(tx *gorm.DB) error {
+			return s.applyFill(ctx, tx, fill)
+		})
+		if err != nil {
+			log.Printf("Failed to settle fill for order %s: %v", fill.OrderID, err)
+		}
+		return err
+	})
+
+	for _, orderID := range evicted {
+		log.Printf("Removed order %s from the order book after %d failed settlements", orderID, maxSettlementFailures)
+		if err := s.CancelOrderAsSystem(ctx, orderID); err != nil {
+			log.Printf("Failed to cancel order %s removed from the order book: %v", orderID, err)
+		}
+	}
+}
+
+// applyFill settles a fill produced by the matching engine
+func (s *TradingService) applyFill(ctx context.Context, tx *gorm.DB, fill Fill) error {
+	orderRepo := repository.NewOrderRepository(tx)
+	order, err := orderRepo.GetByIDForUpdate(ctx, fill.OrderID)
+	if err != nil {
+		return fmt.Errorf("failed to get order: %w", err)
+	}
+
+	if order == nil {
+		return fmt.Errorf("order not found")
+	}
+
+	if order.Status != models.OrderStatusOpen && order.Status != models.OrderStatusPartial {
+		return fmt.Errorf("cannot fill order with status: %s", order.Status)
+	}
+
+	return s.fillOrder(ctx, tx, order, fill.Quantity, fill.Price, fill.Time)
+}
+
+// fillOrder records an execution of quantity at price against an order,
+// moving it to PARTIAL or COMPLETED and settling holdings and wallet
+func (s *TradingService) fillOrder(ctx context.Context, tx *gorm.DB, order *models.Order, quantity int, price float64, executedAt time.Time) error {
+	if quantity <= 0 || quantity > order.RemainingQty {
+		return fmt.Errorf("invalid fill quantity %d for order %s", quantity, order.ID)
+	}
+
+	// Work out the charges for this execution
+	charges, err := s.charges.Calculate(order.Product, order.InstrumentType, order.Exchange, order.Side, quantity, price, executedAt)
+	if err != nil {
+		return fmt.Errorf("failed to calculate charges: %w", err)
+	}
+	orderCharges := models.ChargesBreakupFromJSON(order.Charges)
+	orderCharges.Add(charges)
+	order.Charges = orderCharges.ToJSON()
+
+	// Iceberg fills are numbered by the disclosed slice they belong to
+	var sliceNumber int
+	var replenished bool
+	if disclosed, visible := icebergSlice(order); disclosed > 0 {
+		sliceNumber = order.FilledQuantity/disclosed + 1
+		replenished = quantity >= visible && quantity < order.RemainingQty
+	}
+
+	// Update the volume weighted average execution price
+	var filledValue float64
+	if order.AvgExecutionPrice != nil {
+		filledValue = float64(order.FilledQuantity) * *order.AvgExecutionPrice
+	}
+	order.FilledQuantity += quantity
+	order.RemainingQty -= quantity
+	avgPrice := (filledValue + float64(quantity)*price) / float64(order.FilledQuantity)
+	order.AvgExecutionPrice = &avgPrice
+
 	// Record the execution
-	now := time.Now()
-	order.Status = "FILLED"
-	order.FilledQuantity = order.Quantity
-	order.RemainingQuantity = 0
-	order.ExecutedAt = &now
+	if order.RemainingQty == 0 {
+		order.Status = models.OrderStatusCompleted
+		order.ExecutedAt = &executedAt
+	} else {
+		order.Status = models.OrderStatusPartial
+	}
 
 	// Update order
 	orderRepo := repository.NewOrderRepository(tx)
@@ -240,48 +735,72 @@ func (s *TradingService) executeMarketOrder(ctx context.Context, tx *gorm.DB, or
 		return fmt.Errorf("failed to update order: %w", err)
 	}
 
-	// Create trade history
-	tradeHistRepo := repository.NewTradeHistoryRepository(tx)
-	tradeHistory := &models.TradeHistory{
-		ID:        uuid.New(),
-		UserID:    order.UserID,
-		OrderID:   order.ID,
-		Symbol:    order.Symbol,
-		Side:      order.Side,
-		Quantity:  order.Quantity,
-		Price:     marketPrice,
-		Timestamp: now,
-		Fee:       marketPrice * order.Quantity * 0.001, // 0.1% fee
+	// Create trade
+	tradeRepo := repository.NewTradeRepository(tx)
+	trade := &models.Trade{
+		ID:             uuid.New().String(),
+		OrderID:        order.ID,
+		UserID:         order.UserID,
+		Symbol:         order.Symbol,
+		Exchange:       order.Exchange,
+		Quantity:       quantity,
+		Price:          price,
+		Side:           order.Side,
+		Product:        order.Product,
+		InstrumentType: order.InstrumentType,
+		OrderTimestamp: order.CreatedAt,
+		TradeTimestamp: executedAt,
+		Charges:        charges.ToJSON(),
+		IcebergSlice:   sliceNumber,
+		Replenished:    replenished,
 	}
 
-	if err := tradeHistRepo.Create(ctx, tradeHistory); err != nil {
-		return fmt.Errorf("failed to create trade history: %w", err)
+	if err := tradeRepo.Create(ctx, trade); err != nil {
+		return fmt.Errorf("failed to create trade: %w", err)
 	}
+	s.emitFillEvents(tx, order, trade)
 
 	// Update portfolio holdings
-	if err := s.updateHoldings(ctx, tx, order, marketPrice); err != nil {
+	if err := s.updateHoldings(ctx, tx, order, quantity, price); err != nil {
 		return fmt.Errorf("failed to update holdings: %w", err)
 	}
 
 	// Update wallet
-	if err := s.finalizeWalletTransaction(ctx, tx, order, marketPrice, tradeHistory.Fee); err != nil {
+	if err := s.finalizeWalletTransaction(ctx, tx, order, quantity, price, charges); err != nil {
 		return fmt.Errorf("failed to finalize wallet transaction: %w", err)
 	}
 
-	return nil
+	// Activate or settle bracket and cover order legs
+	if err := s.onBracketFill(ctx, tx, order, quantity); err != nil {
+		return err
+	}
+
+	// Roll the fill up into a sliced order's parent
+	return s.onSliceChange(ctx, tx, order)
+}
+
+// GetOrderBook returns the resting limit orders for a symbol. If userID is
+// set only that user's orders are included.
+func (s *TradingService) GetOrderBook(ctx context.Context, exchange string, symbol string, userID string) *models.OrderBook {
+	return s.matchingEngine.Snapshot(exchange, symbol, userID)
 }
 
 // updateHoldings updates portfolio holdings after a trade
-func (s *TradingService) updateHoldings(ctx context.Context, tx *gorm.DB, order *models.Order, executionPrice float64) error {
+func (s *TradingService) updateHoldings(ctx context.Context, tx *gorm.DB, order *models.Order, quantity int, executionPrice float64) error {
+	// Intraday trades are tracked as positions until squared off
+	if isIntraday(order) {
+		return s.updatePosition(ctx, tx, order, quantity, executionPrice)
+	}
+
 	holdingRepo := repository.NewHoldingRepository(tx)
-	
+
 	// Get default portfolio
 	portfolioRepo := repository.NewPortfolioRepository(tx)
 	portfolios, err := portfolioRepo.GetByUserID(ctx, order.UserID)
 	if err != nil {
 		return fmt.Errorf("failed to get portfolios: %w", err)
 	}
-	
+
 	if len(portfolios) == 0 {
 		// Create default portfolio if none exists
 		defaultPortfolio := &models.Portfolio{
@@ -295,15 +814,15 @@ func (s *TradingService) updateHoldings(ctx context.Context, tx *gorm.DB, order
 		}
 		portfolios = append(portfolios, defaultPortfolio)
 	}
-	
+
 	defaultPortfolio := portfolios[0]
-	
+
 	// Get existing holding or create new one
 	holding, err := holdingRepo.GetByPortfolioIDAndSymbol(ctx, defaultPortfolio.ID, order.Symbol)
 	if err != nil {
 		return fmt.Errorf("failed to get holding: %w", err)
 	}
-	
+
 	if holding == nil {
 		// Create new holding
 		holding = &models.Holding{
@@ -312,29 +831,28 @@ func (s *TradingService) updateHoldings(ctx context.Context, tx *gorm.DB, order
 			Symbol:       order.Symbol,
 			Quantity:     0,
 			AveragePrice: 0,
-			LastUpdated:  time.Now(),
+			LastUpdated:  s.calendar.Now(),
 		}
 	}
-	
+
 	// Update holding based on order side
-	if order.Side == "BUY" {
+	if order.Side == models.OrderSideBuy {
 		// Calculate new average price
 		totalValue := holding.Quantity * holding.AveragePrice
-		newValue := order.Quantity * executionPrice
-		newTotalQuantity := holding.Quantity + order.Quantity
-		
+		newValue := float64(quantity) * executionPrice
+		newTotalQuantity := holding.Quantity + float64(quantity)
+
 		if newTotalQuantity > 0 {
 			holding.AveragePrice = (totalValue + newValue) / newTotalQuantity
 		}
-		
+
 		holding.Quantity = newTotalQuantity
-	} else { // SELL
-		// Just reduce quantity for sells
-		holding.Quantity -= order.Quantity
 	}
-	
-	holding.LastUpdated = time.Now()
-	
+	// Sold securities were already taken out of the holding when the order
+	// was reserved, so sells leave the quantity unchanged
+
+	holding.LastUpdated = s.calendar.Now()
+
 	// Save or update holding
 	if holding.ID == uuid.Nil {
 		if err := holdingRepo.Create(ctx, holding); err != nil {
@@ -345,152 +863,594 @@ func (s *TradingService) updateHoldings(ctx context.Context, tx *gorm.DB, order
 			return fmt.Errorf("failed to update holding: %w", err)
 		}
 	}
-	
+
 	return nil
 }
 
 // finalizeWalletTransaction updates the wallet after a trade is executed
-func (s *TradingService) finalizeWalletTransaction(ctx context.Context, tx *gorm.DB, order *models.Order, executionPrice float64, fee float64) error {
+func (s *TradingService) finalizeWalletTransaction(ctx context.Context, tx *gorm.DB, order *models.Order, quantity int, executionPrice float64, charges *models.ChargesBreakup) error {
 	walletRepo := repository.NewWalletRepository(tx)
 	wallet, err := walletRepo.GetByUserID(ctx, order.UserID)
 	if err != nil {
 		return fmt.Errorf("failed to get wallet: %w", err)
 	}
-	
+
 	if wallet == nil {
 		return fmt.Errorf("wallet not found for user")
 	}
-	
+
 	// Create transaction record
 	transactionRepo := repository.NewTransactionRepository(tx)
-	
-	if order.Side == "BUY" {
+	fee := charges.Total
+
+	if order.Side == models.OrderSideBuy {
 		// For buy orders, move from hold balance to final transaction
-		totalCost := executionPrice * order.Quantity + fee
-		
-		// Adjust hold balance (might be different from actual execution price)
-		wallet.HoldBalance -= totalCost
-		
+		totalCost := executionPrice*float64(quantity) + fee
+
+		// Draw the cost from the order's reservation. A market order that
+		// fills above its buffered estimate pays the excess from the
+		// available balance, and whatever is left once the order completes
+		// goes back to it.
+		consumed, leftover, err := s.consumeReservation(ctx, tx, order, totalCost, quantity)
+		if err != nil {
+			return err
+		}
+		wallet.HoldBalance -= consumed + leftover
+		wallet.Balance += leftover - (totalCost - consumed)
+
 		// Create transaction record
 		transaction := &models.Transaction{
 			ID:          uuid.New(),
 			WalletID:    wallet.ID,
 			Type:        "TRADE",
 			Amount:      -totalCost,
-			Description: fmt.Sprintf("Buy %f shares of %s at %f", order.Quantity, order.Symbol, executionPrice),
+			Description: fmt.Sprintf("Buy %d shares of %s at %f", quantity, order.Symbol, executionPrice),
 			Status:      "COMPLETED",
 			OrderID:     &order.ID,
-			CreatedAt:   time.Now(),
-			UpdatedAt:   time.Now(),
+			CreatedAt:   s.calendar.Now(),
+			UpdatedAt:   s.calendar.Now(),
 		}
-		
+
 		if err := transactionRepo.Create(ctx, transaction); err != nil {
 			return fmt.Errorf("failed to create transaction: %w", err)
 		}
-		
+
 		// Create fee transaction
 		feeTransaction := &models.Transaction{
-			ID:          uuid.New(),
-			WalletID:    wallet.ID,
-			Type:        "FEE",
-			Amount:      -fee,
-			Description: fmt.Sprintf("Fee for buy order %s", order.ID),
-			Status:      "COMPLETED",
-			OrderID:     &order.ID,
-			CreatedAt:   time.Now(),
-			UpdatedAt:   time.Now(),
+			ID:             uuid.New(),
+			WalletID:       wallet.ID,
+			Type:           "FEE",
+			Amount:         -fee,
+			Description:    fmt.Sprintf("Fee for buy order %s", order.ID),
+			Status:         "COMPLETED",
+			OrderID:        &order.ID,
+			CreatedAt:      s.calendar.Now(),
+			UpdatedAt:      s.calendar.Now(),
+			ChargesBreakup: charges.ToJSON(),
 		}
-		
+
 		if err := transactionRepo.Create(ctx, feeTransaction); err != nil {
 			return fmt.Errorf("failed to create fee transaction: %w", err)
 		}
 	} else { // SELL
+		// The margin for any quantity sold short moves from the order's
+		// reservation onto the position
+		shortQty, err := s.shortFillQuantity(ctx, tx, order, quantity)
+		if err != nil {
+			return err
+		}
+
+		// The sold securities come out of the order's reservation
+		margin, leftover, err := s.consumeReservation(ctx, tx, order, shortMargin(shortQty, executionPrice), quantity)
+		if err != nil {
+			return err
+		}
+		if margin > 0 {
+			if err := s.blockShortMargin(ctx, tx, order, margin); err != nil {
+				return err
+			}
+		}
+		wallet.HoldBalance -= leftover
+		wallet.Balance += leftover
+
 		// For sell orders, add funds to wallet
-		totalAmount := executionPrice * order.Quantity - fee
+		totalAmount := executionPrice*float64(quantity) - fee
 		wallet.Balance += totalAmount
-		
+
 		// Create transaction record
 		transaction := &models.Transaction{
 			ID:          uuid.New(),
 			WalletID:    wallet.ID,
 			Type:        "TRADE",
 			Amount:      totalAmount,
-			Description: fmt.Sprintf("Sell %f shares of %s at %f", order.Quantity, order.Symbol, executionPrice),
+			Description: fmt.Sprintf("Sell %d shares of %s at %f", quantity, order.Symbol, executionPrice),
 			Status:      "COMPLETED",
 			OrderID:     &order.ID,
-			CreatedAt:   time.Now(),
-			UpdatedAt:   time.Now(),
+			CreatedAt:   s.calendar.Now(),
+			UpdatedAt:   s.calendar.Now(),
 		}
-		
+
 		if err := transactionRepo.Create(ctx, transaction); err != nil {
 			return fmt.Errorf("failed to create transaction: %w", err)
 		}
-		
+
 		// Create fee transaction
 		feeTransaction := &models.Transaction{
-			ID:          uuid.New(),
-			WalletID:    wallet.ID,
-			Type:        "FEE",
-			Amount:      -fee,
-			Description: fmt.Sprintf("Fee for sell order %s", order.ID),
-			Status:      "COMPLETED",
-			OrderID:     &order.ID,
-			CreatedAt:   time.Now(),
-			UpdatedAt:   time.Now(),
+			ID:             uuid.New(),
+			WalletID:       wallet.ID,
+			Type:           "FEE",
+			Amount:         -fee,
+			Description:    fmt.Sprintf("Fee for sell order %s", order.ID),
+			Status:         "COMPLETED",
+			OrderID:        &order.ID,
+			CreatedAt:      s.calendar.Now(),
+			UpdatedAt:      s.calendar.Now(),
+			ChargesBreakup: charges.ToJSON(),
 		}
-		
+
 		if err := transactionRepo.Create(ctx, feeTransaction); err != nil {
 			return fmt.Errorf("failed to create fee transaction: %w", err)
 		}
 	}
-	
+
 	// Update wallet
 	if err := walletRepo.Update(ctx, wallet); err != nil {
 		return fmt.Errorf("failed to update wallet: %w", err)
 	}
-	
+
 	return nil
 }
 
 // CancelOrder cancels an order
-func (s *TradingService) CancelOrder(ctx context.Context, orderID uuid.UUID, userID uuid.UUID) error {
+func (s *TradingService) CancelOrder(ctx context.Context, orderID string, userID string) error {
+	if userID == "" {
+		return fmt.Errorf("user is required to cancel an order")
+	}
+	return s.cancelOrder(ctx, orderID, userID, userID)
+}
+
+// CancelOrderAsSystem cancels an order on behalf of the platform, such as
+// during intraday square-off
+func (s *TradingService) CancelOrderAsSystem(ctx context.Context, orderID string) error {
+	return s.cancelOrder(ctx, orderID, "", "system")
+}
+
+// cancelOrder cancels an order. If userID is set the order must belong to
+// that user.
+func (s *TradingService) cancelOrder(ctx context.Context, orderID string, userID string, cancelledBy string) error {
 	return s.transactionMgr.WithTransaction(ctx, func(tx *gorm.DB) error {
 		orderRepo := repository.NewOrderRepository(tx)
-		order, err := orderRepo.GetByID(ctx, orderID)
+		order, err := orderRepo.GetByIDForUpdate(ctx, orderID)
 		if err != nil {
 			return fmt.Errorf("failed to get order: %w", err)
 		}
-		
+
 		if order == nil {
 			return fmt.Errorf("order not found")
 		}
-		
+
 		// Verify order belongs to user
-		if order.UserID != userID {
+		if userID != "" && order.UserID != userID {
 			return fmt.Errorf("order does not belong to user")
 		}
-		
+
 		// Check if order can be cancelled
-		if order.Status != "PENDING" {
+		switch order.Status {
+		case models.OrderStatusPending, models.OrderStatusOpen, models.OrderStatusPartial, models.OrderStatusQueued:
+		default:
 			return fmt.Errorf("cannot cancel order with status: %s", order.Status)
 		}
-		
+
+		// Cancelling a sliced order cancels all of its open slices
+		if isSliceParent(order) {
+			return s.cancelSlices(ctx, tx, order, cancelledBy)
+		}
+
+		holdsReservation := s.holdsReservation(order)
+
 		// Update order status
-		now := time.Now()
-		order.Status = "CANCELLED"
+		now := s.calendar.Now()
+		order.Status = models.OrderStatusCancelled
 		order.CancelledAt = &now
-		
+		order.CancelledBy = cancelledBy
+
 		if err := orderRepo.Update(ctx, order); err != nil {
 			return fmt.Errorf("failed to update order: %w", err)
 		}
-		
+		s.emitOrderEvent(tx, OrderCancelled, order, nil)
+
+		// Take the order out of the book and trigger index once the
+		// cancellation is committed
+		s.untrackAfterCommit(tx, order)
+
 		// Release reserved funds or securities
-		if order.Side == "BUY" {
-			return s.releaseReservedFunds(ctx, tx, order)
-		} else {
-			return s.releaseReservedSecurities(ctx, tx, order)
+		if holdsReservation {
+			if err := s.releaseReservation(ctx, tx, order); err != nil {
+				return err
+			}
+		}
+
+		// Cancel the rest of a bracket or cover order
+		if _, err := s.closeBracketLegs(ctx, tx, order, models.OrderStatusCancelled); err != nil {
+			return err
+		}
+
+		return s.onSliceChange(ctx, tx, order)
+	})
+}
+
+// ModifyOrder changes the quantity, price or trigger price of an open order.
+// The amended order goes through the pre-trade risk checks, the reservation
+// is adjusted by the difference in the same transaction and the change is
+// recorded in the order's amendment history.
+func (s *TradingService) ModifyOrder(ctx context.Context, orderID string, userID string, req *models.ModifyOrderRequest) (*models.Order, error) {
+	// The risk checks run before the order is locked, since a rule may halt
+	// the user and cancel their open orders
+	if err := s.checkAmendment(ctx, orderID, userID, req); err != nil {
+		return nil, err
+	}
+
+	var modified *models.Order
+	err := s.transactionMgr.WithTransaction(ctx, func(tx *gorm.DB) error {
+		orderRepo := repository.NewOrderRepository(tx)
+		order, err := orderRepo.GetByIDForUpdate(ctx, orderID)
+		if err != nil {
+			return fmt.Errorf("failed to get order: %w", err)
+		}
+
+		if order == nil {
+			return fmt.Errorf("order not found")
+		}
+
+		// Verify order belongs to user
+		if order.UserID != userID {
+			return fmt.Errorf("order does not belong to user")
+		}
+
+		// Halted orders can still be cancelled but not modified
+		if rejection := s.killSwitch.Check(order); rejection != nil {
+			return rejection
+		}
+
+		// Check if order can be modified
+		switch order.Status {
+		case models.OrderStatusPending, models.OrderStatusOpen, models.OrderStatusPartial, models.OrderStatusInactive, models.OrderStatusQueued:
+		default:
+			return fmt.Errorf("cannot modify order with status: %s", order.Status)
+		}
+
+		// Apply the changes to a copy so the reservation delta can be
+		// computed against the original
+		updated, err := amendOrder(order, req)
+		if err != nil {
+			return err
+		}
+
+		if err := s.validateOrder(ctx, updated); err != nil {
+			return err
+		}
+
+		// Move the reservation by the difference between old and new
+		if s.holdsReservation(order) {
+			if err := s.adjustReservation(ctx, tx, order, updated); err != nil {
+				return err
+			}
+		}
+
+		if err := orderRepo.Update(ctx, updated); err != nil {
+			return fmt.Errorf("failed to update order: %w", err)
+		}
+		s.emitOrderEvent(tx, OrderModified, updated, nil)
+
+		amendment := &models.OrderAmendment{
+			ID:              uuid.New().String(),
+			OrderID:         order.ID,
+			UserID:          userID,
+			OldQuantity:     order.Quantity,
+			NewQuantity:     updated.Quantity,
+			OldPrice:        order.Price,
+			NewPrice:        updated.Price,
+			OldTriggerPrice: order.TriggerPrice,
+			NewTriggerPrice: updated.TriggerPrice,
+			FilledQuantity:  order.FilledQuantity,
+			AmendedBy:       userID,
+			CreatedAt:       s.calendar.Now(),
+		}
+		if err := orderRepo.CreateAmendment(ctx, amendment); err != nil {
+			return fmt.Errorf("failed to record amendment: %w", err)
+		}
+
+		// Inactive legs of a bracket or cover entry follow its quantity
+		if isBracketVariety(order) && order.ParentOrderID == nil && updated.Quantity != order.Quantity {
+			legs, err := orderRepo.GetByParentID(ctx, order.ID)
+			if err != nil {
+				return fmt.Errorf("failed to get %s legs: %w", order.Variety, err)
+			}
+			for _, leg := range legs {
+				if leg.Status != models.OrderStatusInactive {
+					continue
+				}
+				leg.Quantity = updated.Quantity
+				leg.RemainingQty = updated.Quantity
+				if err := orderRepo.Update(ctx, leg); err != nil {
+					return fmt.Errorf("failed to resize leg: %w", err)
+				}
+				s.emitOrderEvent(tx, OrderModified, leg, nil)
+			}
+		}
+
+		// A sliced order's quantity follows its slices
+		if updated.Quantity != order.Quantity {
+			if err := s.onSliceChange(ctx, tx, updated); err != nil {
+				return err
+			}
+		}
+
+		modified = updated
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// Re-queue the order in the book or trigger index
+	switch {
+	case modified.Status == models.OrderStatusInactive, modified.Status == models.OrderStatusQueued:
+	case modified.Type == models.OrderTypeLimit:
+		if err := s.matchingEngine.Amend(modified); err != nil {
+			return nil, fmt.Errorf("failed to amend order in book: %w", err)
+		}
+		if quote, err := s.marketData.GetQuote(modified.Symbol, modified.Exchange); err == nil && quote != nil {
+			s.matchQuote(ctx, quote)
+		}
+		if updated, err := s.orderRepo.GetByID(ctx, modified.ID); err == nil && updated != nil {
+			modified = updated
+		}
+	case modified.Status == models.OrderStatusPending:
+		s.stopMonitor.Untrack(modified.ID)
+		if err := s.stopMonitor.Track(modified); err != nil {
+			return nil, fmt.Errorf("failed to track stop order: %w", err)
+		}
+	}
+
+	return modified, nil
+}
+
+// checkAmendment runs the pre-trade risk checks against an order as it
+// would stand after an amendment. An open order cannot be sliced, so
+// amendments taking it above what one order may hold are rejected. As at
+// placement, the risk chain is skipped for system orders.
+func (s *TradingService) checkAmendment(ctx context.Context, orderID string, userID string, req *models.ModifyOrderRequest) error {
+	order, err := s.orderRepo.GetByID(ctx, orderID)
+	if err != nil {
+		return fmt.Errorf("failed to get order: %w", err)
+	}
+
+	if order == nil {
+		return fmt.Errorf("order not found")
+	}
+
+	if order.UserID != userID {
+		return fmt.Errorf("order does not belong to user")
+	}
+
+	amended, err := amendOrder(order, req)
+	if err != nil {
+		return err
+	}
+
+	if size := s.sliceSize(amended); size > 0 && amended.Quantity > size {
+		return fmt.Errorf("quantity %d exceeds the %d that can be placed in one order", amended.Quantity, size)
+	}
+
+	if amended.PlacedBy == "system" {
+		return nil
+	}
+	return s.risk.CheckAmendment(ctx, amended)
+}
+
+// amendOrder returns a copy of an order with a modification request applied
+func amendOrder(order *models.Order, req *models.ModifyOrderRequest) (*models.Order, error) {
+	if order.Type == models.OrderTypeMarket {
+		return nil, fmt.Errorf("market orders cannot be modified")
+	}
+
+	if isSliceParent(order) {
+		return nil, fmt.Errorf("sliced orders are modified one slice at a time")
+	}
+
+	updated := *order
+	if req.Quantity != nil {
+		if isBracketVariety(order) && order.ParentOrderID != nil {
+			return nil, fmt.Errorf("the quantity of %s legs follows the entry order", order.Variety)
+		}
+		if *req.Quantity <= order.FilledQuantity {
+			return nil, fmt.Errorf("new quantity %d must exceed the filled quantity %d", *req.Quantity, order.FilledQuantity)
+		}
+		updated.Quantity = *req.Quantity
+		updated.RemainingQty = *req.Quantity - order.FilledQuantity
+	}
+	if req.Price != nil {
+		if order.Type == models.OrderTypeStopLoss || order.Type == models.OrderTypeTrailingStop {
+			return nil, fmt.Errorf("stop-loss orders do not take a limit price")
+		}
+		price := *req.Price
+		updated.Price = &price
+	}
+	if req.TriggerPrice != nil {
+		if order.Type == models.OrderTypeTrailingStop {
+			return nil, fmt.Errorf("trailing stops move their own trigger price")
+		}
+		if order.Type != models.OrderTypeStopLoss && order.Type != models.OrderTypeStopLimit {
+			return nil, fmt.Errorf("only stop orders take a trigger price")
+		}
+		triggerPrice := *req.TriggerPrice
+		updated.TriggerPrice = &triggerPrice
+	}
+	return &updated, nil
+}
+
+// adjustReservation moves an order's reservation from what the original
+// order holds to what the modified order needs
+func (s *TradingService) adjustReservation(ctx context.Context, tx *gorm.DB, original *models.Order, modified *models.Order) error {
+	reservation, err := s.reservationFor(ctx, tx, original)
+	if err != nil {
+		return err
+	}
+
+	// Intraday sells are reserved afresh since the split between closing the
+	// position and selling short depends on the new quantity
+	if original.Side == models.OrderSideSell && isIntraday(original) {
+		if err := s.releaseReservedSecurities(ctx, tx, original); err != nil {
+			return err
+		}
+		return s.reserveIntradaySell(ctx, tx, modified)
+	}
+
+	if original.Side == models.OrderSideSell {
+		held := original.RemainingQty
+		if reservation != nil {
+			held = reservation.Quantity
+		}
+		delta := modified.RemainingQty - held
+		switch {
+		case delta > 0:
+			if err := s.takeSecurities(ctx, tx, modified, delta); err != nil {
+				return err
+			}
+		case delta < 0:
+			if err := s.returnSecurities(ctx, tx, modified, -delta); err != nil {
+				return err
+			}
+		default:
+			return nil
 		}
+		return s.recordReservation(ctx, tx, modified, 0, delta)
+	}
+
+	var held float64
+	if reservation != nil {
+		held = reservation.Amount
+	} else if held, err = s.requiredFunds(ctx, original); err != nil {
+		return err
+	}
+	required, err := s.requiredFunds(ctx, modified)
+	if err != nil {
+		return err
+	}
+	delta := required - held
+	if delta == 0 {
+		return nil
+	}
+
+	walletRepo := repository.NewWalletRepository(tx)
+	wallet, err := walletRepo.GetByUserID(ctx, original.UserID)
+	if err != nil {
+		return fmt.Errorf("failed to get wallet: %w", err)
+	}
+
+	if wallet == nil {
+		return fmt.Errorf("wallet not found for user")
+	}
+
+	if delta > 0 && wallet.Balance < delta {
+		return fmt.Errorf("insufficient funds: required %.2f, available %.2f", delta, wallet.Balance)
+	}
+
+	wallet.Balance -= delta
+	wallet.HoldBalance += delta
+
+	if err := walletRepo.Update(ctx, wallet); err != nil {
+		return fmt.Errorf("failed to update wallet: %w", err)
+	}
+
+	return s.recordReservation(ctx, tx, modified, delta, 0)
+}
+
+// ExpirableOrders returns the open orders whose validity runs out at a
+// session close
+func (s *TradingService) ExpirableOrders(ctx context.Context) ([]*models.Order, error) {
+	return s.orderRepo.GetByStatusAndValidity(ctx,
+		[]models.OrderStatus{models.OrderStatusPending, models.OrderStatusOpen, models.OrderStatusPartial},
+		[]models.OrderValidity{models.OrderValidityDay, models.OrderValidityGTD},
+	)
+}
+
+// ExpireOrder expires an open order, releasing whatever is still reserved
+// for its unfilled quantity. Filled quantity is kept.
+func (s *TradingService) ExpireOrder(ctx context.Context, orderID string, reason string) error {
+	var expired []*models.Order
+	err := s.transactionMgr.WithTransaction(ctx, func(tx *gorm.DB) error {
+		orderRepo := repository.NewOrderRepository(tx)
+		order, err := orderRepo.GetByIDForUpdate(ctx, orderID)
+		if err != nil {
+			return fmt.Errorf("failed to get order: %w", err)
+		}
+
+		if order == nil || isTerminalStatus(order.Status) {
+			return errOrderNotExpirable
+		}
+
+		holdsReservation := s.holdsReservation(order)
+
+		order.Status = models.OrderStatusExpired
+		order.Remarks = reason
+		if err := orderRepo.Update(ctx, order); err != nil {
+			return fmt.Errorf("failed to update order: %w", err)
+		}
+		s.emitOrderEvent(tx, OrderExpired, order, nil)
+		s.untrackAfterCommit(tx, order)
+
+		if holdsReservation {
+			if err := s.releaseReservation(ctx, tx, order); err != nil {
+				return err
+			}
+		}
+		expired = append(expired, order)
+
+		// Expire the rest of a bracket or cover order
+		legs, err := s.closeBracketLegs(ctx, tx, order, models.OrderStatusExpired)
+		if err != nil {
+			return err
+		}
+		expired = append(expired, legs...)
+
+		return s.onSliceChange(ctx, tx, order)
 	})
+	if err != nil {
+		return err
+	}
+
+	for _, order := range expired {
+		s.notifyOrderExpired(order)
+	}
+	return nil
+}
+
+// OnOrderExpired registers a callback for expired orders
+func (s *TradingService) OnOrderExpired(callback func(order *models.Order)) {
+	s.callbackMutex.Lock()
+	defer s.callbackMutex.Unlock()
+	s.expiredCallbacks = append(s.expiredCallbacks, callback)
+}
+
+// notifyOrderExpired notifies every expiry callback
+func (s *TradingService) notifyOrderExpired(order *models.Order) {
+	s.callbackMutex.RLock()
+	defer s.callbackMutex.RUnlock()
+
+	for _, callback := range s.expiredCallbacks {
+		go callback(order)
+	}
+}
+
+// releaseReservation releases the funds or securities held for an order's
+// unfilled quantity
+func (s *TradingService) releaseReservation(ctx context.Context, tx *gorm.DB, order *models.Order) error {
+	if order.Side == models.OrderSideBuy {
+		return s.releaseReservedFunds(ctx, tx, order)
+	}
+	return s.releaseReservedSecurities(ctx, tx, order)
 }
 
 // releaseReservedFunds releases funds reserved for a buy order
@@ -500,38 +1460,36 @@ func (s *TradingService) releaseReservedFunds(ctx context.Context, tx *gorm.DB,
 	if err != nil {
 		return fmt.Errorf("failed to get wallet: %w", err)
 	}
-	
+
 	if wallet == nil {
 		return fmt.Errorf("wallet not found for user")
 	}
-	
-	// Calculate reserved amount
-	var reservedAmount float64
-	if order.OrderType == "MARKET" {
-		// For market orders, estimate was current price plus buffer
-		marketPrice, err := s.marketData.GetCurrentPrice(ctx, order.Symbol)
+
+	// Release exactly what the ledger still holds for the order
+	reservedAmount, _, ok, err := s.closeReservation(ctx, tx, order)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		// Orders placed before the ledger existed fall back to an estimate
+		reservedAmount, err = s.requiredFunds(ctx, order)
 		if err != nil {
-			return fmt.Errorf("failed to get current price: %w", err)
+			return err
 		}
-		reservedAmount = marketPrice * order.Quantity * 1.05 // 5% buffer
-	} else if order.OrderType == "LIMIT" {
-		reservedAmount = *order.Price * order.Quantity
-	} else {
-		reservedAmount = *order.StopPrice * order.Quantity
 	}
-	
-	// Add estimated fees
-	reservedAmount += reservedAmount * 0.001
-	
+	if reservedAmount == 0 {
+		return nil
+	}
+
 	// Return funds from hold to available balance
 	wallet.HoldBalance -= reservedAmount
 	wallet.Balance += reservedAmount
-	
+
 	// Update wallet
 	if err := walletRepo.Update(ctx, wallet); err != nil {
 		return fmt.Errorf("failed to update wallet: %w", err)
 	}
-	
+
 	// Create transaction record
 	transactionRepo := repository.NewTransactionRepository(tx)
 	transaction := &models.Transaction{
@@ -542,52 +1500,137 @@ func (s *TradingService) releaseReservedFunds(ctx context.Context, tx *gorm.DB,
 		Description: fmt.Sprintf("Refund for cancelled order %s", order.ID),
 		Status:      "COMPLETED",
 		OrderID:     &order.ID,
-		CreatedAt:   time.Now(),
-		UpdatedAt:   time.Now(),
+		CreatedAt:   s.calendar.Now(),
+		UpdatedAt:   s.calendar.Now(),
 	}
-	
+
 	if err := transactionRepo.Create(ctx, transaction); err != nil {
 		return fmt.Errorf("failed to create transaction: %w", err)
 	}
-	
+
 	return nil
 }
 
+// requiredFunds returns the funds to hold for a buy order's unfilled
+// quantity, including the charges it will attract
+func (s *TradingService) requiredFunds(ctx context.Context, order *models.Order) (float64, error) {
+	price, err := s.estimatedPrice(ctx, order)
+	if err != nil {
+		return 0, err
+	}
+
+	// Add estimated charges
+	charges, err := s.charges.Calculate(order.Product, order.InstrumentType, order.Exchange, order.Side, order.RemainingQty, price, s.calendar.Now())
+	if err != nil {
+		return 0, fmt.Errorf("failed to calculate charges: %w", err)
+	}
+	return price*float64(order.RemainingQty) + charges.Total, nil
+}
+
+// estimatedPrice returns the price to reserve an order's unfilled quantity at
+func (s *TradingService) estimatedPrice(ctx context.Context, order *models.Order) (float64, error) {
+	if order.Type == models.OrderTypeMarket {
+		// For market orders, use current price plus a buffer
+		marketPrice, err := s.marketData.GetCurrentPrice(ctx, order.Symbol)
+		if err != nil {
+			return 0, fmt.Errorf("failed to get current price: %w", err)
+		}
+		return marketPrice * 1.05, nil // 5% buffer
+	}
+	if order.Price != nil {
+		return *order.Price, nil
+	}
+	return *order.TriggerPrice, nil
+}
+
+// PreviewCharges returns the charges a trade would attract if it executed
+// now. Without a price the current market price is used.
+func (s *TradingService) PreviewCharges(ctx context.Context, req *models.ChargesRequest) (*models.ChargesBreakup, error) {
+	if req.Side != models.OrderSideBuy && req.Side != models.OrderSideSell {
+		return nil, fmt.Errorf("invalid order side: %s", req.Side)
+	}
+
+	var price float64
+	if req.Price != nil {
+		price = *req.Price
+	} else {
+		marketPrice, err := s.marketData.GetCurrentPrice(ctx, req.Symbol)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get current price: %w", err)
+		}
+		price = marketPrice
+	}
+
+	if price <= 0 || req.Quantity <= 0 {
+		return nil, fmt.Errorf("invalid charges parameters")
+	}
+
+	return s.charges.Calculate(req.Product, req.InstrumentType, req.Exchange, req.Side, req.Quantity, price, s.calendar.Now())
+}
+
 // releaseReservedSecurities releases securities reserved for a sell order
 func (s *TradingService) releaseReservedSecurities(ctx context.Context, tx *gorm.DB, order *models.Order) error {
+	// Release exactly what the ledger still holds for the order
+	margin, quantity, ok, err := s.closeReservation(ctx, tx, order)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		// Orders placed before the ledger existed fall back to the unfilled quantity
+		quantity = order.RemainingQty
+	}
+
+	// Short sales hold margin rather than securities for what they sell short
+	if err := s.releaseFunds(ctx, tx, order.UserID, margin); err != nil {
+		return err
+	}
+	if quantity == 0 {
+		return nil
+	}
+
+	return s.returnSecurities(ctx, tx, order, quantity)
+}
+
+// returnSecurities puts quantity of an order's symbol back into the user's
+// default portfolio
+func (s *TradingService) returnSecurities(ctx context.Context, tx *gorm.DB, order *models.Order, quantity int) error {
+	if isIntraday(order) {
+		return s.releasePositionQuantity(ctx, tx, order, quantity)
+	}
+
 	holdingRepo := repository.NewHoldingRepository(tx)
-	
+
 	// Get default portfolio
 	portfolioRepo := repository.NewPortfolioRepository(tx)
 	portfolios, err := portfolioRepo.GetByUserID(ctx, order.UserID)
 	if err != nil {
 		return fmt.Errorf("failed to get portfolios: %w", err)
 	}
-	
+
 	if len(portfolios) == 0 {
 		return fmt.Errorf("no portfolios found for user")
 	}
-	
+
 	defaultPortfolio := portfolios[0]
-	
+
 	// Get holding
 	holding, err := holdingRepo.GetByPortfolioIDAndSymbol(ctx, defaultPortfolio.ID, order.Symbol)
 	if err != nil {
 		return fmt.Errorf("failed to get holding: %w", err)
 	}
-	
+
 	if holding == nil {
 		return fmt.Errorf("holding not found")
 	}
-	
-	// Return securities
-	holding.Quantity += order.Quantity
-	holding.LastUpdated = time.Now()
-	
+
+	// Return the unfilled securities
+	holding.Quantity += float64(quantity)
+	holding.LastUpdated = s.calendar.Now()
+
 	// Update holding
 	if err := holdingRepo.Update(ctx, holding); err != nil {
 		return fmt.Errorf("failed to update holding: %w", err)
 	}
-	
+
 	return nil
 }
@@ -0,0 +1,125 @@
+// stock-trading-app/backend/internal/services/trailing_stop.go
+
+package services
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/shyamanurag/stock-trading-app/backend/internal/models"
+	"github.com/shyamanurag/stock-trading-app/backend/internal/repository"
+	"gorm.io/gorm"
+)
+
+// Trailing stops are stop-loss orders whose trigger trails the best price
+// seen since placement by a fixed amount or percentage. A sell trailing stop
+// protects a long position and its trigger only moves up; a buy trailing
+// stop protects a short and its trigger only moves down. Every move is saved
+// on the order and in its amendment history, and a breach fires a market
+// order like any other stop-loss.
+
+// trailingTrigger returns the trigger price a trailing stop should have for
+// the given best price
+func trailingTrigger(side models.OrderSide, anchor float64, amount float64, percent float64) float64 {
+	distance := amount
+	if percent > 0 {
+		distance = anchor * percent / 100
+	}
+
+	if side == models.OrderSideBuy {
+		return roundPaise(anchor + distance)
+	}
+	return roundPaise(anchor - distance)
+}
+
+// validateTrailingStop checks a trailing stop's trail
+func validateTrailingStop(order *models.Order) error {
+	hasAmount := order.TrailAmount != nil
+	hasPercent := order.TrailPercent != nil
+	if hasAmount == hasPercent {
+		return fmt.Errorf("trailing stops require either a trail amount or a trail percentage")
+	}
+	if hasAmount && *order.TrailAmount <= 0 {
+		return fmt.Errorf("trail amount must be positive")
+	}
+	if hasPercent && (*order.TrailPercent <= 0 || *order.TrailPercent >= 100) {
+		return fmt.Errorf("trail percentage must be between 0 and 100")
+	}
+	if order.Price != nil {
+		return fmt.Errorf("trailing stops do not take a limit price")
+	}
+	return nil
+}
+
+// initTrailingStop anchors a new trailing stop at the current price and sets
+// its first trigger
+func (s *TradingService) initTrailingStop(ctx context.Context, order *models.Order) error {
+	if err := validateTrailingStop(order); err != nil {
+		return err
+	}
+
+	lastPrice, err := s.marketData.GetCurrentPrice(ctx, order.Symbol)
+	if err != nil {
+		return fmt.Errorf("failed to get current price: %w", err)
+	}
+
+	var amount, percent float64
+	if order.TrailAmount != nil {
+		amount = *order.TrailAmount
+	}
+	if order.TrailPercent != nil {
+		percent = *order.TrailPercent
+	}
+
+	triggerPrice := trailingTrigger(order.Side, lastPrice, amount, percent)
+	if triggerPrice <= 0 {
+		return fmt.Errorf("trail is larger than the current price %.2f", lastPrice)
+	}
+
+	order.TrailAnchor = &lastPrice
+	order.TriggerPrice = &triggerPrice
+	return nil
+}
+
+// TrailStopOrder saves a trailing stop's new trigger and best price and
+// records the move in the order's amendment history
+func (s *TradingService) TrailStopOrder(ctx context.Context, orderID string, triggerPrice float64, anchorPrice float64) error {
+	return s.transactionMgr.WithTransaction(ctx, func(tx *gorm.DB) error {
+		orderRepo := repository.NewOrderRepository(tx)
+		order, err := orderRepo.GetByIDForUpdate(ctx, orderID)
+		if err != nil {
+			return fmt.Errorf("failed to get order: %w", err)
+		}
+
+		if order == nil || order.Status != models.OrderStatusPending || order.Type != models.OrderTypeTrailingStop {
+			return errOrderNotTriggerable
+		}
+
+		oldTriggerPrice := order.TriggerPrice
+		order.TriggerPrice = &triggerPrice
+		order.TrailAnchor = &anchorPrice
+		if err := orderRepo.Update(ctx, order); err != nil {
+			return fmt.Errorf("failed to update order: %w", err)
+		}
+		s.emitOrderEvent(tx, OrderModified, order, nil)
+
+		amendment := &models.OrderAmendment{
+			ID:              uuid.New().String(),
+			OrderID:         order.ID,
+			UserID:          order.UserID,
+			OldQuantity:     order.Quantity,
+			NewQuantity:     order.Quantity,
+			OldTriggerPrice: oldTriggerPrice,
+			NewTriggerPrice: order.TriggerPrice,
+			FilledQuantity:  order.FilledQuantity,
+			AmendedBy:       "system",
+			CreatedAt:       s.calendar.Now(),
+		}
+		if err := orderRepo.CreateAmendment(ctx, amendment); err != nil {
+			return fmt.Errorf("failed to record amendment: %w", err)
+		}
+
+		return nil
+	})
+}
@@ -0,0 +1,401 @@
+// stock-trading-app/backend/internal/services/websocket_feed.go
+
+package services
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/shyamanurag/stock-trading-app/backend/internal/models"
+)
+
+// webSocketFeed streams quotes and depth from the market data provider over
+// a WebSocket and fetches snapshots, history and reference data over REST
+type webSocketFeed struct {
+	apiURL          string
+	apiKey          string
+	httpClient      *http.Client
+	wsConn          *websocket.Conn
+	isConnected     bool
+	subscriptions   map[string]bool
+	onQuote         func(quote *models.MarketQuote)
+	onDepth         func(depth *models.MarketDepth)
+	mutex           sync.RWMutex
+	reconnectTicker *time.Ticker
+	done            chan struct{}
+}
+
+// NewWebSocketFeed creates a live feed for the provider at apiURL
+func NewWebSocketFeed(apiURL string, apiKey string, timeout time.Duration) MarketDataFeed {
+	if timeout <= 0 {
+		timeout = 5 * time.Second
+	}
+
+	return &webSocketFeed{
+		apiURL:        strings.TrimRight(apiURL, "/"),
+		apiKey:        apiKey,
+		httpClient:    &http.Client{Timeout: timeout},
+		subscriptions: make(map[string]bool),
+		done:          make(chan struct{}),
+	}
+}
+
+// OnUpdate sets the handlers the feed streams quotes and depth to
+func (f *webSocketFeed) OnUpdate(onQuote func(quote *models.MarketQuote), onDepth func(depth *models.MarketDepth)) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	f.onQuote = onQuote
+	f.onDepth = onDepth
+}
+
+// Connect establishes a connection to the market data provider
+func (f *webSocketFeed) Connect() error {
+	if f.IsConnected() {
+		return nil
+	}
+
+	// Establish WebSocket connection
+	dialer := &websocket.Dialer{}
+	conn, _, err := dialer.Dial(f.streamURL(), nil)
+	if err != nil {
+		return err
+	}
+
+	f.mutex.Lock()
+	f.wsConn = conn
+	f.isConnected = true
+	f.mutex.Unlock()
+
+	// Start reading messages
+	go f.readMessages()
+
+	// Start reconnection ticker
+	if f.reconnectTicker == nil {
+		f.reconnectTicker = time.NewTicker(time.Second * 30)
+		go f.reconnectIfNeeded()
+	}
+
+	// Authentication message
+	authMsg := map[string]string{
+		"type": "auth",
+		"key":  f.apiKey,
+	}
+	if err := conn.WriteJSON(authMsg); err != nil {
+		f.Disconnect()
+		return err
+	}
+
+	return nil
+}
+
+// streamURL returns the WebSocket URL for the provider's base URL
+func (f *webSocketFeed) streamURL() string {
+	switch {
+	case strings.HasPrefix(f.apiURL, "https://"):
+		return "wss://" + strings.TrimPrefix(f.apiURL, "https://") + "/ws"
+	case strings.HasPrefix(f.apiURL, "http://"):
+		return "ws://" + strings.TrimPrefix(f.apiURL, "http://") + "/ws"
+	}
+	return f.apiURL + "/ws"
+}
+
+// Disconnect closes the connection
+func (f *webSocketFeed) Disconnect() error {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	if !f.isConnected {
+		return nil
+	}
+
+	// Stop reconnection ticker
+	if f.reconnectTicker != nil {
+		f.reconnectTicker.Stop()
+		f.reconnectTicker = nil
+	}
+
+	// Close done channel
+	close(f.done)
+	f.done = make(chan struct{})
+
+	// Close WebSocket connection
+	err := f.wsConn.Close()
+	f.isConnected = false
+	f.wsConn = nil
+	return err
+}
+
+// IsConnected returns the connection status
+func (f *webSocketFeed) IsConnected() bool {
+	f.mutex.RLock()
+	defer f.mutex.RUnlock()
+	return f.isConnected
+}
+
+// Subscribe to market data for a symbol
+func (f *webSocketFeed) Subscribe(symbol string, exchange string) error {
+	if !f.IsConnected() {
+		if err := f.Connect(); err != nil {
+			return err
+		}
+	}
+
+	key := fmt.Sprintf("%s:%s", exchange, symbol)
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	if f.subscriptions[key] {
+		return nil // Already subscribed
+	}
+
+	// Send subscription message
+	subMsg := map[string]string{
+		"type":     "subscribe",
+		"symbol":   symbol,
+		"exchange": exchange,
+	}
+	if err := f.wsConn.WriteJSON(subMsg); err != nil {
+		return err
+	}
+
+	f.subscriptions[key] = true
+	return nil
+}
+
+// Unsubscribe from market data for a symbol
+func (f *webSocketFeed) Unsubscribe(symbol string, exchange string) error {
+	if !f.IsConnected() {
+		return nil
+	}
+
+	key := fmt.Sprintf("%s:%s", exchange, symbol)
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	if !f.subscriptions[key] {
+		return nil // Not subscribed
+	}
+
+	// Send unsubscription message
+	unsubMsg := map[string]string{
+		"type":     "unsubscribe",
+		"symbol":   symbol,
+		"exchange": exchange,
+	}
+	if err := f.wsConn.WriteJSON(unsubMsg); err != nil {
+		return err
+	}
+
+	delete(f.subscriptions, key)
+	return nil
+}
+
+// readMessages reads messages from WebSocket
+func (f *webSocketFeed) readMessages() {
+	f.mutex.RLock()
+	conn, done := f.wsConn, f.done
+	f.mutex.RUnlock()
+
+	for {
+		select {
+		case <-done:
+			return
+		default:
+			_, message, err := conn.ReadMessage()
+			if err != nil {
+				log.Printf("Error reading message: %v", err)
+				f.handleDisconnect()
+				return
+			}
+
+			f.processMessage(message)
+		}
+	}
+}
+
+// processMessage processes a message from WebSocket
+func (f *webSocketFeed) processMessage(message []byte) {
+	// Determine message type
+	var msg map[string]interface{}
+	if err := json.Unmarshal(message, &msg); err != nil {
+		log.Printf("Error unmarshaling message: %v", err)
+		return
+	}
+
+	msgType, ok := msg["type"].(string)
+	if !ok {
+		log.Printf("Message missing type field")
+		return
+	}
+
+	f.mutex.RLock()
+	onQuote, onDepth := f.onQuote, f.onDepth
+	f.mutex.RUnlock()
+
+	switch msgType {
+	case "quote":
+		var quote models.MarketQuote
+		if err := json.Unmarshal(message, &quote); err != nil {
+			log.Printf("Error unmarshaling quote: %v", err)
+			return
+		}
+		if onQuote != nil {
+			onQuote(&quote)
+		}
+
+	case "depth":
+		var depth models.MarketDepth
+		if err := json.Unmarshal(message, &depth); err != nil {
+			log.Printf("Error unmarshaling depth: %v", err)
+			return
+		}
+		if onDepth != nil {
+			onDepth(&depth)
+		}
+
+	case "heartbeat":
+		// Just a keep-alive message, ignore
+
+	default:
+		log.Printf("Unknown message type: %s", msgType)
+	}
+}
+
+// reconnectIfNeeded tries to reconnect if the connection is lost
+func (f *webSocketFeed) reconnectIfNeeded() {
+	f.mutex.RLock()
+	ticker, done := f.reconnectTicker, f.done
+	f.mutex.RUnlock()
+
+	for {
+		select {
+		case <-done:
+			return
+		case <-ticker.C:
+			if f.IsConnected() {
+				continue
+			}
+
+			log.Println("Attempting to reconnect to market data service...")
+			if err := f.Connect(); err != nil {
+				log.Printf("Reconnection failed: %v", err)
+				continue
+			}
+
+			// Restore subscriptions
+			f.mutex.Lock()
+			subscriptions := f.subscriptions
+			f.subscriptions = make(map[string]bool)
+			f.mutex.Unlock()
+
+			for key := range subscriptions {
+				parts := splitKey(key)
+				if len(parts) == 2 {
+					if err := f.Subscribe(parts[1], parts[0]); err != nil {
+						log.Printf("Failed to restore subscription to %s: %v", key, err)
+					}
+				}
+			}
+		}
+	}
+}
+
+// handleDisconnect handles a disconnection event
+func (f *webSocketFeed) handleDisconnect() {
+	f.mutex.Lock()
+	f.isConnected = false
+	f.wsConn = nil
+	f.mutex.Unlock()
+}
+
+// FetchQuote fetches a quote snapshot from the provider
+func (f *webSocketFeed) FetchQuote(symbol string, exchange string) (*models.MarketQuote, error) {
+	var quote models.MarketQuote
+	if err := f.get("/quote", url.Values{"symbol": {symbol}, "exchange": {exchange}}, &quote); err != nil {
+		return nil, fmt.Errorf("failed to fetch quote for %s:%s: %w", exchange, symbol, err)
+	}
+	return &quote, nil
+}
+
+// FetchMarketDepth fetches a market depth snapshot from the provider
+func (f *webSocketFeed) FetchMarketDepth(symbol string, exchange string) (*models.MarketDepth, error) {
+	var depth models.MarketDepth
+	if err := f.get("/depth", url.Values{"symbol": {symbol}, "exchange": {exchange}}, &depth); err != nil {
+		return nil, fmt.Errorf("failed to fetch market depth for %s:%s: %w", exchange, symbol, err)
+	}
+	return &depth, nil
+}
+
+// FetchHistoricalData fetches candles from the provider
+func (f *webSocketFeed) FetchHistoricalData(symbol string, exchange string, interval string, startTime time.Time, endTime time.Time) (*models.HistoricalData, error) {
+	query := url.Values{
+		"symbol":   {symbol},
+		"exchange": {exchange},
+		"interval": {interval},
+		"from":     {startTime.Format(time.RFC3339)},
+		"to":       {endTime.Format(time.RFC3339)},
+	}
+
+	var data models.HistoricalData
+	if err := f.get("/historical", query, &data); err != nil {
+		return nil, fmt.Errorf("failed to fetch historical data for %s:%s: %w", exchange, symbol, err)
+	}
+	return &data, nil
+}
+
+// FetchSymbols fetches the instrument master from the provider
+func (f *webSocketFeed) FetchSymbols() ([]models.Symbol, error) {
+	var symbols []models.Symbol
+	if err := f.get("/symbols", nil, &symbols); err != nil {
+		return nil, fmt.Errorf("failed to fetch symbols: %w", err)
+	}
+	return symbols, nil
+}
+
+// FetchIndices fetches the market indices from the provider
+func (f *webSocketFeed) FetchIndices() ([]models.MarketIndex, error) {
+	var indices []models.MarketIndex
+	if err := f.get("/indices", nil, &indices); err != nil {
+		return nil, fmt.Errorf("failed to fetch indices: %w", err)
+	}
+	return indices, nil
+}
+
+// get calls a provider REST endpoint and decodes its JSON response into out
+func (f *webSocketFeed) get(path string, query url.Values, out interface{}) error {
+	endpoint := f.apiURL + path
+	if len(query) > 0 {
+		endpoint += "?" + query.Encode()
+	}
+
+	req, err := http.NewRequest(http.MethodGet, endpoint, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("X-API-Key", f.apiKey)
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := f.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("provider returned %s", resp.Status)
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
+
+// Helper function to split key into exchange and symbol
+func splitKey(key string) []string {
+	parts := strings.Split(key, ":")
+	return parts
+}